@@ -0,0 +1,556 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+)
+
+////// m,n,k-games: tic-tac-toe is the 3,3,3 member of a whole family. Players take turns placing
+// stones on an m x n board and whoever gets k in a row (horizontally, vertically or diagonally)
+// wins. Gomoku is 15,15,5. Add gravity, so stones fall to the lowest free cell of a column,
+// and 6,7,4 becomes Connect Four.
+
+type Mark int
+
+const (
+	Empty Mark = iota
+	X
+	O
+)
+
+var markName = map[Mark]string{
+	Empty: ".",
+	X:     "X",
+	O:     "O",
+}
+
+func (m Mark) String() string {
+	return markName[m]
+}
+
+func (m Mark) Opponent() Mark {
+	switch m {
+	case X:
+		return O
+	case O:
+		return X
+	default:
+		return Empty
+	}
+}
+
+var (
+	ErrOutOfBounds = errors.New("move is outside the board")
+	ErrOccupied    = errors.New("cell is already taken")
+	ErrFloating    = errors.New("stone must rest on the bottom or on another stone")
+	ErrColumnFull  = errors.New("column is full")
+	ErrGameOver    = errors.New("game is already over")
+	ErrNoMoves     = errors.New("no moves to undo")
+)
+
+// Rules describe a member of the m,n,k family.
+type Rules struct {
+	Rows, Cols int
+	K          int
+	Gravity    bool
+}
+
+var (
+	TicTacToe   = Rules{Rows: 3, Cols: 3, K: 3}
+	Gomoku      = Rules{Rows: 15, Cols: 15, K: 5}
+	ConnectFour = Rules{Rows: 6, Cols: 7, K: 4, Gravity: true}
+)
+
+// Game is a position in an m,n,k-game. Cells are stored row-major and a move is a cell index,
+// row*Cols + col. Row 0 is the top of the board, so with gravity stones settle on the last row.
+type Game struct {
+	Rules
+	cells   []Mark
+	history []int
+	winner  Mark
+	hash    uint64
+	keys    [][2]uint64
+	turnKey uint64
+}
+
+// NewGame returns an empty board for the given rules.
+func NewGame(r Rules) (*Game, error) {
+	if r.Rows < 1 || r.Cols < 1 || r.K < 1 {
+		return nil, fmt.Errorf("invalid rules %+v: sizes must be positive", r)
+	}
+	if r.K > r.Rows && r.K > r.Cols {
+		return nil, fmt.Errorf("invalid rules %+v: k does not fit on the board", r)
+	}
+	g := &Game{Rules: r, cells: make([]Mark, r.Rows*r.Cols)}
+	g.initZobrist()
+	return g, nil
+}
+
+////// Zobrist hashing: every (cell, player) pair gets a random 64-bit key and a position's hash
+// is the XOR of the keys of its stones. Playing or undoing a move is a single XOR, which makes
+// the hash cheap enough to key a transposition table.
+func (g *Game) initZobrist() {
+	// A fixed seed keeps hashes reproducible between runs.
+	rng := rand.New(rand.NewPCG(0x9e3779b97f4a7c15, uint64(g.Rows*1000+g.Cols)))
+	g.keys = make([][2]uint64, len(g.cells))
+	for i := range g.keys {
+		g.keys[i] = [2]uint64{rng.Uint64(), rng.Uint64()}
+	}
+	g.turnKey = rng.Uint64()
+}
+
+func (g *Game) key(cell int, m Mark) uint64 {
+	return g.keys[cell][m-X]
+}
+
+// Hash returns the Zobrist hash of the position, including whose turn it is.
+func (g *Game) Hash() uint64 {
+	return g.hash
+}
+
+func (g *Game) At(row, col int) Mark {
+	return g.cells[row*g.Cols+col]
+}
+
+func (g *Game) Turn() Mark {
+	if len(g.history)%2 == 0 {
+		return X
+	}
+	return O
+}
+
+func (g *Game) Winner() Mark {
+	return g.winner
+}
+
+func (g *Game) Over() bool {
+	return g.winner != Empty || len(g.history) == len(g.cells)
+}
+
+func (g *Game) Draw() bool {
+	return g.winner == Empty && len(g.history) == len(g.cells)
+}
+
+// legal reports whether a stone may go in the given empty cell.
+func (g *Game) legal(cell int) bool {
+	if g.cells[cell] != Empty {
+		return false
+	}
+	below := cell + g.Cols
+	return !g.Gravity || below >= len(g.cells) || g.cells[below] != Empty
+}
+
+// Play places a stone for the player to move at (row, col).
+func (g *Game) Play(row, col int) error {
+	if g.Over() {
+		return ErrGameOver
+	}
+	if row < 0 || row >= g.Rows || col < 0 || col >= g.Cols {
+		return fmt.Errorf("(%d, %d): %w", row, col, ErrOutOfBounds)
+	}
+	cell := row*g.Cols + col
+	if g.cells[cell] != Empty {
+		return fmt.Errorf("(%d, %d): %w", row, col, ErrOccupied)
+	}
+	if !g.legal(cell) {
+		return fmt.Errorf("(%d, %d): %w", row, col, ErrFloating)
+	}
+	g.play(cell)
+	return nil
+}
+
+// Drop plays in a column of a gravity game, landing on the lowest free cell.
+func (g *Game) Drop(col int) error {
+	if col < 0 || col >= g.Cols {
+		return fmt.Errorf("column %d: %w", col, ErrOutOfBounds)
+	}
+	for row := g.Rows - 1; row >= 0; row-- {
+		if g.cells[row*g.Cols+col] == Empty {
+			return g.Play(row, col)
+		}
+	}
+	return fmt.Errorf("column %d: %w", col, ErrColumnFull)
+}
+
+// play makes a move that is already known to be legal.
+func (g *Game) play(cell int) {
+	m := g.Turn()
+	g.cells[cell] = m
+	g.history = append(g.history, cell)
+	g.hash ^= g.key(cell, m) ^ g.turnKey
+	if g.wins(cell) {
+		g.winner = m
+	}
+}
+
+func (g *Game) Undo() error {
+	if len(g.history) == 0 {
+		return ErrNoMoves
+	}
+	g.undo()
+	return nil
+}
+
+func (g *Game) undo() {
+	cell := g.history[len(g.history)-1]
+	g.history = g.history[:len(g.history)-1]
+	g.hash ^= g.key(cell, g.cells[cell]) ^ g.turnKey
+	g.cells[cell] = Empty
+	// No move can follow a win, so whatever was undone is the position before it.
+	g.winner = Empty
+}
+
+var directions = [4][2]int{{0, 1}, {1, 0}, {1, 1}, {1, -1}}
+
+// wins reports whether the stone on cell completes k in a row. Only lines through the last
+// move need checking, since the game would have ended already otherwise.
+func (g *Game) wins(cell int) bool {
+	m := g.cells[cell]
+	r0, c0 := cell/g.Cols, cell%g.Cols
+	for _, d := range directions {
+		n := 1
+		for _, sign := range [2]int{1, -1} {
+			r, c := r0+sign*d[0], c0+sign*d[1]
+			for r >= 0 && r < g.Rows && c >= 0 && c < g.Cols && g.cells[r*g.Cols+c] == m {
+				n++
+				r, c = r+sign*d[0], c+sign*d[1]
+			}
+		}
+		if n >= g.K {
+			return true
+		}
+	}
+	return false
+}
+
+// Moves returns every legal move as a cell index.
+func (g *Game) Moves() []int {
+	if g.Over() {
+		return nil
+	}
+	var moves []int
+	for cell := range g.cells {
+		if g.legal(cell) {
+			moves = append(moves, cell)
+		}
+	}
+	return moves
+}
+
+func (g *Game) String() string {
+	var sb strings.Builder
+	for r := 0; r < g.Rows; r++ {
+		row := make([]string, g.Cols)
+		for c := 0; c < g.Cols; c++ {
+			row[c] = g.cells[r*g.Cols+c].String()
+		}
+		sb.WriteString(strings.Join(row, " "))
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+////// perft: count the positions reachable in exactly depth moves, stopping at finished games.
+// The numbers are known for tic-tac-toe and Connect Four, so 10-mnk-game_test.go uses them to
+// check move generation.
+func (g *Game) Perft(depth int) int {
+	if depth == 0 {
+		return 1
+	}
+	moves := g.Moves()
+	if depth == 1 {
+		return len(moves)
+	}
+	n := 0
+	for _, cell := range moves {
+		g.play(cell)
+		n += g.Perft(depth - 1)
+		g.undo()
+	}
+	return n
+}
+
+////// iterative-deepening alpha-beta: search to depth 1, then 2, then 3... until the time budget
+// runs out, and play the best move of the deepest search that finished. The transposition table
+// remembers earlier results by Zobrist hash, both to skip repeated positions and to try the
+// previously best move first, which makes alpha-beta cut much more.
+
+// A win scores winScore minus the number of moves from the root, so faster wins score higher.
+const winScore = 1_000_000
+
+// isMate reports whether score is a forced win or loss rather than a heuristic value.
+func isMate(score int) bool {
+	return score > winScore-1000 || score < -winScore+1000
+}
+
+// The table may return a position at a different distance from the root than where it was
+// stored, so mate scores are stored as distance from the position itself and converted back.
+func scoreToTT(score, ply int) int {
+	switch {
+	case score > winScore-1000:
+		return score + ply
+	case score < -winScore+1000:
+		return score - ply
+	}
+	return score
+}
+
+func scoreFromTT(score, ply int) int {
+	switch {
+	case score > winScore-1000:
+		return score - ply
+	case score < -winScore+1000:
+		return score + ply
+	}
+	return score
+}
+
+type bound int
+
+const (
+	exact bound = iota
+	lower
+	upper
+)
+
+type ttEntry struct {
+	depth int
+	score int
+	bound bound
+	best  int
+}
+
+// AI searches for moves in a Game.
+type AI struct {
+	MaxDepth int
+	Budget   time.Duration
+
+	table     map[uint64]ttEntry
+	deadline  time.Time
+	rootDepth int
+	nodes     int
+	aborted   bool
+}
+
+// SearchResult describes the outcome of BestMove.
+type SearchResult struct {
+	Row, Col int
+	Score    int
+	Depth    int
+	Nodes    int
+	Elapsed  time.Duration
+}
+
+func NewAI(maxDepth int, budget time.Duration) *AI {
+	return &AI{MaxDepth: maxDepth, Budget: budget, table: make(map[uint64]ttEntry)}
+}
+
+// BestMove searches the position for the player to move within the AI's time budget. The depth
+// 1 search always finishes, even past the budget, so the move played has been looked at.
+func (ai *AI) BestMove(g *Game) (SearchResult, error) {
+	moves := g.Moves()
+	if len(moves) == 0 {
+		return SearchResult{}, ErrGameOver
+	}
+	start := time.Now()
+	ai.deadline = start.Add(ai.Budget)
+	ai.nodes = 0
+	ai.aborted = false
+
+	var res SearchResult
+	for depth := 1; depth == 1 || depth <= ai.MaxDepth; depth++ {
+		ai.rootDepth = depth
+		score := ai.negamax(g, depth, 0, -winScore-1, winScore+1)
+		if ai.aborted {
+			break
+		}
+		best := ai.table[g.hash].best
+		res.Row, res.Col, res.Score, res.Depth = best/g.Cols, best%g.Cols, score, depth
+		// A forced win or loss was found; deeper searches won't change it.
+		if isMate(score) {
+			break
+		}
+	}
+	res.Nodes = ai.nodes
+	res.Elapsed = time.Since(start)
+	return res, nil
+}
+
+// negamax scores the position from the point of view of the player to move.
+func (ai *AI) negamax(g *Game, depth, ply, alpha, beta int) int {
+	ai.nodes++
+	if ai.nodes%1024 == 0 && ai.rootDepth > 1 && time.Now().After(ai.deadline) {
+		ai.aborted = true
+	}
+	if ai.aborted {
+		return 0
+	}
+	if g.winner != Empty {
+		// The previous player just won.
+		return -winScore + ply
+	}
+	if len(g.history) == len(g.cells) {
+		return 0
+	}
+
+	alpha0 := alpha
+	entry, found := ai.table[g.hash]
+	if found && entry.depth >= depth && ply > 0 {
+		score := scoreFromTT(entry.score, ply)
+		switch {
+		case entry.bound == exact:
+			return score
+		case entry.bound == lower && score >= beta:
+			return score
+		case entry.bound == upper && score <= alpha:
+			return score
+		}
+	}
+	if depth == 0 {
+		return g.evaluate()
+	}
+
+	moves := g.candidates()
+	if found {
+		// Try the move that was best last time first.
+		for i, cell := range moves {
+			if cell == entry.best {
+				moves[0], moves[i] = moves[i], moves[0]
+				break
+			}
+		}
+	}
+
+	best, bestScore := moves[0], -winScore-1
+	for _, cell := range moves {
+		g.play(cell)
+		score := -ai.negamax(g, depth-1, ply+1, -beta, -alpha)
+		g.undo()
+		if ai.aborted {
+			return 0
+		}
+		if score > bestScore {
+			best, bestScore = cell, score
+		}
+		alpha = max(alpha, score)
+		if alpha >= beta {
+			break
+		}
+	}
+
+	e := ttEntry{depth: depth, score: scoreToTT(bestScore, ply), best: best, bound: exact}
+	switch {
+	case bestScore <= alpha0:
+		e.bound = upper
+	case bestScore >= beta:
+		e.bound = lower
+	}
+	ai.table[g.hash] = e
+	return bestScore
+}
+
+// candidates returns the moves worth searching. On big open boards like Gomoku only cells next
+// to an existing stone are considered; a stone far from the action is almost never best.
+func (g *Game) candidates() []int {
+	if g.Gravity || len(g.cells) <= 25 {
+		return g.Moves()
+	}
+	if len(g.history) == 0 {
+		return []int{(g.Rows/2)*g.Cols + g.Cols/2}
+	}
+	var moves []int
+	for cell, m := range g.cells {
+		if m != Empty {
+			continue
+		}
+		r, c := cell/g.Cols, cell%g.Cols
+	neighbours:
+		for dr := -1; dr <= 1; dr++ {
+			for dc := -1; dc <= 1; dc++ {
+				rr, cc := r+dr, c+dc
+				if rr >= 0 && rr < g.Rows && cc >= 0 && cc < g.Cols && g.cells[rr*g.Cols+cc] != Empty {
+					moves = append(moves, cell)
+					break neighbours
+				}
+			}
+		}
+	}
+	return moves
+}
+
+// evaluate is the heuristic score of an unfinished position for the player to move. Every
+// window of k cells that only one player has stones in is worth 4^stones to that player.
+func (g *Game) evaluate() int {
+	me := g.Turn()
+	score := 0
+	for cell := range g.cells {
+		r0, c0 := cell/g.Cols, cell%g.Cols
+		for _, d := range directions {
+			rEnd, cEnd := r0+(g.K-1)*d[0], c0+(g.K-1)*d[1]
+			if rEnd < 0 || rEnd >= g.Rows || cEnd < 0 || cEnd >= g.Cols {
+				continue
+			}
+			mine, theirs := 0, 0
+			for i := 0; i < g.K; i++ {
+				switch g.cells[(r0+i*d[0])*g.Cols+c0+i*d[1]] {
+				case me:
+					mine++
+				case me.Opponent():
+					theirs++
+				}
+			}
+			switch {
+			case mine > 0 && theirs == 0:
+				score += 1 << (2 * mine)
+			case theirs > 0 && mine == 0:
+				score -= 1 << (2 * theirs)
+			}
+		}
+	}
+	return score
+}
+
+func tryMNKGames() {
+	// tic-tac-toe as a 3,3,3-game: perfect play draws.
+	ttt, _ := NewGame(TicTacToe)
+	ai := NewAI(9, time.Second)
+	for !ttt.Over() {
+		res, _ := ai.BestMove(ttt)
+		ttt.Play(res.Row, res.Col)
+	}
+	fmt.Print(ttt)
+	fmt.Println("draw:", ttt.Draw())
+
+	// Connect Four: gravity makes floating stones illegal.
+	c4, _ := NewGame(ConnectFour)
+	fmt.Println(c4.Play(0, 3))
+	for _, col := range []int{3, 3, 4, 4, 5, 5} {
+		c4.Drop(col)
+	}
+	fmt.Print(c4)
+	// X has three on the bottom row and it is X's turn, so the search finds the win.
+	res, _ := NewAI(8, 500*time.Millisecond).BestMove(c4)
+	fmt.Printf("X plays (%d, %d) at depth %d after %d nodes, score %d\n", res.Row, res.Col, res.Depth, res.Nodes, res.Score)
+	c4.Play(res.Row, res.Col)
+	fmt.Println("winner:", c4.Winner())
+
+	// Gomoku on a 15x15 board with a per-move time budget.
+	gomoku, _ := NewGame(Gomoku)
+	ai = NewAI(6, 200*time.Millisecond)
+	for i := 0; i < 10 && !gomoku.Over(); i++ {
+		res, _ := ai.BestMove(gomoku)
+		gomoku.Play(res.Row, res.Col)
+		fmt.Printf("%v plays (%d, %d): depth %d, %d nodes, %v\n",
+			gomoku.cells[res.Row*gomoku.Cols+res.Col], res.Row, res.Col, res.Depth, res.Nodes, res.Elapsed.Round(time.Millisecond))
+	}
+	fmt.Print(gomoku)
+	fmt.Printf("hash: %#x\n", gomoku.Hash())
+}
+
+func main() {
+	// m,n,k-games
+	tryMNKGames()
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPerft(t *testing.T) {
+	// Known perft values; finished games are not expanded further.
+	tests := []struct {
+		name  string
+		rules Rules
+		want  []int
+	}{
+		{"tic-tac-toe", TicTacToe, []int{9, 72, 504, 3024, 15120, 54720, 148176, 200448, 127872}},
+		{"connect four", ConnectFour, []int{7, 49, 343, 2401, 16807, 117649}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, _ := NewGame(tt.rules)
+			for i, want := range tt.want {
+				if got := g.Perft(i + 1); got != want {
+					t.Errorf("perft(%d) = %d, want %d", i+1, got, want)
+				}
+			}
+		})
+	}
+}
+
+// A budget that runs out during depth 1 still gets a searched move, not just the first legal
+// one. The board is big enough that depth 1 alone goes past the first deadline check.
+func TestBestMoveFinishesDepthOne(t *testing.T) {
+	g, _ := NewGame(Rules{Rows: 50, Cols: 50, K: 5})
+	// Scattered pairs of stones, so there are many candidate moves...
+	for r := 2; r < 46; r += 4 {
+		for c := 2; c < 46; c += 4 {
+			g.Play(r, c)
+			g.Play(r, c+1)
+		}
+	}
+	// ...and four X stones in a row at the bottom right, with X to move.
+	for c := 30; c < 34; c++ {
+		g.Play(49, c)
+		g.Play(0, c)
+	}
+	if len(g.candidates()) < 1024 {
+		t.Fatalf("only %d candidates", len(g.candidates()))
+	}
+
+	res, err := NewAI(3, 0).BestMove(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Depth != 1 || res.Row != 49 || (res.Col != 29 && res.Col != 34) {
+		t.Errorf("BestMove = %+v, want the win at (49, 29) or (49, 34) from depth 1", res)
+	}
+}
+
+// Reusing the transposition table from the previous move must not change mate scores: they
+// count moves from the current position, not from wherever the entry was stored.
+func TestMateScoresSurviveTableReuse(t *testing.T) {
+	var walk func(g *Game, depth int)
+	walk = func(g *Game, depth int) {
+		for _, cell := range g.Moves() {
+			warm := NewAI(9, time.Minute)
+			warm.BestMove(g)
+			g.play(cell)
+			if !g.Over() {
+				got, _ := warm.BestMove(g)
+				want, _ := NewAI(9, time.Minute).BestMove(g)
+				if got.Score != want.Score {
+					t.Errorf("after %v: score %d with a reused table, %d without", g.history, got.Score, want.Score)
+				}
+				if depth > 1 {
+					walk(g, depth-1)
+				}
+			}
+			g.undo()
+		}
+	}
+	g, _ := NewGame(TicTacToe)
+	walk(g, 4)
+}
@@ -0,0 +1,885 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	b64 "encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+////// networked tic-tac-toe: the net/http server from tryHttpServer (8-extra-part-2.go) grows into a
+// game server. Two clients join a room over a WebSocket, send moves, and the server checks them
+// against the tic-tac-toe rules and pushes the new board to both players.
+//
+//	go run 11-tictactoe-server.go serve :8090
+//	go run 11-tictactoe-server.go play http://localhost:8090          (creates a room)
+//	go run 11-tictactoe-server.go play http://localhost:8090 room-1   (joins it)
+
+type Mark int
+
+const (
+	Empty Mark = iota
+	X
+	O
+)
+
+var markName = map[Mark]string{
+	Empty: "_",
+	X:     "X",
+	O:     "O",
+}
+
+func (m Mark) String() string {
+	return markName[m]
+}
+
+var (
+	ErrOutOfBounds = errors.New("move is outside the board")
+	ErrOccupied    = errors.New("cell is already taken")
+	ErrNotYourTurn = errors.New("it is not your turn")
+	ErrNotPlaying  = errors.New("game is not in progress")
+	ErrRoomFull    = errors.New("room is full")
+	ErrNoRoom      = errors.New("no such room")
+)
+
+// Board holds the rules, the same ones createTicTacToeBoard (3-more-types.go) lays out by hand.
+type Board struct {
+	cells [9]Mark
+	moves int
+}
+
+var lines = [8][3]int{
+	{0, 1, 2}, {3, 4, 5}, {6, 7, 8},
+	{0, 3, 6}, {1, 4, 7}, {2, 5, 8},
+	{0, 4, 8}, {2, 4, 6},
+}
+
+func (b *Board) Turn() Mark {
+	if b.moves%2 == 0 {
+		return X
+	}
+	return O
+}
+
+func (b *Board) Winner() Mark {
+	for _, l := range lines {
+		if m := b.cells[l[0]]; m != Empty && m == b.cells[l[1]] && m == b.cells[l[2]] {
+			return m
+		}
+	}
+	return Empty
+}
+
+func (b *Board) Over() bool {
+	return b.Winner() != Empty || b.moves == 9
+}
+
+func (b *Board) Play(row, col int) error {
+	if row < 0 || row > 2 || col < 0 || col > 2 {
+		return fmt.Errorf("(%d, %d): %w", row, col, ErrOutOfBounds)
+	}
+	if b.cells[row*3+col] != Empty {
+		return fmt.Errorf("(%d, %d): %w", row, col, ErrOccupied)
+	}
+	b.cells[row*3+col] = b.Turn()
+	b.moves++
+	return nil
+}
+
+// String packs the board into nine characters, row by row.
+func (b *Board) String() string {
+	var sb strings.Builder
+	for _, m := range b.cells {
+		sb.WriteString(m.String())
+	}
+	return sb.String()
+}
+
+////// WebSocket: just enough of RFC 6455 for small text messages. The handshake is an HTTP
+// upgrade; after that both sides exchange frames over the raw connection. Frames sent by a
+// client must be masked, frames sent by a server must not be.
+
+const wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+const (
+	opText  = 0x1
+	opClose = 0x8
+	opPing  = 0x9
+	opPong  = 0xA
+)
+
+// wsWriteTimeout bounds every write, so a client that stops reading can hold up the room it's
+// in for at most this long; after that its connection is dropped.
+const wsWriteTimeout = 5 * time.Second
+
+type wsConn struct {
+	conn    net.Conn
+	br      *bufio.Reader
+	client  bool
+	timeout time.Duration // per write; 0 means none
+	wmu     sync.Mutex
+}
+
+func wsAccept(key string) string {
+	h := sha1.Sum([]byte(key + wsGUID))
+	return b64.StdEncoding.EncodeToString(h[:])
+}
+
+// wsUpgrade turns an HTTP request into a WebSocket connection on the server side.
+func wsUpgrade(w http.ResponseWriter, req *http.Request) (*wsConn, error) {
+	key := req.Header.Get("Sec-WebSocket-Key")
+	if !strings.EqualFold(req.Header.Get("Upgrade"), "websocket") || key == "" {
+		http.Error(w, "expected a websocket upgrade", http.StatusBadRequest)
+		return nil, errors.New("websocket: not an upgrade request")
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "websocket not supported", http.StatusInternalServerError)
+		return nil, errors.New("websocket: response does not support hijacking")
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n", wsAccept(key))
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &wsConn{conn: conn, br: rw.Reader, timeout: wsWriteTimeout}, nil
+}
+
+// wsDial opens a client WebSocket connection to a ws:// or wss:// URL. tlsConfig is used for
+// wss and may be nil for the defaults.
+func wsDial(rawURL string, tlsConfig *tls.Config) (*wsConn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	host := u.Host
+	var conn net.Conn
+	switch u.Scheme {
+	case "ws":
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "80")
+		}
+		conn, err = net.Dial("tcp", host)
+	case "wss":
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "443")
+		}
+		cfg := &tls.Config{}
+		if tlsConfig != nil {
+			cfg = tlsConfig.Clone()
+		}
+		if cfg.ServerName == "" {
+			cfg.ServerName = u.Hostname()
+		}
+		conn, err = tls.Dial("tcp", host, cfg)
+	default:
+		return nil, fmt.Errorf("websocket: unsupported scheme %q", u.Scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	key := b64.StdEncoding.EncodeToString(nonce)
+	fmt.Fprintf(conn, "GET %s HTTP/1.1\r\nHost: %s\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Key: %s\r\nSec-WebSocket-Version: 13\r\n\r\n", u.RequestURI(), u.Host, key)
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		body, _ := io.ReadAll(resp.Body)
+		conn.Close()
+		return nil, fmt.Errorf("websocket: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != wsAccept(key) {
+		conn.Close()
+		return nil, errors.New("websocket: bad Sec-WebSocket-Accept")
+	}
+	return &wsConn{conn: conn, br: br, client: true, timeout: wsWriteTimeout}, nil
+}
+
+func (c *wsConn) writeFrame(op byte, payload []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	header := []byte{0x80 | op}
+	var maskBit byte
+	if c.client {
+		maskBit = 0x80
+	}
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, maskBit|byte(n))
+	case n <= 0xFFFF:
+		header = append(header, maskBit|126)
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header = append(header, maskBit|127)
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+	if c.client {
+		mask := make([]byte, 4)
+		rand.Read(mask)
+		header = append(header, mask...)
+		masked := make([]byte, len(payload))
+		for i, b := range payload {
+			masked[i] = b ^ mask[i%4]
+		}
+		payload = masked
+	}
+	if c.timeout > 0 {
+		c.conn.SetWriteDeadline(time.Now().Add(c.timeout))
+	}
+	if _, err := c.conn.Write(header); err != nil {
+		return err
+	}
+	_, err := c.conn.Write(payload)
+	return err
+}
+
+// WriteMessage sends one text message.
+func (c *wsConn) WriteMessage(p []byte) error {
+	return c.writeFrame(opText, p)
+}
+
+// ReadMessage returns the next text message, answering pings on the way. Fragmented
+// messages are not supported; none of our messages come close to needing them.
+func (c *wsConn) ReadMessage() ([]byte, error) {
+	for {
+		var h [2]byte
+		if _, err := io.ReadFull(c.br, h[:]); err != nil {
+			return nil, err
+		}
+		if h[0]&0x80 == 0 {
+			return nil, errors.New("websocket: fragmented messages are not supported")
+		}
+		op := h[0] & 0x0F
+		n := uint64(h[1] & 0x7F)
+		switch n {
+		case 126:
+			var ext [2]byte
+			if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+				return nil, err
+			}
+			n = uint64(binary.BigEndian.Uint16(ext[:]))
+		case 127:
+			var ext [8]byte
+			if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+				return nil, err
+			}
+			n = binary.BigEndian.Uint64(ext[:])
+		}
+		if n > 1<<20 {
+			return nil, errors.New("websocket: message too large")
+		}
+		var mask [4]byte
+		masked := h[1]&0x80 != 0
+		if masked {
+			if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+				return nil, err
+			}
+		}
+		payload := make([]byte, n)
+		if _, err := io.ReadFull(c.br, payload); err != nil {
+			return nil, err
+		}
+		if masked {
+			for i := range payload {
+				payload[i] ^= mask[i%4]
+			}
+		}
+
+		switch op {
+		case opText:
+			return payload, nil
+		case opPing:
+			c.writeFrame(opPong, payload)
+		case opClose:
+			c.writeFrame(opClose, nil)
+			return nil, io.EOF
+		}
+	}
+}
+
+func (c *wsConn) Close() error {
+	c.writeFrame(opClose, nil)
+	return c.conn.Close()
+}
+
+// abort closes the connection without a close frame, for a peer that can't be written to.
+func (c *wsConn) abort() {
+	c.conn.Close()
+}
+
+////// protocol: JSON messages over the socket. Clients only ever send moves; the server answers
+// with "joined" once, then a "state" after every change, or an "error" for a rejected move.
+
+type ClientMessage struct {
+	Type string `json:"type"`
+	Row  int    `json:"row"`
+	Col  int    `json:"col"`
+}
+
+type ServerMessage struct {
+	Type    string `json:"type"`
+	Room    string `json:"room,omitempty"`
+	You     string `json:"you,omitempty"`
+	Token   string `json:"token,omitempty"`
+	Board   string `json:"board,omitempty"`
+	Turn    string `json:"turn,omitempty"`
+	Status  string `json:"status,omitempty"`
+	Result  string `json:"result,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+const (
+	StatusWaiting = "waiting"
+	StatusPlaying = "playing"
+	StatusOver    = "over"
+)
+
+// seat is one player's place in a room. The token lets a player who lost their connection
+// come back to the same seat before the forfeit timer fires.
+type seat struct {
+	mark    Mark
+	token   string
+	conn    *wsConn
+	forfeit *time.Timer
+}
+
+type Room struct {
+	ID string
+
+	mu     sync.Mutex
+	board  Board
+	seats  [2]*seat
+	status string
+	result string
+}
+
+type RoomInfo struct {
+	ID      string `json:"id"`
+	Players int    `json:"players"`
+	Status  string `json:"status"`
+}
+
+// Server hosts rooms. A player who disconnects mid-game forfeits after ForfeitAfter.
+type Server struct {
+	ForfeitAfter time.Duration
+
+	mu     sync.Mutex
+	rooms  map[string]*Room
+	nextID int
+}
+
+func NewServer(forfeitAfter time.Duration) *Server {
+	return &Server{ForfeitAfter: forfeitAfter, rooms: make(map[string]*Room)}
+}
+
+// Handler returns the routes, next to the hello and headers handlers from tryHttpServer.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hello", hello)
+	mux.HandleFunc("/headers", headers)
+	mux.HandleFunc("GET /rooms", s.listRooms)
+	mux.HandleFunc("POST /rooms", s.createRoom)
+	mux.HandleFunc("GET /rooms/{id}/ws", s.join)
+	return mux
+}
+
+func hello(w http.ResponseWriter, req *http.Request) {
+	fmt.Fprintf(w, "hello\n")
+}
+
+func headers(w http.ResponseWriter, req *http.Request) {
+	for name, headers := range req.Header {
+		for _, h := range headers {
+			fmt.Fprintf(w, "%v: %v\n", name, h)
+		}
+	}
+}
+
+func (s *Server) listRooms(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	infos := make([]RoomInfo, 0, len(s.rooms))
+	for _, r := range s.rooms {
+		infos = append(infos, r.info())
+	}
+	s.mu.Unlock()
+	slices.SortFunc(infos, func(a, b RoomInfo) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(infos)
+}
+
+func (s *Server) createRoom(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	s.nextID++
+	r := &Room{ID: "room-" + strconv.Itoa(s.nextID), status: StatusWaiting}
+	s.rooms[r.ID] = r
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(r.info())
+}
+
+func (s *Server) join(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	r, ok := s.rooms[req.PathValue("id")]
+	s.mu.Unlock()
+	if !ok {
+		http.Error(w, ErrNoRoom.Error(), http.StatusNotFound)
+		return
+	}
+
+	// Pick the seat before upgrading, so a full room is an ordinary HTTP error. A new seat
+	// has no connection until the upgrade succeeds, and is given back if it doesn't.
+	token := req.URL.Query().Get("token")
+	r.mu.Lock()
+	st, err := r.claim(token)
+	r.mu.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	conn, err := wsUpgrade(w, req)
+	if err != nil {
+		if token == "" {
+			r.mu.Lock()
+			r.release(st)
+			r.mu.Unlock()
+		}
+		return
+	}
+
+	r.mu.Lock()
+	// A player reconnecting with their token replaces their old connection; closing it ends
+	// the old handler, whose leave then sees it is no longer current.
+	if old := st.conn; old != nil {
+		old.abort()
+	}
+	st.conn = conn
+	if st.forfeit != nil {
+		st.forfeit.Stop()
+		st.forfeit = nil
+	}
+	if r.status == StatusWaiting && r.seats[0] != nil && r.seats[1] != nil {
+		r.status = StatusPlaying
+	}
+	send(conn, ServerMessage{Type: "joined", Room: r.ID, You: st.mark.String(), Token: st.token})
+	r.broadcast()
+	r.mu.Unlock()
+
+	for {
+		data, err := conn.ReadMessage()
+		if err != nil {
+			break
+		}
+		var msg ClientMessage
+		if err := json.Unmarshal(data, &msg); err != nil || msg.Type != "move" {
+			send(conn, ServerMessage{Type: "error", Message: "expected {\"type\":\"move\",\"row\":r,\"col\":c}"})
+			continue
+		}
+		r.mu.Lock()
+		if err := r.move(st, msg.Row, msg.Col); err != nil {
+			send(conn, ServerMessage{Type: "error", Message: err.Error()})
+		} else {
+			r.broadcast()
+		}
+		r.mu.Unlock()
+	}
+
+	conn.Close()
+	r.mu.Lock()
+	s.leave(r, st, conn)
+	r.mu.Unlock()
+}
+
+// claim returns the seat for a returning token, or a free seat for a new player.
+func (r *Room) claim(token string) (*seat, error) {
+	if token != "" {
+		for _, st := range r.seats {
+			if st != nil && st.token == token {
+				return st, nil
+			}
+		}
+		return nil, errors.New("unknown token")
+	}
+	if r.status == StatusOver {
+		return nil, ErrNotPlaying
+	}
+	for i, st := range r.seats {
+		if st == nil {
+			r.seats[i] = &seat{mark: Mark(i + 1), token: newToken()}
+			return r.seats[i], nil
+		}
+	}
+	return nil, ErrRoomFull
+}
+
+// release frees a seat that never got a connection. r.mu must be held.
+func (r *Room) release(st *seat) {
+	if st.conn == nil && r.seats[st.mark-1] == st {
+		r.seats[st.mark-1] = nil
+	}
+}
+
+func (r *Room) move(st *seat, row, col int) error {
+	if r.status != StatusPlaying {
+		return ErrNotPlaying
+	}
+	if r.board.Turn() != st.mark {
+		return ErrNotYourTurn
+	}
+	if err := r.board.Play(row, col); err != nil {
+		return err
+	}
+	if w := r.board.Winner(); w != Empty {
+		r.status, r.result = StatusOver, w.String()+" wins"
+	} else if r.board.Over() {
+		r.status, r.result = StatusOver, "draw"
+	}
+	return nil
+}
+
+// leave handles a dropped connection. Before the game starts the seat is simply freed;
+// during a game the player gets ForfeitAfter to reconnect with their token.
+func (s *Server) leave(r *Room, st *seat, conn *wsConn) {
+	if st.conn != conn {
+		// The player already reconnected on a newer connection.
+		return
+	}
+	st.conn = nil
+	switch r.status {
+	case StatusWaiting:
+		r.seats[st.mark-1] = nil
+	case StatusPlaying:
+		st.forfeit = time.AfterFunc(s.ForfeitAfter, func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			if st.conn != nil || r.status != StatusPlaying {
+				return
+			}
+			other := X
+			if st.mark == X {
+				other = O
+			}
+			r.status, r.result = StatusOver, other.String()+" wins by forfeit"
+			r.broadcast()
+		})
+	}
+}
+
+func (r *Room) info() RoomInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, st := range r.seats {
+		if st != nil {
+			n++
+		}
+	}
+	return RoomInfo{ID: r.ID, Players: n, Status: r.status}
+}
+
+// broadcast pushes the current state to every connected player. r.mu must be held, so each
+// write is bounded by the connection's write timeout; a player whose write fails is cut off,
+// and their handler's leave takes it from there.
+func (r *Room) broadcast() {
+	msg := ServerMessage{
+		Type:   "state",
+		Room:   r.ID,
+		Board:  r.board.String(),
+		Turn:   r.board.Turn().String(),
+		Status: r.status,
+		Result: r.result,
+	}
+	for _, st := range r.seats {
+		if st != nil && st.conn != nil {
+			if err := send(st.conn, msg); err != nil {
+				st.conn.abort()
+			}
+		}
+	}
+}
+
+func send(conn *wsConn, msg ServerMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return conn.WriteMessage(data)
+}
+
+func newToken() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+////// client: a Go client for the server, used by the terminal player below and by the demo.
+
+// HTTPClient makes the client's HTTP requests. Join takes its TLS settings from it for wss, so
+// pointing it at an httptest.NewTLSServer's Client() makes the whole client trust that server.
+var HTTPClient = http.DefaultClient
+
+type Client struct {
+	BaseURL string
+	Room    string
+	Mark    string
+	Token   string
+	ws      *wsConn
+}
+
+// CreateRoom asks the server for a new room and returns its id.
+func CreateRoom(baseURL string) (string, error) {
+	resp, err := HTTPClient.Post(baseURL+"/rooms", "application/json", nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var info RoomInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return "", err
+	}
+	return info.ID, nil
+}
+
+func ListRooms(baseURL string) ([]RoomInfo, error) {
+	resp, err := HTTPClient.Get(baseURL + "/rooms")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var infos []RoomInfo
+	err = json.NewDecoder(resp.Body).Decode(&infos)
+	return infos, err
+}
+
+// Join connects to a room. Pass the token from an earlier Join to get the same seat back; the
+// earlier connection, if still open, is closed by the server. http URLs connect with ws and
+// https URLs with wss.
+func Join(baseURL, room, token string) (*Client, error) {
+	wsURL := "ws" + strings.TrimPrefix(baseURL, "http") + "/rooms/" + url.PathEscape(room) + "/ws"
+	if token != "" {
+		wsURL += "?token=" + url.QueryEscape(token)
+	}
+	var tlsConfig *tls.Config
+	if t, ok := HTTPClient.Transport.(*http.Transport); ok {
+		tlsConfig = t.TLSClientConfig
+	}
+	ws, err := wsDial(wsURL, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{BaseURL: baseURL, Room: room, ws: ws}
+	msg, err := c.Next()
+	if err != nil {
+		ws.Close()
+		return nil, err
+	}
+	c.Mark, c.Token = msg.You, msg.Token
+	return c, nil
+}
+
+func (c *Client) Move(row, col int) error {
+	data, _ := json.Marshal(ClientMessage{Type: "move", Row: row, Col: col})
+	return c.ws.WriteMessage(data)
+}
+
+// Next blocks until the server pushes a message.
+func (c *Client) Next() (ServerMessage, error) {
+	var msg ServerMessage
+	data, err := c.ws.ReadMessage()
+	if err != nil {
+		return msg, err
+	}
+	err = json.Unmarshal(data, &msg)
+	return msg, err
+}
+
+// WaitFor skips messages until one matches.
+func (c *Client) WaitFor(match func(ServerMessage) bool) (ServerMessage, error) {
+	for {
+		msg, err := c.Next()
+		if err != nil || match(msg) {
+			return msg, err
+		}
+	}
+}
+
+func (c *Client) Close() error {
+	return c.ws.Close()
+}
+
+func printBoard(board string) {
+	for r := 0; r < 3; r++ {
+		fmt.Println(strings.Join(strings.Split(board[r*3:r*3+3], ""), " "))
+	}
+}
+
+// playRemote is the terminal client: moves are read from stdin, updates printed as they arrive.
+func playRemote(baseURL, room string) error {
+	if room == "" {
+		id, err := CreateRoom(baseURL)
+		if err != nil {
+			return err
+		}
+		room = id
+	}
+	c, err := Join(baseURL, room, "")
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	fmt.Printf("joined %s as %s. Enter moves as \"row col\".\n", c.Room, c.Mark)
+
+	go func() {
+		in := bufio.NewScanner(os.Stdin)
+		for in.Scan() {
+			var r, col int
+			if _, err := fmt.Sscan(in.Text(), &r, &col); err != nil {
+				fmt.Println("expected \"row col\"")
+				continue
+			}
+			c.Move(r, col)
+		}
+		c.Close()
+	}()
+
+	for {
+		msg, err := c.Next()
+		if err != nil {
+			return nil
+		}
+		switch msg.Type {
+		case "error":
+			fmt.Println("error:", msg.Message)
+		case "state":
+			printBoard(msg.Board)
+			switch {
+			case msg.Status == StatusOver:
+				fmt.Println(msg.Result)
+				return nil
+			case msg.Status == StatusWaiting:
+				fmt.Println("waiting for an opponent...")
+			case msg.Turn == c.Mark:
+				fmt.Print("your move> ")
+			}
+		}
+	}
+}
+
+////// integration demo: a real server on a loopback port via httptest.Server, two clients playing
+// a full game, then a player dropping out and losing by forfeit.
+func tryGameServer() {
+	srv := httptest.NewServer(NewServer(200 * time.Millisecond).Handler())
+	defer srv.Close()
+
+	room, _ := CreateRoom(srv.URL)
+	alice, err := Join(srv.URL, room, "")
+	if err != nil {
+		panic(err)
+	}
+	bob, err := Join(srv.URL, room, "")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("alice is", alice.Mark, "bob is", bob.Mark)
+
+	// A third player is turned away.
+	_, err = Join(srv.URL, room, "")
+	fmt.Println("third player:", err)
+
+	playing := func(m ServerMessage) bool { return m.Type == "state" && m.Status == StatusPlaying }
+	alice.WaitFor(playing)
+	bob.WaitFor(playing)
+
+	// Moving out of turn is rejected and only the offender hears about it.
+	bob.Move(1, 1)
+	msg, _ := bob.WaitFor(func(m ServerMessage) bool { return m.Type == "error" })
+	fmt.Println("bob:", msg.Message)
+
+	moves := []struct {
+		c        *Client
+		row, col int
+	}{
+		{alice, 0, 0}, {bob, 1, 1}, {alice, 0, 1}, {bob, 2, 2}, {alice, 0, 2},
+	}
+	for i, mv := range moves {
+		mv.c.Move(mv.row, mv.col)
+		// Both players see every update.
+		after := func(m ServerMessage) bool { return m.Type == "state" && strings.Count(m.Board, "_") == 8-i }
+		alice.WaitFor(after)
+		msg, _ = bob.WaitFor(after)
+	}
+	printBoard(msg.Board)
+	fmt.Println(msg.Status, "-", msg.Result)
+	alice.Close()
+	bob.Close()
+
+	// Forfeit: X drops out mid-game, reconnects in time once, then disappears for good.
+	room2, _ := CreateRoom(srv.URL)
+	x, _ := Join(srv.URL, room2, "")
+	o, _ := Join(srv.URL, room2, "")
+	o.WaitFor(playing)
+	x.Close()
+	time.Sleep(50 * time.Millisecond)
+	x, err = Join(srv.URL, room2, x.Token)
+	fmt.Println("x reconnected:", err == nil, "as", x.Mark)
+	x.Close()
+	msg, _ = o.WaitFor(func(m ServerMessage) bool { return m.Status == StatusOver })
+	fmt.Println(msg.Result)
+	o.Close()
+
+	infos, _ := ListRooms(srv.URL)
+	fmt.Printf("%+v\n", infos)
+}
+
+func main() {
+	if len(os.Args) > 2 && os.Args[1] == "serve" {
+		fmt.Println("listening on", os.Args[2])
+		if err := http.ListenAndServe(os.Args[2], NewServer(30*time.Second).Handler()); err != nil {
+			fmt.Println(err)
+		}
+		return
+	}
+	if len(os.Args) > 2 && os.Args[1] == "play" {
+		room := ""
+		if len(os.Args) > 3 {
+			room = os.Args[3]
+		}
+		if err := playRemote(os.Args[2], room); err != nil {
+			fmt.Println(err)
+		}
+		return
+	}
+
+	// game server
+	tryGameServer()
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestServer(t *testing.T, forfeitAfter time.Duration) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(NewServer(forfeitAfter).Handler())
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func mustJoin(t *testing.T, baseURL, room, token string) *Client {
+	t.Helper()
+	c, err := Join(baseURL, room, token)
+	if err != nil {
+		t.Fatalf("Join(%s, %q): %v", room, token, err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// waitFor is Client.WaitFor with a deadline, so a missing message fails instead of hanging.
+func waitFor(t *testing.T, c *Client, what string, match func(ServerMessage) bool) ServerMessage {
+	t.Helper()
+	c.ws.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	defer c.ws.conn.SetReadDeadline(time.Time{})
+	msg, err := c.WaitFor(match)
+	if err != nil {
+		t.Fatalf("%s: waiting for %s: %v", c.Mark, what, err)
+	}
+	return msg
+}
+
+func isPlaying(m ServerMessage) bool { return m.Type == "state" && m.Status == StatusPlaying }
+func isOver(m ServerMessage) bool    { return m.Type == "state" && m.Status == StatusOver }
+
+func roomInfo(t *testing.T, baseURL, room string) RoomInfo {
+	t.Helper()
+	infos, err := ListRooms(baseURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, info := range infos {
+		if info.ID == room {
+			return info
+		}
+	}
+	t.Fatalf("room %s not listed in %+v", room, infos)
+	return RoomInfo{}
+}
+
+func TestFullGame(t *testing.T) {
+	srv := newTestServer(t, time.Minute)
+	room, err := CreateRoom(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := mustJoin(t, srv.URL, room, "")
+	o := mustJoin(t, srv.URL, room, "")
+	if x.Mark != "X" || o.Mark != "O" {
+		t.Fatalf("marks = %s, %s", x.Mark, o.Mark)
+	}
+	if _, err := Join(srv.URL, room, ""); err == nil || !strings.Contains(err.Error(), ErrRoomFull.Error()) {
+		t.Errorf("third player: err = %v, want %v", err, ErrRoomFull)
+	}
+	waitFor(t, x, "start", isPlaying)
+	waitFor(t, o, "start", isPlaying)
+
+	// Out of turn: only the offender gets the error.
+	o.Move(1, 1)
+	if msg := waitFor(t, o, "error", func(m ServerMessage) bool { return m.Type == "error" }); msg.Message != ErrNotYourTurn.Error() {
+		t.Errorf("error = %q", msg.Message)
+	}
+
+	moves := []struct {
+		c        *Client
+		row, col int
+	}{{x, 0, 0}, {o, 1, 1}, {x, 0, 1}, {o, 2, 2}, {x, 0, 2}}
+	var last ServerMessage
+	for i, mv := range moves {
+		if err := mv.c.Move(mv.row, mv.col); err != nil {
+			t.Fatal(err)
+		}
+		after := func(m ServerMessage) bool { return m.Type == "state" && strings.Count(m.Board, "_") == 8-i }
+		waitFor(t, x, "move", after)
+		last = waitFor(t, o, "move", after)
+	}
+	if last.Board != "XXX_O___O" || last.Status != StatusOver || last.Result != "X wins" {
+		t.Errorf("final state = %+v", last)
+	}
+	if info := roomInfo(t, srv.URL, room); info.Status != StatusOver {
+		t.Errorf("room = %+v", info)
+	}
+}
+
+func TestJoinErrors(t *testing.T) {
+	srv := newTestServer(t, time.Minute)
+	if _, err := Join(srv.URL, "room-99", ""); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("unknown room: err = %v", err)
+	}
+	room, _ := CreateRoom(srv.URL)
+	if _, err := Join(srv.URL, room, "not-a-token"); err == nil || !strings.Contains(err.Error(), "409") {
+		t.Errorf("unknown token: err = %v", err)
+	}
+}
+
+// A request that isn't a WebSocket upgrade is rejected without keeping the seat it claimed.
+func TestFailedUpgradeReleasesSeat(t *testing.T) {
+	srv := newTestServer(t, time.Minute)
+	room, _ := CreateRoom(srv.URL)
+	for range 2 {
+		resp, err := http.Get(srv.URL + "/rooms/" + room + "/ws")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("plain GET: status %d, want 400", resp.StatusCode)
+		}
+	}
+	if info := roomInfo(t, srv.URL, room); info.Players != 0 || info.Status != StatusWaiting {
+		t.Errorf("after failed upgrades: %+v", info)
+	}
+	x := mustJoin(t, srv.URL, room, "")
+	o := mustJoin(t, srv.URL, room, "")
+	if x.Mark != "X" || o.Mark != "O" {
+		t.Errorf("marks = %s, %s", x.Mark, o.Mark)
+	}
+}
+
+func TestReconnectClosesOldConnection(t *testing.T) {
+	srv := newTestServer(t, time.Minute)
+	room, _ := CreateRoom(srv.URL)
+	x := mustJoin(t, srv.URL, room, "")
+	o := mustJoin(t, srv.URL, room, "")
+	waitFor(t, o, "start", isPlaying)
+
+	x2 := mustJoin(t, srv.URL, room, x.Token)
+	if x2.Mark != "X" {
+		t.Fatalf("reconnected as %s", x2.Mark)
+	}
+	// The old connection ends; the new one plays on.
+	x.ws.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		if _, err := x.Next(); err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				t.Fatal("old connection still open")
+			}
+			break
+		}
+	}
+	x2.Move(0, 0)
+	msg := waitFor(t, o, "move", func(m ServerMessage) bool { return m.Type == "state" && m.Board[0] == 'X' })
+	if msg.Status != StatusPlaying {
+		t.Errorf("state = %+v", msg)
+	}
+}
+
+func TestForfeit(t *testing.T) {
+	srv := newTestServer(t, 100*time.Millisecond)
+	room, _ := CreateRoom(srv.URL)
+	x := mustJoin(t, srv.URL, room, "")
+	o := mustJoin(t, srv.URL, room, "")
+	waitFor(t, o, "start", isPlaying)
+
+	// Back in time: no forfeit.
+	x.Close()
+	time.Sleep(20 * time.Millisecond)
+	x = mustJoin(t, srv.URL, room, x.Token)
+	time.Sleep(200 * time.Millisecond)
+	if info := roomInfo(t, srv.URL, room); info.Status != StatusPlaying {
+		t.Fatalf("after reconnecting: %+v", info)
+	}
+
+	// Gone for good.
+	x.Close()
+	if msg := waitFor(t, o, "forfeit", isOver); msg.Result != "O wins by forfeit" {
+		t.Errorf("result = %q", msg.Result)
+	}
+}
+
+func TestLeavingBeforeStartFreesSeat(t *testing.T) {
+	srv := newTestServer(t, time.Minute)
+	room, _ := CreateRoom(srv.URL)
+	x := mustJoin(t, srv.URL, room, "")
+	x.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for roomInfo(t, srv.URL, room).Players != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("seat not freed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// A player who stops reading holds up broadcasts only until the write times out, and then
+// is cut off while the other player still gets the update.
+func TestStalledClientDoesNotBlockRoom(t *testing.T) {
+	stalledServer, stalledClient := net.Pipe() // nobody ever reads stalledClient
+	liveServer, liveClient := net.Pipe()
+	defer stalledClient.Close()
+	defer liveClient.Close()
+
+	r := &Room{ID: "room-1", status: StatusPlaying}
+	r.seats[0] = &seat{mark: X, conn: &wsConn{conn: stalledServer, timeout: 50 * time.Millisecond}}
+	r.seats[1] = &seat{mark: O, conn: &wsConn{conn: liveServer, timeout: 50 * time.Millisecond}}
+
+	got := make(chan []byte, 1)
+	go func() {
+		c := &wsConn{conn: liveClient, br: bufio.NewReader(liveClient), client: true}
+		msg, _ := c.ReadMessage()
+		got <- msg
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		r.mu.Lock()
+		r.broadcast()
+		r.mu.Unlock()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("broadcast blocked on a stalled client")
+	}
+	if msg := <-got; !strings.Contains(string(msg), `"status":"playing"`) {
+		t.Errorf("live player got %q", msg)
+	}
+	// The stalled connection was closed.
+	if _, err := stalledServer.Write([]byte("x")); err == nil {
+		t.Error("stalled connection still open")
+	}
+}
+
+func TestWSS(t *testing.T) {
+	srv := httptest.NewTLSServer(NewServer(time.Minute).Handler())
+	defer srv.Close()
+	old := HTTPClient
+	HTTPClient = srv.Client()
+	defer func() { HTTPClient = old }()
+
+	room, err := CreateRoom(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := mustJoin(t, srv.URL, room, "")
+	mustJoin(t, srv.URL, room, "")
+	waitFor(t, x, "start over TLS", isPlaying)
+}
@@ -0,0 +1,406 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"time"
+)
+
+////// structured errors: MyError{When, What} (4-methods.go) and argError{arg, message} (7-extra.go)
+// each carry a couple of fixed fields. Error generalises them: a machine-readable code, any number
+// of key/value fields, optional causes wrapped like %w, the stack where it was created, and a
+// category that errors.Is understands.
+
+// Category is a broad class of failure. The categories are sentinels: errors.Is(err, NotFound)
+// is true for any *Error in the chain created with that category.
+type Category struct {
+	name   string
+	status int
+}
+
+func (c *Category) Error() string {
+	return c.name
+}
+
+var (
+	NotFound         = &Category{"not found", http.StatusNotFound}
+	Invalid          = &Category{"invalid", http.StatusBadRequest}
+	MethodNotAllowed = &Category{"method not allowed", http.StatusMethodNotAllowed}
+	Timeout          = &Category{"timeout", http.StatusGatewayTimeout}
+	Conflict         = &Category{"conflict", http.StatusConflict}
+	Internal         = &Category{"internal", http.StatusInternalServerError}
+)
+
+var categories = []*Category{NotFound, Invalid, MethodNotAllowed, Timeout, Conflict, Internal}
+
+// CategoryOf returns the category that describes err: the first one other than Internal met on
+// a walk down the chain from the outside in, in the order errors.Is uses, or Internal if there
+// is none. The outermost category is the most specific statement of what failed, so
+// Wrap(notFound, Invalid, ...) is Invalid; Internal only says nobody knew better, so a real
+// category further in wins over it. Status codes and JSON bodies both come from CategoryOf,
+// so they always agree.
+func CategoryOf(err error) *Category {
+	if cat := categoryIn(err); cat != nil {
+		return cat
+	}
+	return Internal
+}
+
+// categoryIn returns the first category other than Internal in err's chain, or nil.
+func categoryIn(err error) *Category {
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case *Category:
+		if e != Internal {
+			return e
+		}
+	case *Error:
+		if e.Category != nil && e.Category != Internal {
+			return e.Category
+		}
+	case interface{ Is(error) bool }:
+		// Some other error type that claims a category of its own.
+		for _, cat := range categories {
+			if cat != Internal && e.Is(cat) {
+				return cat
+			}
+		}
+	}
+	switch u := err.(type) {
+	case interface{ Unwrap() error }:
+		return categoryIn(u.Unwrap())
+	case interface{ Unwrap() []error }:
+		for _, inner := range u.Unwrap() {
+			if cat := categoryIn(inner); cat != nil {
+				return cat
+			}
+		}
+	}
+	return nil
+}
+
+// Field is one piece of context attached to an error, like argError's arg.
+type Field struct {
+	Key   string
+	Value any
+}
+
+type Error struct {
+	Code     string
+	Message  string
+	Category *Category
+	Fields   []Field
+	Causes   []error
+	When     time.Time
+
+	// formatted is set by Errorf, whose message already includes the causes.
+	formatted bool
+	stack     []uintptr
+}
+
+// newError captures the stack of whoever called the exported constructor.
+func newError(cat *Category, code, msg string, kv []any) *Error {
+	if cat == nil {
+		cat = Internal
+	}
+	e := &Error{Code: code, Message: msg, Category: cat, When: time.Now()}
+	e.addFields(kv)
+	pcs := make([]uintptr, 32)
+	// Skip runtime.Callers, newError and the constructor itself.
+	n := runtime.Callers(3, pcs)
+	e.stack = pcs[:n]
+	return e
+}
+
+// New creates an error. kv is an alternating list of keys and values.
+func New(cat *Category, code, msg string, kv ...any) *Error {
+	return newError(cat, code, msg, kv)
+}
+
+// Wrap creates an error caused by err. Wrapping nil returns nil, so it can be used directly
+// on a function's result.
+func Wrap(err error, cat *Category, code, msg string, kv ...any) error {
+	if err == nil {
+		return nil
+	}
+	e := newError(cat, code, msg, kv)
+	e.Causes = []error{err}
+	return e
+}
+
+// Errorf formats the message like fmt.Errorf; every %w verb adds a cause.
+func Errorf(cat *Category, code, format string, args ...any) *Error {
+	wrapped := fmt.Errorf(format, args...)
+	e := newError(cat, code, wrapped.Error(), nil)
+	switch u := wrapped.(type) {
+	case interface{ Unwrap() error }:
+		e.Causes = []error{u.Unwrap()}
+	case interface{ Unwrap() []error }:
+		e.Causes = u.Unwrap()
+	}
+	e.formatted = len(e.Causes) > 0
+	return e
+}
+
+// With returns the error with more fields attached.
+func (e *Error) With(kv ...any) *Error {
+	e.addFields(kv)
+	return e
+}
+
+func (e *Error) addFields(kv []any) {
+	for i := 0; i < len(kv); i += 2 {
+		key := fmt.Sprint(kv[i])
+		var value any = "(missing)"
+		if i+1 < len(kv) {
+			value = kv[i+1]
+		}
+		e.Fields = append(e.Fields, Field{key, value})
+	}
+}
+
+// Field returns the value of the first field with the given key.
+func (e *Error) Field(key string) (any, bool) {
+	for _, f := range e.Fields {
+		if f.Key == key {
+			return f.Value, true
+		}
+	}
+	return nil, false
+}
+
+func (e *Error) Error() string {
+	var sb strings.Builder
+	if e.Code != "" {
+		sb.WriteString(e.Code)
+		sb.WriteString(": ")
+	}
+	sb.WriteString(e.Message)
+	for _, f := range e.Fields {
+		fmt.Fprintf(&sb, " %s=%v", f.Key, f.Value)
+	}
+	if !e.formatted {
+		for _, cause := range e.Causes {
+			sb.WriteString(": ")
+			sb.WriteString(cause.Error())
+		}
+	}
+	return sb.String()
+}
+
+// Unwrap returns every cause, so errors.Is and errors.As search all of them.
+func (e *Error) Unwrap() []error {
+	return e.Causes
+}
+
+// Is makes errors.Is(err, NotFound) and friends match on the category.
+func (e *Error) Is(target error) bool {
+	cat, ok := target.(*Category)
+	return ok && cat == e.Category
+}
+
+// Stack returns the frames where the error was created, innermost first.
+func (e *Error) Stack() []runtime.Frame {
+	var out []runtime.Frame
+	frames := runtime.CallersFrames(e.stack)
+	for {
+		f, more := frames.Next()
+		out = append(out, f)
+		if !more {
+			return out
+		}
+	}
+}
+
+// Format prints the stack trace for %+v; every other verb prints Error().
+func (e *Error) Format(s fmt.State, verb rune) {
+	io.WriteString(s, e.Error())
+	if verb == 'v' && s.Flag('+') {
+		for _, f := range e.Stack() {
+			fmt.Fprintf(s, "\n\t%s\n\t\t%s:%d", f.Function, f.File, f.Line)
+		}
+		for _, cause := range e.Causes {
+			fmt.Fprintf(s, "\ncaused by: %+v", cause)
+		}
+	}
+}
+
+////// JSON: errors serialise to an object clients can act on. The category is CategoryOf the
+// whole error, the same one HTTPStatus uses. Nested *Error causes stay structured; any other
+// cause becomes its message.
+
+type errorJSON struct {
+	Code     string         `json:"code,omitempty"`
+	Category string         `json:"category"`
+	Message  string         `json:"message"`
+	Fields   map[string]any `json:"fields,omitempty"`
+	When     time.Time      `json:"when"`
+	Causes   []any          `json:"causes,omitempty"`
+}
+
+func (e *Error) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.toJSON(CategoryOf(e)))
+}
+
+func (e *Error) toJSON(cat *Category) errorJSON {
+	out := errorJSON{
+		Code:     e.Code,
+		Category: cat.name,
+		Message:  e.Message,
+		When:     e.When,
+	}
+	if len(e.Fields) > 0 {
+		out.Fields = make(map[string]any, len(e.Fields))
+		for _, f := range e.Fields {
+			out.Fields[f.Key] = f.Value
+		}
+	}
+	for _, cause := range e.Causes {
+		var inner *Error
+		if errors.As(cause, &inner) {
+			out.Causes = append(out.Causes, inner)
+		} else {
+			out.Causes = append(out.Causes, cause.Error())
+		}
+	}
+	return out
+}
+
+////// HTTP: categories map to status codes, so handlers can just return errors.
+
+// HTTPStatus returns the status code for err's category, so a timeout wrapped as an internal
+// error still answers 504.
+func HTTPStatus(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+	return CategoryOf(err).status
+}
+
+// WriteError sends err as a JSON body, with the status code of the category in the body.
+func WriteError(w http.ResponseWriter, err error) {
+	cat := CategoryOf(err)
+	var e *Error
+	if !errors.As(err, &e) {
+		e = newError(cat, "", err.Error(), nil)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(cat.status)
+	json.NewEncoder(w).Encode(e.toJSON(cat))
+}
+
+// HandlerFunc is an http handler that can fail.
+type HandlerFunc func(w http.ResponseWriter, req *http.Request) error
+
+func (h HandlerFunc) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if err := h(w, req); err != nil {
+		WriteError(w, err)
+	}
+}
+
+// hello and headers from tryHttpServer (8-extra-part-2.go), now reporting bad requests.
+func hello(w http.ResponseWriter, req *http.Request) error {
+	if req.Method != http.MethodGet {
+		return New(MethodNotAllowed, "method_not_allowed", "hello only answers GET", "method", req.Method)
+	}
+	fmt.Fprintf(w, "hello\n")
+	return nil
+}
+
+func headers(w http.ResponseWriter, req *http.Request) error {
+	// ?name=... asks for a single header.
+	if name := req.URL.Query().Get("name"); name != "" {
+		values, ok := req.Header[http.CanonicalHeaderKey(name)]
+		if !ok {
+			return New(NotFound, "header_not_found", "no such header", "name", name)
+		}
+		for _, h := range values {
+			fmt.Fprintf(w, "%v: %v\n", name, h)
+		}
+		return nil
+	}
+	for name, headers := range req.Header {
+		for _, h := range headers {
+			fmt.Fprintf(w, "%v: %v\n", name, h)
+		}
+	}
+	return nil
+}
+
+// run and f are the originals rewritten on top of Error.
+func run() error {
+	return New(Internal, "run_failed", "it didn't work")
+}
+
+func f(arg int) (int, error) {
+	if arg == 42 {
+		return -1, New(Invalid, "bad_arg", "can't work with it", "arg", arg)
+	}
+	return arg + 3, nil
+}
+
+func loadConfig(path string) error {
+	// A deadline that passed while reading, wrapped twice on the way up.
+	err := New(Timeout, "read_timeout", "read took too long", "after", 2*time.Second)
+	return Errorf(Internal, "config", "loading %s: %w", path, err)
+}
+
+func tryStructuredErrors() {
+	err := run()
+	fmt.Println(err)
+
+	// errors.As still gives the fields back, as in tryCustomError.
+	_, err = f(42)
+	var e *Error
+	if errors.As(err, &e) {
+		arg, _ := e.Field("arg")
+		fmt.Println(e.Code, arg, e.Message)
+	}
+	fmt.Println("invalid?", errors.Is(err, Invalid), "not found?", errors.Is(err, NotFound))
+
+	// Categories are found anywhere in the chain, through %w and Wrap alike.
+	err = loadConfig("app.yaml")
+	fmt.Println(err)
+	fmt.Println("timeout?", errors.Is(err, Timeout), "status:", HTTPStatus(err))
+
+	err = Wrap(fmt.Errorf("disk on fire"), Conflict, "save", "could not save", "id", 7)
+	fmt.Println(err, HTTPStatus(err))
+	fmt.Println(Wrap(nil, Conflict, "save", "could not save") == nil)
+
+	// The stack shows where the error was created.
+	fmt.Printf("%+v\n", New(NotFound, "demo", "with a stack trace"))
+
+	data, _ := json.MarshalIndent(loadConfig("app.yaml"), "", "  ")
+	fmt.Println(string(data))
+}
+
+func tryErrorHandlers() {
+	mux := http.NewServeMux()
+	mux.Handle("/hello", HandlerFunc(hello))
+	mux.Handle("/headers", HandlerFunc(headers))
+
+	for _, r := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/hello", nil),
+		httptest.NewRequest(http.MethodPost, "/hello", nil),
+		httptest.NewRequest(http.MethodGet, "/headers?name=X-Missing", nil),
+	} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, r)
+		fmt.Println(r.Method, r.URL, "->", rec.Code, strings.TrimSpace(rec.Body.String()))
+	}
+}
+
+func main() {
+	// structured errors
+	tryStructuredErrors()
+
+	// errors in http handlers
+	tryErrorHandlers()
+}
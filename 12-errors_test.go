@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type responseBody struct {
+	Code     string `json:"code"`
+	Category string `json:"category"`
+	Causes   []any  `json:"causes"`
+}
+
+func serve(t *testing.T, h HandlerFunc, method string) (int, responseBody) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, "/", nil))
+	var body responseBody
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("body %q: %v", rec.Body, err)
+	}
+	return rec.Code, body
+}
+
+// The status code and the body's category come from the same lookup.
+func TestWriteErrorStatusMatchesCategory(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		status   int
+		category string
+	}{
+		{"wrapped timeout", loadConfig("app.yaml"), http.StatusGatewayTimeout, "timeout"},
+		{"not found", New(NotFound, "x", "gone"), http.StatusNotFound, "not found"},
+		{"plain error", errors.New("boom"), http.StatusInternalServerError, "internal"},
+		{"plain wrapping a category", fmt.Errorf("busy: %w", Conflict), http.StatusConflict, "conflict"},
+		{"nil category", &Error{Message: "zero value"}, http.StatusInternalServerError, "internal"},
+		// The outermost category wins; Internal gives way to anything more specific.
+		{"invalid around not found", Wrap(New(NotFound, "x", "gone"), Invalid, "y", "bad id"), http.StatusBadRequest, "invalid"},
+		{"conflict around timeout", Wrap(New(Timeout, "x", "slow"), Conflict, "y", "busy"), http.StatusConflict, "conflict"},
+		{"internal around not found", Wrap(New(NotFound, "x", "gone"), Internal, "y", "failed"), http.StatusNotFound, "not found"},
+		{"plain around invalid around timeout", fmt.Errorf("ctx: %w", Errorf(Invalid, "y", "bad: %w", New(Timeout, "x", "slow"))), http.StatusBadRequest, "invalid"},
+		{"joined: first wins", errors.Join(errors.New("boom"), New(Conflict, "x", "a"), New(NotFound, "y", "b")), http.StatusConflict, "conflict"},
+		{"only internal", Wrap(errors.New("boom"), Internal, "x", "failed"), http.StatusInternalServerError, "internal"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := serve(t, func(http.ResponseWriter, *http.Request) error { return tt.err }, http.MethodGet)
+			if status != tt.status || body.Category != tt.category {
+				t.Errorf("got %d %q, want %d %q", status, body.Category, tt.status, tt.category)
+			}
+			if got := HTTPStatus(tt.err); got != tt.status {
+				t.Errorf("HTTPStatus = %d, want %d", got, tt.status)
+			}
+		})
+	}
+}
+
+func TestHelloRejectsOtherMethods(t *testing.T) {
+	status, body := serve(t, hello, http.MethodPost)
+	if status != http.StatusMethodNotAllowed || body.Category != "method not allowed" || body.Code != "method_not_allowed" {
+		t.Errorf("POST /hello: %d %+v", status, body)
+	}
+}
+
+func TestErrorfKeepsEveryCause(t *testing.T) {
+	err := Errorf(Internal, "both", "read: %w; write: %w", New(Timeout, "r", "slow"), New(Conflict, "w", "busy"))
+	if !errors.Is(err, Timeout) || !errors.Is(err, Conflict) {
+		t.Errorf("causes lost: %v", err.Causes)
+	}
+	if got, want := err.Error(), "both: read: r: slow; write: w: busy"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	data, err2 := json.Marshal(err)
+	if err2 != nil {
+		t.Fatal(err2)
+	}
+	var body responseBody
+	json.Unmarshal(data, &body)
+	if len(body.Causes) != 2 {
+		t.Errorf("JSON causes = %v", body.Causes)
+	}
+}
+
+func TestMarshalNilCategory(t *testing.T) {
+	data, err := json.Marshal(&Error{Message: "zero value"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body responseBody
+	json.Unmarshal(data, &body)
+	if body.Category != "internal" {
+		t.Errorf("category = %q", body.Category)
+	}
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+////// validation reports: f(arg) (7-extra.go) returns a single argError, so tryCustomError only ever
+// sees the first thing that went wrong. A Report collects every field-level problem of a batch,
+// each with a path, a code and a message, and still lets errors.Is and errors.As find each one.
+
+var (
+	ErrRequired   = errors.New("required")
+	ErrOutOfRange = errors.New("out of range")
+	ErrFormat     = errors.New("invalid format")
+)
+
+// FieldError is one problem at one path, e.g. `["Google"].Lat`.
+type FieldError struct {
+	Path    string
+	Code    string
+	Message string
+	Value   any
+	Err     error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// Report is a list of field errors. The zero value is an empty report ready to use.
+type Report struct {
+	Errors []*FieldError
+}
+
+// Add records a problem. err is the sentinel or underlying error, if there is one.
+func (r *Report) Add(path, code, message string, value any, err error) {
+	r.Errors = append(r.Errors, &FieldError{Path: path, Code: code, Message: message, Value: value, Err: err})
+}
+
+// Check records a problem when ok is false, and reports ok so checks can be chained.
+func (r *Report) Check(ok bool, path, code, message string, value any, err error) bool {
+	if !ok {
+		r.Add(path, code, message, value, err)
+	}
+	return ok
+}
+
+// AddError records any error at a path. A *FieldError keeps its code and has its path prefixed;
+// anything else gets the code "error". An error made of several, like a *Report or the result
+// of errors.Join, adds each of them.
+func (r *Report) AddError(path string, err error) {
+	if err == nil {
+		return
+	}
+	if multi, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range multi.Unwrap() {
+			r.AddError(path, e)
+		}
+		return
+	}
+	var fe *FieldError
+	if errors.As(err, &fe) {
+		r.Add(joinPath(path, fe.Path), fe.Code, fe.Message, fe.Value, fe.Err)
+		return
+	}
+	r.Add(path, "error", err.Error(), nil, err)
+}
+
+// Merge adds all of other's problems under prefix, for validating nested values. A nil report
+// has none.
+func (r *Report) Merge(prefix string, other *Report) {
+	if other == nil {
+		return
+	}
+	for _, fe := range other.Errors {
+		cp := *fe
+		cp.Path = joinPath(prefix, fe.Path)
+		r.Errors = append(r.Errors, &cp)
+	}
+}
+
+func joinPath(prefix, path string) string {
+	switch {
+	case prefix == "":
+		return path
+	case path == "", strings.HasPrefix(path, "["):
+		return prefix + path
+	default:
+		return prefix + "." + path
+	}
+}
+
+func (r *Report) Len() int {
+	return len(r.Errors)
+}
+
+// Err returns nil for an empty report, so callers can write `if err := r.Err(); err != nil`.
+func (r *Report) Err() error {
+	if r.Len() == 0 {
+		return nil
+	}
+	return r
+}
+
+// Join returns the problems as a plain errors.Join error, for code that only knows the stdlib.
+func (r *Report) Join() error {
+	errs := make([]error, len(r.Errors))
+	for i, fe := range r.Errors {
+		errs[i] = fe
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Report) Error() string {
+	return r.Text()
+}
+
+// Unwrap exposes every item, so errors.Is and errors.As look at each of them.
+func (r *Report) Unwrap() []error {
+	errs := make([]error, len(r.Errors))
+	for i, fe := range r.Errors {
+		errs[i] = fe
+	}
+	return errs
+}
+
+////// rendering: plain text for logs, JSON for APIs, and a table for people.
+
+func (r *Report) Text() string {
+	lines := make([]string, len(r.Errors))
+	for i, fe := range r.Errors {
+		lines[i] = fe.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (r *Report) MarshalJSON() ([]byte, error) {
+	type item struct {
+		Path    string `json:"path"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+		Value   any    `json:"value,omitempty"`
+	}
+	items := make([]item, len(r.Errors))
+	for i, fe := range r.Errors {
+		items[i] = item{fe.Path, fe.Code, fe.Message, fe.Value}
+		// JSON has no NaN or Inf, and those are exactly the values worth reporting.
+		if v, ok := fe.Value.(float64); ok && (math.IsNaN(v) || math.IsInf(v, 0)) {
+			items[i].Value = fmt.Sprint(v)
+		}
+	}
+	return json.Marshal(map[string]any{"errors": items})
+}
+
+func (r *Report) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PATH\tCODE\tVALUE\tMESSAGE")
+	for _, fe := range r.Errors {
+		fmt.Fprintf(tw, "%s\t%s\t%v\t%s\n", fe.Path, fe.Code, fe.Value, fe.Message)
+	}
+	return tw.Flush()
+}
+
+////// validating Vertex2 coordinates, like the m map in 3-more-types.go.
+
+type Vertex2 struct {
+	Lat, Long float64
+}
+
+func inRange(path string, v, lo, hi float64, r *Report) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		r.Add(path, "not_finite", "must be a finite number", v, ErrFormat)
+		return
+	}
+	r.Check(v >= lo && v <= hi, path, "out_of_range", fmt.Sprintf("must be between %g and %g", lo, hi), v, ErrOutOfRange)
+}
+
+func ValidateVertex2(v Vertex2) *Report {
+	var r Report
+	inRange("Lat", v.Lat, -90, 90, &r)
+	inRange("Long", v.Long, -180, 180, &r)
+	return &r
+}
+
+// ValidateLocations checks every entry; map keys are sorted so reports are stable.
+func ValidateLocations(m map[string]Vertex2) *Report {
+	var r Report
+	for _, name := range slices.Sorted(maps.Keys(m)) {
+		r.Check(strings.TrimSpace(name) != "", fmt.Sprintf("[%q]", name), "required", "name must not be blank", name, ErrRequired)
+		r.Merge(fmt.Sprintf("[%q]", name), ValidateVertex2(m[name]))
+	}
+	return &r
+}
+
+////// validating flags: parse first, then check every value instead of stopping at the first bad one.
+
+// ValidateFlags runs a check per flag name over a parsed FlagSet.
+func ValidateFlags(fs *flag.FlagSet, checks map[string]func(value string) error) *Report {
+	var r Report
+	for _, name := range slices.Sorted(maps.Keys(checks)) {
+		fl := fs.Lookup(name)
+		if fl == nil {
+			r.Add("-"+name, "unknown_flag", "flag is not defined", nil, nil)
+			continue
+		}
+		r.AddError("-"+name, checks[name](fl.Value.String()))
+	}
+	return &r
+}
+
+// argError and f from 7-extra.go.
+type argError struct {
+	arg     int
+	message string
+}
+
+func (e *argError) Error() string {
+	return fmt.Sprintf("%d - %s", e.arg, e.message)
+}
+
+func f(arg int) (int, error) {
+	if arg == 42 {
+		return -1, &argError{arg, "can't work with it"}
+	}
+	return arg + 3, nil
+}
+
+func tryValidationReport() {
+	// Every failure of a batch, not just the first.
+	var r Report
+	for i, arg := range []int{1, 42, 7, 42} {
+		_, err := f(arg)
+		r.AddError(fmt.Sprintf("args[%d]", i), err)
+	}
+	fmt.Println(r.Err())
+
+	// errors.As reaches into the report and finds the first argError.
+	var ae *argError
+	if errors.As(r.Err(), &ae) {
+		fmt.Println("first argError:", ae.arg, ae.message)
+	}
+
+	locations := map[string]Vertex2{
+		"Bell Labs": {40.68433, -74.39967},
+		"Google":    {137.42202, -122.08408},
+		"Nowhere":   {math.NaN(), 200},
+		" ":         {0, 0},
+	}
+	report := ValidateLocations(locations)
+	fmt.Println(report.Len(), "problems")
+	fmt.Println("out of range?", errors.Is(report.Err(), ErrOutOfRange), "required?", errors.Is(report.Err(), ErrRequired))
+	report.WriteTable(os.Stdout)
+	data, err := json.Marshal(report)
+	fmt.Println(string(data), err)
+
+	// errors.Join gives the same items to code that doesn't know about Report.
+	joined := report.Join()
+	var fe *FieldError
+	fmt.Println(errors.As(joined, &fe), fe.Path, errors.Is(joined, ErrFormat))
+
+	// A valid input has no error at all.
+	fmt.Println(ValidateLocations(map[string]Vertex2{"Google": {37.42202, -122.08408}}).Err() == nil)
+}
+
+func tryValidatingFlags() {
+	// The flags from tryCommandLineFlags (8-extra-part-2.go), on a FlagSet so the demo can pass arguments.
+	fs := flag.NewFlagSet("demo", flag.ContinueOnError)
+	fs.String("word", "foo", "a string")
+	fs.Int("numb", 42, "an int")
+	fs.String("svar", "bar", "a string var")
+	fs.Parse([]string{"-word=", "-numb=-3", "-svar=baz"})
+
+	report := ValidateFlags(fs, map[string]func(string) error{
+		"word": func(v string) error {
+			if v == "" {
+				return &FieldError{Code: "required", Message: "must not be empty", Value: v, Err: ErrRequired}
+			}
+			return nil
+		},
+		"numb": func(v string) error {
+			if strings.HasPrefix(v, "-") {
+				return &FieldError{Code: "out_of_range", Message: "must be positive", Value: v, Err: ErrOutOfRange}
+			}
+			return nil
+		},
+		"svar": func(v string) error {
+			if v != "bar" && v != "baz" {
+				return fmt.Errorf("must be bar or baz, got %q", v)
+			}
+			return nil
+		},
+		"fork": func(string) error { return nil },
+	})
+	fmt.Println(report.Text())
+}
+
+func main() {
+	// validation reports
+	tryValidationReport()
+
+	// validating flags
+	tryValidatingFlags()
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+// paths lists the path of every item, in order.
+func paths(r *Report) []string {
+	var out []string
+	for _, fe := range r.Errors {
+		out = append(out, fe.Path)
+	}
+	return out
+}
+
+func TestAddError(t *testing.T) {
+	inner := ValidateVertex2(Vertex2{100, 200})
+	tests := []struct {
+		name      string
+		err       error
+		wantPaths []string
+		wantCodes []string
+	}{
+		{"nil", nil, nil, nil},
+		{"plain error", errors.New("boom"), []string{"p"}, []string{"error"}},
+		{"field error", &FieldError{Path: "x", Code: "required", Err: ErrRequired}, []string{"p.x"}, []string{"required"}},
+		{"wrapped field error", fmt.Errorf("ctx: %w", &FieldError{Path: "[0]", Code: "c"}), []string{"p[0]"}, []string{"c"}},
+		{"report", inner.Err(), []string{"p.Lat", "p.Long"}, []string{"out_of_range", "out_of_range"}},
+		{"joined report", inner.Join(), []string{"p.Lat", "p.Long"}, []string{"out_of_range", "out_of_range"}},
+		{"mixed join", errors.Join(errors.New("boom"), &FieldError{Path: "y", Code: "c"}), []string{"p", "p.y"}, []string{"error", "c"}},
+	}
+	for _, tt := range tests {
+		var r Report
+		r.AddError("p", tt.err)
+		var codes []string
+		for _, fe := range r.Errors {
+			codes = append(codes, fe.Code)
+		}
+		if fmt.Sprint(paths(&r)) != fmt.Sprint(tt.wantPaths) || fmt.Sprint(codes) != fmt.Sprint(tt.wantCodes) {
+			t.Errorf("%s: paths %q codes %q, want %q %q", tt.name, paths(&r), codes, tt.wantPaths, tt.wantCodes)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	var r Report
+	r.Add("top", "c", "m", 1, nil)
+	r.Merge(`["Google"]`, ValidateVertex2(Vertex2{math.NaN(), 0}))
+	r.Merge("nested", ValidateVertex2(Vertex2{0, 500}))
+	r.Merge("", ValidateVertex2(Vertex2{-91, 0}))
+	r.Merge("none", nil)
+	want := []string{"top", `["Google"].Lat`, "nested.Long", "Lat"}
+	if fmt.Sprint(paths(&r)) != fmt.Sprint(want) {
+		t.Errorf("paths %q, want %q", paths(&r), want)
+	}
+
+	// Merge copies, so the merged report's paths don't change.
+	inner := ValidateVertex2(Vertex2{100, 0})
+	r.Merge("again", inner)
+	if inner.Errors[0].Path != "Lat" {
+		t.Errorf("Merge changed the source path to %q", inner.Errors[0].Path)
+	}
+}
+
+func TestErrEmpty(t *testing.T) {
+	var r Report
+	if r.Err() != nil {
+		t.Errorf("empty report: Err() = %v", r.Err())
+	}
+	if err := ValidateLocations(map[string]Vertex2{"Google": {37.42202, -122.08408}}).Err(); err != nil {
+		t.Errorf("valid locations: %v", err)
+	}
+}
+
+// errors.Is and errors.As see every item, both through the report and through Join.
+func TestErrorsIsAsEachItem(t *testing.T) {
+	r := ValidateLocations(map[string]Vertex2{
+		" ":       {0, 0},
+		"Google":  {137.42202, -122.08408},
+		"Nowhere": {math.NaN(), 0},
+	})
+	for _, err := range []error{r.Err(), r.Join()} {
+		for _, sentinel := range []error{ErrRequired, ErrOutOfRange, ErrFormat} {
+			if !errors.Is(err, sentinel) {
+				t.Errorf("errors.Is(%T, %v) = false", err, sentinel)
+			}
+		}
+		var fe *FieldError
+		if !errors.As(err, &fe) || fe.Path != `[" "]` {
+			t.Errorf("errors.As(%T) found %v, want the first item", err, fe)
+		}
+	}
+
+	var batch Report
+	for i, arg := range []int{1, 42, 7, 42} {
+		_, err := f(arg)
+		batch.AddError(fmt.Sprintf("args[%d]", i), err)
+	}
+	if batch.Len() != 2 {
+		t.Fatalf("%d items, want 2", batch.Len())
+	}
+	for _, fe := range batch.Errors {
+		var ae *argError
+		if !errors.As(fe, &ae) || ae.arg != 42 {
+			t.Errorf("%s: errors.As found %v", fe.Path, ae)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	r := ValidateLocations(map[string]Vertex2{
+		"Google":  {137.42202, -122.08408},
+		"Nowhere": {math.NaN(), 200},
+	})
+
+	wantText := `["Google"].Lat: must be between -90 and 90
+["Nowhere"].Lat: must be a finite number
+["Nowhere"].Long: must be between -180 and 180`
+	if got := r.Text(); got != wantText {
+		t.Errorf("Text:\n%s\nwant\n%s", got, wantText)
+	}
+	if r.Error() != wantText {
+		t.Errorf("Error() differs from Text()")
+	}
+
+	data, err := json.Marshal(r)
+	wantJSON := `{"errors":[` +
+		`{"path":"[\"Google\"].Lat","code":"out_of_range","message":"must be between -90 and 90","value":137.42202},` +
+		`{"path":"[\"Nowhere\"].Lat","code":"not_finite","message":"must be a finite number","value":"NaN"},` +
+		`{"path":"[\"Nowhere\"].Long","code":"out_of_range","message":"must be between -180 and 180","value":200}]}`
+	if err != nil || string(data) != wantJSON {
+		t.Errorf("JSON: %s %v\nwant %s", data, err, wantJSON)
+	}
+
+	var sb strings.Builder
+	if err := r.WriteTable(&sb); err != nil {
+		t.Fatal(err)
+	}
+	wantTable := `PATH              CODE          VALUE      MESSAGE
+["Google"].Lat    out_of_range  137.42202  must be between -90 and 90
+["Nowhere"].Lat   not_finite    NaN        must be a finite number
+["Nowhere"].Long  out_of_range  200        must be between -180 and 180
+`
+	if sb.String() != wantTable {
+		t.Errorf("table:\n%s\nwant\n%s", sb.String(), wantTable)
+	}
+}
@@ -0,0 +1,345 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+////// supervisors: tryRecover (8-extra-part-2.go) catches one panic from mayPanic and carries on.
+// A supervisor does that for a whole group of long-running goroutines, the way Erlang does: each
+// child that crashes is restarted according to a strategy, and if children crash too often the
+// supervisor gives up instead of looping forever.
+
+// Strategy decides which children restart when one of them crashes.
+type Strategy int
+
+const (
+	// OneForOne restarts only the child that crashed.
+	OneForOne Strategy = iota
+	// OneForAll restarts every child.
+	OneForAll
+	// RestForOne restarts the crashed child and every child started after it.
+	RestForOne
+)
+
+var strategyName = map[Strategy]string{
+	OneForOne:  "one-for-one",
+	OneForAll:  "one-for-all",
+	RestForOne: "rest-for-one",
+}
+
+func (s Strategy) String() string {
+	return strategyName[s]
+}
+
+// ChildFunc is the body of a child. It should return when ctx is cancelled. Returning nil means
+// the child finished its work and is not restarted; returning an error or panicking is a crash.
+type ChildFunc func(ctx context.Context) error
+
+type Child struct {
+	Name string
+	Run  ChildFunc
+}
+
+// Crash describes one failure of a child. Panic and Stack are set when it panicked.
+type Crash struct {
+	Child string
+	Err   error
+	Panic any
+	Stack []byte
+	When  time.Time
+}
+
+func (c Crash) String() string {
+	if c.Panic != nil {
+		return fmt.Sprintf("%s panicked: %v", c.Child, c.Panic)
+	}
+	return fmt.Sprintf("%s failed: %v", c.Child, c.Err)
+}
+
+var ErrTooManyRestarts = errors.New("supervisor: too many restarts")
+
+// Supervisor runs children. At most MaxRestarts restarts are allowed within Window; a Window of 0
+// means no window, so MaxRestarts limits restarts over the supervisor's whole run. Backoff is the
+// wait before the first restart and doubles for each restart still inside the window, up to
+// MaxBackoff. A MaxBackoff of 0 means no cap. OnCrash, if set, is called for every crash.
+type Supervisor struct {
+	Strategy    Strategy
+	MaxRestarts int
+	Window      time.Duration
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+	OnCrash     func(Crash)
+
+	children []Child
+}
+
+func (s *Supervisor) Add(name string, run ChildFunc) {
+	s.children = append(s.children, Child{Name: name, Run: run})
+}
+
+// exit is what a child's goroutine reports back when it stops.
+type exit struct {
+	index int
+	gen   int
+	crash *Crash
+}
+
+// running is the live instance of a child: cancel stops it, gen tells old exits from new ones.
+type running struct {
+	cancel context.CancelFunc
+	gen    int
+	done   chan struct{}
+}
+
+// Run starts every child and supervises them until ctx is cancelled, every child has finished,
+// or the restart limit is hit, in which case it returns ErrTooManyRestarts.
+func (s *Supervisor) Run(ctx context.Context) error {
+	exits := make(chan exit)
+	live := make([]*running, len(s.children))
+	var restarts []time.Time
+	// Exits of other children that arrive while we are stopping one; handled next.
+	var pending []exit
+	gen := 0
+
+	start := func(i int) {
+		gen++
+		childCtx, cancel := context.WithCancel(ctx)
+		r := &running{cancel: cancel, gen: gen, done: make(chan struct{})}
+		live[i] = r
+		go func() {
+			defer close(r.done)
+			crash := s.runChild(childCtx, s.children[i])
+			// Nobody listens once Run has returned; ctx being done unblocks us then.
+			select {
+			case exits <- exit{i, r.gen, crash}:
+			case <-ctx.Done():
+			}
+		}()
+	}
+	stop := func(i int) {
+		if r := live[i]; r != nil {
+			r.cancel()
+			// Wait, while still draining exits, so the old instance is really gone before
+			// its replacement starts.
+			for {
+				select {
+				case <-r.done:
+					live[i] = nil
+					return
+				case ex := <-exits:
+					if ex.index != i || ex.gen != r.gen {
+						pending = append(pending, ex)
+					}
+				}
+			}
+		}
+	}
+	stopAll := func() {
+		for i := len(live) - 1; i >= 0; i-- {
+			stop(i)
+		}
+	}
+
+	for i := range s.children {
+		start(i)
+	}
+	for {
+		alive := 0
+		for _, r := range live {
+			if r != nil {
+				alive++
+			}
+		}
+		if alive == 0 {
+			return nil
+		}
+
+		var ex exit
+		if len(pending) > 0 {
+			ex, pending = pending[0], pending[1:]
+		} else {
+			select {
+			case <-ctx.Done():
+				stopAll()
+				return ctx.Err()
+			case ex = <-exits:
+			}
+		}
+		if r := live[ex.index]; r == nil || r.gen != ex.gen {
+			// An instance we already replaced.
+			continue
+		}
+		if ex.crash == nil {
+			// Finished normally; nothing to restart.
+			live[ex.index].cancel()
+			live[ex.index] = nil
+			continue
+		}
+		if s.OnCrash != nil {
+			s.OnCrash(*ex.crash)
+		}
+
+		// Forget restarts that fell out of the window, then check the budget.
+		now := time.Now()
+		for s.Window > 0 && len(restarts) > 0 && now.Sub(restarts[0]) > s.Window {
+			restarts = restarts[1:]
+		}
+		if len(restarts) >= s.MaxRestarts {
+			stopAll()
+			return fmt.Errorf("%w: %d in %v, last: %v", ErrTooManyRestarts, len(restarts)+1, s.Window, ex.crash)
+		}
+		restarts = append(restarts, now)
+
+		var targets []int
+		switch s.Strategy {
+		case OneForOne:
+			targets = []int{ex.index}
+		case OneForAll:
+			for i := range s.children {
+				targets = append(targets, i)
+			}
+		case RestForOne:
+			for i := ex.index; i < len(s.children); i++ {
+				targets = append(targets, i)
+			}
+		}
+		// Stop in reverse start order, restart in start order.
+		for i := len(targets) - 1; i >= 0; i-- {
+			stop(targets[i])
+		}
+
+		select {
+		case <-time.After(s.backoff(len(restarts))):
+		case <-ctx.Done():
+			stopAll()
+			return ctx.Err()
+		}
+		for _, i := range targets {
+			start(i)
+		}
+	}
+}
+
+// backoff doubles for each restart inside the current window. Without a cap it stops doubling
+// before the Duration would overflow.
+func (s *Supervisor) backoff(n int) time.Duration {
+	d := s.Backoff
+	for i := 1; i < n && d <= math.MaxInt64/2 && (s.MaxBackoff <= 0 || d < s.MaxBackoff); i++ {
+		d *= 2
+	}
+	if s.MaxBackoff > 0 {
+		d = min(d, s.MaxBackoff)
+	}
+	return d
+}
+
+// runChild runs one child and turns a panic into a Crash, the same recover as tryRecover.
+func (s *Supervisor) runChild(ctx context.Context, c Child) (crash *Crash) {
+	defer func() {
+		if r := recover(); r != nil {
+			crash = &Crash{Child: c.Name, Panic: r, Stack: debug.Stack(), When: time.Now()}
+		}
+	}()
+	err := c.Run(ctx)
+	if err == nil || (ctx.Err() != nil && errors.Is(err, ctx.Err())) {
+		// A nil result, or the child acknowledging its own cancellation.
+		return nil
+	}
+	return &Crash{Child: c.Name, Err: err, When: time.Now()}
+}
+
+func mayPanic() {
+	panic("a problem")
+}
+
+// flaky panics through mayPanic on its first `crashes` runs, then works until cancelled.
+func flaky(name string, crashes int, log func(string)) ChildFunc {
+	var mu sync.Mutex
+	runs := 0
+	return func(ctx context.Context) error {
+		mu.Lock()
+		runs++
+		n := runs
+		mu.Unlock()
+		log(fmt.Sprintf("%s: start #%d", name, n))
+		if n <= crashes {
+			mayPanic()
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	}
+}
+
+func trySupervisor(strategy Strategy) {
+	fmt.Println("---", strategy)
+	var mu sync.Mutex
+	var events []string
+	log := func(s string) {
+		mu.Lock()
+		events = append(events, s)
+		mu.Unlock()
+	}
+
+	sup := &Supervisor{
+		Strategy:    strategy,
+		MaxRestarts: 3,
+		Window:      time.Second,
+		Backoff:     10 * time.Millisecond,
+		MaxBackoff:  50 * time.Millisecond,
+		OnCrash: func(c Crash) {
+			log(fmt.Sprintf("crash: %v (%d bytes of stack)", c, len(c.Stack)))
+		},
+	}
+	sup.Add("db", flaky("db", 0, log))
+	sup.Add("cache", flaky("cache", 1, log))
+	sup.Add("web", flaky("web", 0, log))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	err := sup.Run(ctx)
+
+	// Goroutines start in any order; print the events of each child together.
+	for _, name := range []string{"crash", "db", "cache", "web"} {
+		for _, e := range events {
+			if len(e) > len(name) && e[:len(name)] == name {
+				fmt.Println(e)
+			}
+		}
+	}
+	fmt.Println("result:", err)
+}
+
+func tryRestartLimit() {
+	fmt.Println("--- restart limit")
+	sup := &Supervisor{MaxRestarts: 3, Window: time.Second, Backoff: time.Millisecond, MaxBackoff: 4 * time.Millisecond}
+	crashes := 0
+	sup.OnCrash = func(c Crash) { crashes++ }
+	sup.Add("always", func(ctx context.Context) error {
+		mayPanic()
+		return nil
+	})
+	err := sup.Run(context.Background())
+	fmt.Println(crashes, "crashes;", err)
+	fmt.Println(errors.Is(err, ErrTooManyRestarts))
+
+	// A child that returns nil is done and is not restarted.
+	sup = &Supervisor{MaxRestarts: 1, Window: time.Second}
+	sup.Add("once", func(ctx context.Context) error { return nil })
+	fmt.Println("finished:", sup.Run(context.Background()))
+}
+
+func main() {
+	// supervisor strategies
+	trySupervisor(OneForOne)
+	trySupervisor(OneForAll)
+	trySupervisor(RestForOne)
+
+	// restart limits
+	tryRestartLimit()
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	ms := time.Millisecond
+	for _, c := range []struct {
+		backoff, max time.Duration
+		n            int
+		want         time.Duration
+	}{
+		{10 * ms, 50 * ms, 1, 10 * ms},
+		{10 * ms, 50 * ms, 2, 20 * ms},
+		{10 * ms, 50 * ms, 3, 40 * ms},
+		{10 * ms, 50 * ms, 4, 50 * ms},
+		{10 * ms, 50 * ms, 10, 50 * ms},
+		// No cap: keeps doubling.
+		{10 * ms, 0, 1, 10 * ms},
+		{10 * ms, 0, 4, 80 * ms},
+		{10 * ms, 0, 11, 10240 * ms},
+		{0, 0, 5, 0},
+	} {
+		s := &Supervisor{Backoff: c.backoff, MaxBackoff: c.max}
+		if got := s.backoff(c.n); got != c.want {
+			t.Errorf("backoff(%v, max %v, %d) = %v, want %v", c.backoff, c.max, c.n, got, c.want)
+		}
+	}
+
+	// Without a cap, doubling stops before the Duration overflows.
+	s := &Supervisor{Backoff: time.Second}
+	if got := s.backoff(1000); got <= math.MaxInt64/4 {
+		t.Errorf("backoff(1s, no cap, 1000) = %v, want more than a quarter of the largest Duration", got)
+	}
+}
+
+// startCounter counts how often each child started, for checking strategies.
+type startCounter struct {
+	mu     sync.Mutex
+	starts map[string]int
+}
+
+func (sc *startCounter) log(s string) {
+	name, _, _ := strings.Cut(s, ":")
+	sc.mu.Lock()
+	sc.starts[name]++
+	sc.mu.Unlock()
+}
+
+func TestStrategies(t *testing.T) {
+	for _, c := range []struct {
+		strategy Strategy
+		want     map[string]int
+	}{
+		{OneForOne, map[string]int{"db": 1, "cache": 2, "web": 1}},
+		{OneForAll, map[string]int{"db": 2, "cache": 2, "web": 2}},
+		{RestForOne, map[string]int{"db": 1, "cache": 2, "web": 2}},
+	} {
+		t.Run(c.strategy.String(), func(t *testing.T) {
+			sc := &startCounter{starts: map[string]int{}}
+			var crashes []Crash
+			sup := &Supervisor{
+				Strategy:    c.strategy,
+				MaxRestarts: 3,
+				Window:      time.Second,
+				Backoff:     time.Millisecond,
+				OnCrash:     func(cr Crash) { crashes = append(crashes, cr) },
+			}
+			sup.Add("db", flaky("db", 0, sc.log))
+			sup.Add("cache", flaky("cache", 1, sc.log))
+			sup.Add("web", flaky("web", 0, sc.log))
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			if err := sup.Run(ctx); !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Run = %v, want the deadline", err)
+			}
+			for name, n := range c.want {
+				if sc.starts[name] != n {
+					t.Errorf("%s started %d times, want %d", name, sc.starts[name], n)
+				}
+			}
+			if len(crashes) != 1 {
+				t.Fatalf("%d crashes, want 1", len(crashes))
+			}
+			cr := crashes[0]
+			if cr.Child != "cache" || cr.Panic != "a problem" || !strings.Contains(string(cr.Stack), "mayPanic") {
+				t.Errorf("crash = %v, stack %d bytes", cr, len(cr.Stack))
+			}
+		})
+	}
+}
+
+func TestRestartLimit(t *testing.T) {
+	sup := &Supervisor{MaxRestarts: 3, Window: time.Second, Backoff: time.Millisecond, MaxBackoff: 4 * time.Millisecond}
+	crashes := 0
+	sup.OnCrash = func(c Crash) { crashes++ }
+	sup.Add("always", func(ctx context.Context) error {
+		mayPanic()
+		return nil
+	})
+	err := sup.Run(context.Background())
+	if !errors.Is(err, ErrTooManyRestarts) {
+		t.Errorf("Run = %v, want ErrTooManyRestarts", err)
+	}
+	// Three restarts are allowed; the fourth crash is one too many.
+	if crashes != 4 {
+		t.Errorf("%d crashes, want 4", crashes)
+	}
+}
+
+// Without a Window every restart counts, however long ago it was.
+func TestRestartLimitWithoutWindow(t *testing.T) {
+	sup := &Supervisor{MaxRestarts: 3, Backoff: time.Millisecond}
+	crashes := 0
+	sup.OnCrash = func(c Crash) { crashes++ }
+	sup.Add("always", func(ctx context.Context) error {
+		mayPanic()
+		return nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := sup.Run(ctx); !errors.Is(err, ErrTooManyRestarts) {
+		t.Errorf("Run = %v, want ErrTooManyRestarts", err)
+	}
+	if crashes != 4 {
+		t.Errorf("%d crashes, want 4", crashes)
+	}
+}
+
+func TestErrorIsCrash(t *testing.T) {
+	boom := errors.New("boom")
+	sup := &Supervisor{MaxRestarts: 0, Window: time.Second}
+	var got Crash
+	sup.OnCrash = func(c Crash) { got = c }
+	sup.Add("fails", func(ctx context.Context) error { return boom })
+	if err := sup.Run(context.Background()); !errors.Is(err, ErrTooManyRestarts) {
+		t.Errorf("Run = %v, want ErrTooManyRestarts", err)
+	}
+	if !errors.Is(got.Err, boom) || got.Panic != nil || got.Stack != nil {
+		t.Errorf("crash = %+v", got)
+	}
+}
+
+// A child that returns nil is done and is not restarted.
+func TestFinishedChild(t *testing.T) {
+	sup := &Supervisor{MaxRestarts: 1, Window: time.Second}
+	runs := 0
+	sup.Add("once", func(ctx context.Context) error {
+		runs++
+		return nil
+	})
+	if err := sup.Run(context.Background()); err != nil || runs != 1 {
+		t.Errorf("Run = %v after %d runs, want nil after 1", err, runs)
+	}
+}
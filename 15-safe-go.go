@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+////// panics in goroutines: the recover in tryRecover (8-extra-part-2.go) only protects the goroutine
+// it runs in. A panic in a goroutine started like the workers of tryWaitGroups (7-extra.go) takes
+// the whole program down. Group is an errgroup that recovers those panics and returns them as
+// errors instead.
+
+// PanicError is a recovered panic: the value passed to panic and the stack where it happened.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value when it was an error, e.g. panic(io.EOF).
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Group runs goroutines and collects the first error. The zero value has no limit and does not
+// cancel anything; use WithContext for cancellation.
+type Group struct {
+	wg     sync.WaitGroup
+	sem    chan struct{}
+	cancel context.CancelCauseFunc
+
+	errOnce sync.Once
+	err     error
+}
+
+// WithContext returns a Group whose context is cancelled by the first error or panic, or when
+// Wait returns.
+func WithContext(ctx context.Context) (*Group, context.Context) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	return &Group{cancel: cancel}, ctx
+}
+
+// SetLimit caps how many goroutines run at once; Go blocks while the group is full. n < 0 means
+// no limit. It must not be called while goroutines are running.
+func (g *Group) SetLimit(n int) {
+	if n < 0 {
+		g.sem = nil
+		return
+	}
+	g.sem = make(chan struct{}, n)
+}
+
+// Go runs fn in a new goroutine.
+func (g *Group) Go(fn func() error) {
+	if g.sem != nil {
+		g.sem <- struct{}{}
+	}
+	g.wg.Add(1)
+	go func() {
+		defer g.done()
+		if err := safeCall(fn); err != nil {
+			g.errOnce.Do(func() {
+				g.err = err
+				if g.cancel != nil {
+					g.cancel(err)
+				}
+			})
+		}
+	}()
+}
+
+func (g *Group) done() {
+	if g.sem != nil {
+		<-g.sem
+	}
+	g.wg.Done()
+}
+
+// Wait blocks until every goroutine has returned, then returns the first error.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	if g.cancel != nil {
+		g.cancel(g.err)
+	}
+	return g.err
+}
+
+// safeCall runs fn and turns a panic into a *PanicError.
+func safeCall(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return fn()
+}
+
+// SafeGo starts a single goroutine and delivers its error, or its panic, on the returned channel.
+func SafeGo(fn func() error) <-chan error {
+	errc := make(chan error, 1)
+	go func() {
+		errc <- safeCall(fn)
+	}()
+	return errc
+}
+
+// SafeCounter from 6-concurrency.go.
+type SafeCounter struct {
+	mu sync.Mutex
+	v  map[string]int
+}
+
+func (c *SafeCounter) Inc(key string) {
+	c.mu.Lock()
+	c.v[key]++
+	c.mu.Unlock()
+}
+
+func (c *SafeCounter) Value(key string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.v[key]
+}
+
+func mayPanic() {
+	panic("a problem")
+}
+
+func worker(id int) {
+	fmt.Printf("Worker %d starting\n", id)
+	time.Sleep(100 * time.Millisecond)
+	fmt.Printf("Worker %d done\n", id)
+}
+
+func trySafeGroup() {
+	// tryWaitGroups, with the Group doing the WaitGroup bookkeeping and two workers at a time.
+	var g Group
+	g.SetLimit(2)
+	for i := 1; i <= 4; i++ {
+		g.Go(func() error {
+			worker(i)
+			return nil
+		})
+	}
+	fmt.Println("wait:", g.Wait())
+
+	// trySyncMutex, with one goroutine panicking part way through. The program survives.
+	c := SafeCounter{v: make(map[string]int)}
+	g = Group{}
+	for i := 0; i < 1000; i++ {
+		g.Go(func() error {
+			if i == 500 {
+				mayPanic()
+			}
+			c.Inc("somekey")
+			return nil
+		})
+	}
+	err := g.Wait()
+	fmt.Println("count:", c.Value("somekey"), "err:", err)
+
+	var pe *PanicError
+	if errors.As(err, &pe) {
+		fmt.Printf("recovered %q with a %d byte stack\n", pe.Value, len(pe.Stack))
+	}
+}
+
+func tryGroupCancellation() {
+	// The first failure cancels the context the others are watching.
+	g, ctx := WithContext(context.Background())
+	start := time.Now()
+	g.Go(func() error {
+		time.Sleep(50 * time.Millisecond)
+		panic(fmt.Errorf("lost connection: %w", context.DeadlineExceeded))
+	})
+	for i := 0; i < 3; i++ {
+		g.Go(func() error {
+			select {
+			case <-time.After(5 * time.Second):
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}
+	err := g.Wait()
+	fmt.Println(err, "after", time.Since(start).Round(50*time.Millisecond))
+	// A panic with an error value unwraps to it.
+	fmt.Println(errors.Is(err, context.DeadlineExceeded), errors.Is(context.Cause(ctx), context.DeadlineExceeded))
+
+	// A single goroutine on its own.
+	err = <-SafeGo(func() error {
+		var m map[string]int
+		m["boom"] = 1
+		return nil
+	})
+	fmt.Println(err)
+}
+
+func main() {
+	// panic-safe groups
+	trySafeGroup()
+
+	// cancellation on the first error
+	tryGroupCancellation()
+}
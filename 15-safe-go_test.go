@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGroupFirstErrorWins(t *testing.T) {
+	first := errors.New("first")
+	g, ctx := WithContext(context.Background())
+	g.Go(func() error { return first })
+	// The others only fail once the first error has cancelled the context.
+	for range 5 {
+		g.Go(func() error {
+			select {
+			case <-ctx.Done():
+				return errors.New("later")
+			case <-time.After(5 * time.Second):
+				return nil
+			}
+		})
+	}
+	if err := g.Wait(); err != first {
+		t.Errorf("Wait = %v, want %v", err, first)
+	}
+	if ctx.Err() == nil || context.Cause(ctx) != first {
+		t.Errorf("context: %v, cause %v; want cancelled by %v", ctx.Err(), context.Cause(ctx), first)
+	}
+}
+
+func TestGroupWaitCancelsContext(t *testing.T) {
+	g, ctx := WithContext(context.Background())
+	g.Go(func() error { return nil })
+	if err := g.Wait(); err != nil {
+		t.Errorf("Wait = %v", err)
+	}
+	if ctx.Err() == nil {
+		t.Error("context still live after Wait")
+	}
+}
+
+// The zero Group has no context, and an error doesn't stop the others.
+func TestGroupZeroValue(t *testing.T) {
+	var g Group
+	var ran atomic.Int32
+	g.Go(func() error { return io.EOF })
+	for range 10 {
+		g.Go(func() error {
+			ran.Add(1)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != io.EOF {
+		t.Errorf("Wait = %v, want EOF", err)
+	}
+	if ran.Load() != 10 {
+		t.Errorf("%d goroutines ran, want 10", ran.Load())
+	}
+}
+
+func TestGroupSetLimit(t *testing.T) {
+	for _, limit := range []int{1, 3} {
+		var g Group
+		g.SetLimit(limit)
+		var running, most atomic.Int32
+		for range 20 {
+			g.Go(func() error {
+				n := running.Add(1)
+				for {
+					m := most.Load()
+					if n <= m || most.CompareAndSwap(m, n) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+				running.Add(-1)
+				return nil
+			})
+		}
+		if err := g.Wait(); err != nil {
+			t.Fatal(err)
+		}
+		if most.Load() > int32(limit) || most.Load() < 1 {
+			t.Errorf("limit %d: %d ran at once", limit, most.Load())
+		}
+	}
+}
+
+func TestGroupPanic(t *testing.T) {
+	g, ctx := WithContext(context.Background())
+	var count atomic.Int32
+	for i := range 100 {
+		g.Go(func() error {
+			if i == 50 {
+				mayPanic()
+			}
+			count.Add(1)
+			return nil
+		})
+	}
+	err := g.Wait()
+	var pe *PanicError
+	if !errors.As(err, &pe) {
+		t.Fatalf("Wait = %v, want a *PanicError", err)
+	}
+	if pe.Value != "a problem" || !strings.Contains(string(pe.Stack), "mayPanic") {
+		t.Errorf("panic %v with stack:\n%s", pe.Value, pe.Stack)
+	}
+	if count.Load() != 99 {
+		t.Errorf("%d goroutines finished, want 99", count.Load())
+	}
+	if !errors.As(context.Cause(ctx), &pe) {
+		t.Errorf("context cause = %v, want the panic", context.Cause(ctx))
+	}
+}
+
+// A panic with an error value unwraps to it; any other value unwraps to nothing.
+func TestPanicErrorUnwrap(t *testing.T) {
+	err := safeCall(func() error { panic(io.ErrUnexpectedEOF) })
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("%v does not unwrap to ErrUnexpectedEOF", err)
+	}
+	err = safeCall(func() error { panic(42) })
+	if errors.Unwrap(err) != nil || err.Error() != "panic: 42" {
+		t.Errorf("panic(42) gave %q, unwrapping to %v", err, errors.Unwrap(err))
+	}
+	if err := safeCall(func() error { return io.EOF }); err != io.EOF {
+		t.Errorf("safeCall = %v, want EOF", err)
+	}
+}
+
+func TestSafeGo(t *testing.T) {
+	if err := <-SafeGo(func() error { return nil }); err != nil {
+		t.Errorf("SafeGo = %v", err)
+	}
+	if err := <-SafeGo(func() error { return io.EOF }); err != io.EOF {
+		t.Errorf("SafeGo = %v, want EOF", err)
+	}
+	err := <-SafeGo(func() error {
+		var m map[string]int
+		m["boom"] = 1
+		return nil
+	})
+	var pe *PanicError
+	if !errors.As(err, &pe) || len(pe.Stack) == 0 {
+		t.Fatalf("SafeGo = %v, want a *PanicError with a stack", err)
+	}
+	// A runtime panic is a runtime.Error, and so an error PanicError unwraps to.
+	if errors.Unwrap(err) == nil {
+		t.Error("runtime panic does not unwrap")
+	}
+}
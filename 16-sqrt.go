@@ -0,0 +1,196 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"math/cmplx"
+)
+
+////// Newton's method: sqrt in 2-flowcontrol.go hands the work to math.Sqrt and glues an "i" onto
+// negative results. Here the root is computed the way the tour's exercise suggests: start with a
+// guess z and keep improving it with z -= (z*z - x) / (2*z) until it stops moving.
+
+var (
+	ErrNegative   = errors.New("negative input has no real root")
+	ErrNaN        = errors.New("input is NaN")
+	ErrBadDegree  = errors.New("root degree must be positive")
+	ErrNoConverge = errors.New("did not converge")
+)
+
+// maxIterations bounds every loop here; Newton doubles the correct digits each step, so
+// real inputs converge in well under 10.
+const maxIterations = 100
+
+// Root is the outcome of an iterative root: the value, how many Newton steps it took, and the
+// relative change of the last step, which is the tolerance actually reached.
+type Root struct {
+	Value      float64
+	Iterations int
+	Tolerance  float64
+}
+
+// guess is a starting point just above the n-th root of x, taken from the exponent: x = f * 2^e
+// with f < 1, so the root is below 2^(e/n) by less than a factor of 2^(1/n). From above, Newton
+// moves steadily down to the root. From below, the first step can overshoot so far that z^(n-1)
+// overflows for large n.
+func guess(x float64, n int) float64 {
+	_, exp := math.Frexp(x)
+	return math.Exp2(float64(exp) / float64(n))
+}
+
+// NewtonSqrt returns the square root of x, stopping once a step changes z by less than tol
+// relative to z. tol <= 0 means full float64 precision.
+func NewtonSqrt(x, tol float64) (Root, error) {
+	return NthRoot(x, 2, tol)
+}
+
+// NthRoot returns the real n-th root of x with Newton's method:
+// z -= (z^n - x) / (n * z^(n-1)). Negative x only has a real root for odd n.
+func NthRoot(x float64, n int, tol float64) (Root, error) {
+	switch {
+	case n < 1:
+		return Root{}, ErrBadDegree
+	case math.IsNaN(x):
+		return Root{}, ErrNaN
+	case x < 0 && n%2 == 0:
+		return Root{}, fmt.Errorf("%d-th root of %g: %w", n, x, ErrNegative)
+	case x == 0 || math.IsInf(x, 0) || n == 1:
+		return Root{Value: x}, nil
+	case x < 0:
+		r, err := NthRoot(-x, n, tol)
+		r.Value = -r.Value
+		return r, err
+	}
+	if tol <= 0 {
+		// One unit in the last place. Near the root z can flip between two neighbouring
+		// floats forever, so asking for less than this would never finish.
+		tol = 0x1p-52
+	}
+
+	// z^n overflows for x near math.MaxFloat64 and loses bits for subnormal x. So work on
+	// m = x / 2^(n*k), which lies in [0.5, 2^n), and multiply the root by 2^k at the end;
+	// scaling by powers of two is exact.
+	frac, exp := math.Frexp(x)
+	shift := exp % n
+	if shift < 0 {
+		shift += n
+	}
+	m, k := math.Ldexp(frac, shift), (exp-shift)/n
+
+	z := guess(m, n)
+	for i := 1; i <= maxIterations; i++ {
+		zn1 := math.Pow(z, float64(n-1))
+		next := z - (zn1*z-m)/(float64(n)*zn1)
+		delta := math.Abs(next-z) / next
+		if delta <= tol || next == z {
+			return Root{Value: math.Ldexp(next, k), Iterations: i, Tolerance: delta}, nil
+		}
+		z = next
+	}
+	return Root{Value: math.Ldexp(z, k), Iterations: maxIterations}, ErrNoConverge
+}
+
+// Sqrt replaces the string-returning sqrt: negative inputs give an imaginary result instead of
+// a number with "i" glued on.
+func Sqrt(x float64) complex128 {
+	if x < 0 {
+		r, _ := NewtonSqrt(-x, 0)
+		return complex(0, r.Value)
+	}
+	if math.IsNaN(x) {
+		return cmplx.NaN()
+	}
+	r, _ := NewtonSqrt(x, 0)
+	return complex(r.Value, 0)
+}
+
+////// arbitrary precision: the same iteration on big.Float. Each step roughly doubles the number
+// of correct bits, so it keeps going until a step no longer changes the value at prec bits.
+
+// BigSqrt returns the square root of x with prec bits of mantissa, and the steps it took.
+func BigSqrt(x *big.Float, prec uint) (*big.Float, int, error) {
+	switch x.Sign() {
+	case -1:
+		return nil, 0, ErrNegative
+	case 0:
+		return new(big.Float).SetPrec(prec), 0, nil
+	}
+	if x.IsInf() {
+		return new(big.Float).SetPrec(prec).SetInf(false), 0, nil
+	}
+	// Work with a few guard bits so the last step rounds correctly.
+	work := prec + 32
+	xx := new(big.Float).SetPrec(work).Set(x)
+	exp := x.MantExp(nil)
+	z := new(big.Float).SetMantExp(big.NewFloat(1), exp/2).SetPrec(work)
+
+	two := big.NewFloat(2)
+	t := new(big.Float).SetPrec(work)
+	prev := new(big.Float).SetPrec(prec)
+	for i := 1; i <= maxIterations*10; i++ {
+		// z = (z + x/z) / 2, the same step written without the subtraction.
+		t.Quo(xx, z)
+		t.Add(t, z)
+		z.Quo(t, two)
+
+		cur := new(big.Float).SetPrec(prec).Set(z)
+		if cur.Cmp(prev) == 0 {
+			return cur, i, nil
+		}
+		prev = cur
+	}
+	return prev, maxIterations * 10, ErrNoConverge
+}
+
+func tryNewtonSqrt() {
+	for _, x := range []float64{2, 4, 1e-300, 1e300, math.MaxFloat64, 5e-324, 0, math.Inf(1)} {
+		r, err := NewtonSqrt(x, 0)
+		fmt.Printf("sqrt(%g) = %v in %d steps (tol %.1e) %v, math.Sqrt: %v\n", x, r.Value, r.Iterations, r.Tolerance, err, math.Sqrt(x))
+	}
+
+	// A looser tolerance stops earlier.
+	r, _ := NewtonSqrt(2, 1e-3)
+	fmt.Printf("sqrt(2) to 1e-3: %v in %d steps\n", r.Value, r.Iterations)
+
+	// sqrt(2) and sqrt(-4) from 2-flowcontrol.go, now as complex128.
+	fmt.Println(Sqrt(2), Sqrt(-4))
+	_, err := NewtonSqrt(-4, 0)
+	fmt.Println(err, errors.Is(err, ErrNegative))
+
+	for _, c := range []struct {
+		x float64
+		n int
+	}{{27, 3}, {-27, 3}, {1024, 10}, {2, 12}, {16, 4}} {
+		r, err := NthRoot(c.x, c.n, 0)
+		fmt.Printf("root %d of %g = %v (%d steps) %v\n", c.n, c.x, r.Value, r.Iterations, err)
+	}
+	_, err = NthRoot(-16, 4, 0)
+	fmt.Println(err)
+}
+
+func tryBigSqrt() {
+	// sqrt(2) to about 100 decimal digits (333 bits).
+	root, steps, _ := BigSqrt(big.NewFloat(2), 333)
+	fmt.Printf("sqrt(2) = %s (%d steps)\n", root.Text('f', 100), steps)
+
+	// The standard library has big.Float.Sqrt too; they agree.
+	want := new(big.Float).SetPrec(333).Sqrt(big.NewFloat(2))
+	fmt.Println("matches big.Float.Sqrt:", root.Cmp(want) == 0)
+
+	x, _, _ := big.ParseFloat("1e1000", 10, 4000, big.ToNearestEven)
+	root, steps, _ = BigSqrt(x, 4000)
+	fmt.Printf("sqrt(1e1000) = %s (%d steps)\n", root.Text('g', 10), steps)
+
+	_, _, err := BigSqrt(big.NewFloat(-1), 64)
+	fmt.Println(err)
+}
+
+func main() {
+	// Newton's method
+	tryNewtonSqrt()
+
+	// arbitrary precision
+	tryBigSqrt()
+}
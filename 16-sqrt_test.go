@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"math/rand/v2"
+	"testing"
+)
+
+// ulps is the distance between two positive floats in units in the last place.
+func ulps(a, b float64) uint64 {
+	ia, ib := math.Float64bits(a), math.Float64bits(b)
+	if ia > ib {
+		return ia - ib
+	}
+	return ib - ia
+}
+
+// Over random inputs of every magnitude, Newton agrees with math.Sqrt to within maxULP, and
+// squaring the result gives x back.
+func TestNewtonSqrtMatchesMathSqrt(t *testing.T) {
+	const maxULP = 1
+	rng := rand.New(rand.NewPCG(1, 2))
+	worst := uint64(0)
+	for range 100_000 {
+		x := math.Ldexp(rng.Float64()+0.5, rng.IntN(2000)-1000)
+		r, err := NewtonSqrt(x, 0)
+		if err != nil {
+			t.Fatalf("NewtonSqrt(%g): %v", x, err)
+		}
+		d := ulps(r.Value, math.Sqrt(x))
+		if d > maxULP {
+			t.Fatalf("NewtonSqrt(%g) = %v, math.Sqrt = %v: %d ulp apart, want at most %d", x, r.Value, math.Sqrt(x), d, maxULP)
+		}
+		worst = max(worst, d)
+		if rel := math.Abs(r.Value*r.Value-x) / x; rel > 4*0x1p-52 {
+			t.Fatalf("NewtonSqrt(%g)^2 is off by %g relative", x, rel)
+		}
+	}
+	t.Logf("worst difference from math.Sqrt: %d ulp", worst)
+}
+
+// rootError is the relative error of r as an n-th root of x. math.Pow(x, 1/n) is no reference
+// for large x, since 1/n is rounded, so it raises r to the n-th power exactly instead and uses
+// that r^n = x * (1+e)^n ≈ x * (1 + n*e).
+func rootError(r, x float64, n int) float64 {
+	if math.IsNaN(r) || math.IsInf(r, 0) {
+		return math.Inf(1)
+	}
+	p := new(big.Float).SetPrec(2048).SetFloat64(1)
+	for range n {
+		p.Mul(p, big.NewFloat(r))
+	}
+	q, _ := p.Quo(p, big.NewFloat(x)).Float64()
+	return math.Abs(q-1) / float64(n)
+}
+
+// Large degrees, where a start below the root used to overflow z^(n-1).
+func TestNthRootLargeDegree(t *testing.T) {
+	const maxErr = 2 * 0x1p-52 // two ulp
+	check := func(x float64, n int) {
+		t.Helper()
+		r, err := NthRoot(x, n, 0)
+		if err != nil || !(rootError(r.Value, x, n) <= maxErr) {
+			t.Errorf("NthRoot(%g, %d) = %v, %v in %d steps; relative error %g", x, n, r.Value, err, r.Iterations, rootError(r.Value, x, n))
+		}
+	}
+	check(math.Pow(1.9, 60), 60)
+	check(1e15, 60)
+	check(1.5e300, 100)
+	rng := rand.New(rand.NewPCG(3, 4))
+	for range 2000 {
+		check(math.Ldexp(rng.Float64()+0.5, rng.IntN(2000)-1000), 20+rng.IntN(81))
+	}
+}
+
+func TestNewtonSqrtEdgeCases(t *testing.T) {
+	for _, x := range []float64{0, 1, 4, 2, math.SmallestNonzeroFloat64, math.MaxFloat64, math.Inf(1)} {
+		r, err := NewtonSqrt(x, 0)
+		if err != nil || ulps(r.Value, math.Sqrt(x)) > 1 {
+			t.Errorf("NewtonSqrt(%g) = %v, %v; want %v", x, r.Value, err, math.Sqrt(x))
+		}
+	}
+	if _, err := NewtonSqrt(-4, 0); !errors.Is(err, ErrNegative) {
+		t.Errorf("NewtonSqrt(-4): %v, want ErrNegative", err)
+	}
+	if _, err := NewtonSqrt(math.NaN(), 0); !errors.Is(err, ErrNaN) {
+		t.Errorf("NewtonSqrt(NaN): %v, want ErrNaN", err)
+	}
+	if got := Sqrt(-4); got != 2i {
+		t.Errorf("Sqrt(-4) = %v, want 2i", got)
+	}
+}
+
+func TestNthRoot(t *testing.T) {
+	for _, c := range []struct {
+		x    float64
+		n    int
+		want float64
+	}{
+		{27, 3, 3}, {-27, 3, -3}, {1024, 10, 2}, {16, 4, 2}, {5, 1, 5},
+		{2, 12, math.Pow(2, 1.0/12)},
+	} {
+		r, err := NthRoot(c.x, c.n, 0)
+		if err != nil || ulps(math.Abs(r.Value), math.Abs(c.want)) > 1 || (r.Value < 0) != (c.want < 0) {
+			t.Errorf("NthRoot(%g, %d) = %v, %v; want %v", c.x, c.n, r.Value, err, c.want)
+		}
+	}
+	if _, err := NthRoot(-16, 4, 0); !errors.Is(err, ErrNegative) {
+		t.Errorf("NthRoot(-16, 4): %v, want ErrNegative", err)
+	}
+	if _, err := NthRoot(8, 0, 0); !errors.Is(err, ErrBadDegree) {
+		t.Errorf("NthRoot(8, 0): %v, want ErrBadDegree", err)
+	}
+}
+
+func TestBigSqrt(t *testing.T) {
+	for _, prec := range []uint{53, 333, 4000} {
+		root, _, err := BigSqrt(big.NewFloat(2), prec)
+		want := new(big.Float).SetPrec(prec).Sqrt(big.NewFloat(2))
+		if err != nil || root.Cmp(want) != 0 {
+			t.Errorf("BigSqrt(2, %d) = %v, %v; want %v", prec, root, err, want)
+		}
+	}
+	if _, _, err := BigSqrt(big.NewFloat(-1), 64); !errors.Is(err, ErrNegative) {
+		t.Errorf("BigSqrt(-1): %v, want ErrNegative", err)
+	}
+}
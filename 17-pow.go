@@ -0,0 +1,270 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"math/bits"
+	"unsafe"
+)
+
+////// exact powers: pow and pow2 (2-flowcontrol.go) work on float64 through math.Pow, so large
+// integer results lose digits. Exponentiation by squaring computes x^n exactly with about
+// log2(n) multiplications, and every multiplication can be checked for overflow.
+
+// Integer, signed and Limits are the same as in 19-checked-arithmetic.go. Every lesson is a
+// program of its own, so they are copied rather than imported; keep the copies identical.
+
+// Integer is every integer type; the same set as golang.org/x/exp/constraints.Integer.
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+var (
+	ErrOverflow         = errors.New("integer overflow")
+	ErrNegativeExponent = errors.New("negative exponent has no integer result")
+	ErrZeroModulus      = errors.New("modulus must not be zero")
+)
+
+func signed[T Integer]() bool {
+	var zero T
+	return zero-1 < zero
+}
+
+// Limits returns the smallest and largest values of T.
+func Limits[T Integer]() (lo, hi T) {
+	bits := unsafe.Sizeof(lo) * 8
+	if signed[T]() {
+		lo = T(1) << (bits - 1)
+		return lo, ^lo
+	}
+	return 0, ^T(0)
+}
+
+// mulChecked multiplies and reports whether the result overflowed.
+func mulChecked[T Integer](a, b T) (T, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	r := a * b
+	if r/b != a {
+		return r, false
+	}
+	// lo * -1 wraps to lo again, which the division above can't see.
+	if lo, _ := Limits[T](); signed[T]() && ((a == lo && b == ^T(0)) || (b == lo && a == ^T(0))) {
+		return r, false
+	}
+	return r, true
+}
+
+// Pow returns base^exp exactly, or ErrOverflow if it doesn't fit in T. 0^0 is 1. A negative
+// exponent only has an integer result for bases 1 and -1.
+func Pow[T Integer](base, exp T) (T, error) {
+	if exp < 0 {
+		switch {
+		case base == 1:
+			return 1, nil
+		case signed[T]() && base == ^T(0): // -1
+			if exp%2 == 0 {
+				return 1, nil
+			}
+			return base, nil
+		}
+		return 0, fmt.Errorf("%v^%v: %w", base, exp, ErrNegativeExponent)
+	}
+
+	result := T(1)
+	b, e := base, exp
+	for e > 0 {
+		var ok bool
+		if e&1 == 1 {
+			if result, ok = mulChecked(result, b); !ok {
+				return 0, fmt.Errorf("%v^%v: %w", base, exp, ErrOverflow)
+			}
+		}
+		e >>= 1
+		if e > 0 {
+			if b, ok = mulChecked(b, b); !ok {
+				return 0, fmt.Errorf("%v^%v: %w", base, exp, ErrOverflow)
+			}
+		}
+	}
+	return result, nil
+}
+
+// PowLimit is pow from 2-flowcontrol.go for integers: x^n if it is below lim, lim otherwise.
+// A result too big for T is certainly not below lim, so overflowing upwards gives lim. A
+// negative x to an odd power can overflow downwards instead, to a value below lim that T can't
+// hold, which is ErrOverflow. Negative exponents are ErrNegativeExponent, as in Pow; float64
+// would give a fraction that an integer can't.
+func PowLimit[T Integer](x, n, lim T) (T, error) {
+	v, err := Pow(x, n)
+	switch {
+	case errors.Is(err, ErrOverflow) && x < 0 && n%2 == 1:
+		return 0, err
+	case errors.Is(err, ErrOverflow):
+		return lim, nil
+	case err != nil:
+		return 0, err
+	case v >= lim:
+		return lim, nil
+	}
+	return v, nil
+}
+
+// SaturatingPow clamps to the largest or smallest value of T instead of failing.
+func SaturatingPow[T Integer](base, exp T) T {
+	v, err := Pow(base, exp)
+	if errors.Is(err, ErrOverflow) {
+		lo, hi := Limits[T]()
+		if base < 0 && exp%2 == 1 {
+			return lo
+		}
+		return hi
+	}
+	return v
+}
+
+////// modular exponentiation: base^exp mod m without ever computing base^exp. Products are
+// formed in 128 bits with math/bits, so any 64-bit modulus works.
+
+func mulMod(a, b, m uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	return bits.Rem64(hi, lo, m)
+}
+
+// PowMod returns base^exp mod m.
+func PowMod(base, exp, m uint64) (uint64, error) {
+	if m == 0 {
+		return 0, ErrZeroModulus
+	}
+	result := uint64(1) % m
+	base %= m
+	for exp > 0 {
+		if exp&1 == 1 {
+			result = mulMod(result, base, m)
+		}
+		base = mulMod(base, base, m)
+		exp >>= 1
+	}
+	return result, nil
+}
+
+// BigPow returns base^exp as a big.Int, for results that fit in no machine word.
+func BigPow(base int64, exp uint64) *big.Int {
+	return new(big.Int).Exp(big.NewInt(base), new(big.Int).SetUint64(exp), nil)
+}
+
+////// Miller–Rabin: write n-1 = d * 2^s with d odd. For a prime n, every base a has a^d = 1 or
+// a^(d*2^r) = -1 for some r < s (mod n). A base for which neither holds proves n composite.
+// Checking the first twelve primes as bases is enough for every n below 2^64.
+
+var millerRabinBases = []uint64{2, 3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37}
+
+func IsPrime(n uint64) bool {
+	if n < 2 {
+		return false
+	}
+	for _, p := range millerRabinBases {
+		if n%p == 0 {
+			return n == p
+		}
+	}
+
+	d, s := n-1, 0
+	for d%2 == 0 {
+		d /= 2
+		s++
+	}
+witness:
+	for _, a := range millerRabinBases {
+		x, _ := PowMod(a, d, n)
+		if x == 1 || x == n-1 {
+			continue
+		}
+		for r := 1; r < s; r++ {
+			x = mulMod(x, x, n)
+			if x == n-1 {
+				continue witness
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func pow(x, n, lim float64) float64 {
+	if v := math.Pow(x, n); v < lim {
+		return v
+	}
+	return lim
+}
+
+func tryIntegerPow() {
+	// Edge cases.
+	for _, c := range [][2]int64{{0, 0}, {0, 5}, {2, 10}, {-2, 63}, {2, 63}, {-1, -7}, {1, -3}, {2, -1}, {3, 39}, {3, 40}} {
+		v, err := Pow(c[0], c[1])
+		fmt.Printf("%d^%d = %d %v\n", c[0], c[1], v, err)
+	}
+
+	// Just below and just above MaxInt64: 3037000499^2 fits, 3037000500^2 doesn't.
+	fmt.Println(Pow[int64](3037000499, 2))
+	fmt.Println(Pow[int64](3037000500, 2))
+	fmt.Println(Pow[uint64](2, 63))
+	fmt.Println(Pow[uint64](2, 64))
+	fmt.Println(Pow[int8](-2, 7))
+	fmt.Println(SaturatingPow[int8](-2, 9), SaturatingPow[uint16](10, 5))
+
+	// Floats lose the low digits; the exact version doesn't.
+	exact, _ := Pow[int64](3, 39)
+	fmt.Println(int64(math.Pow(3, 39)), exact)
+
+	// pow from 2-flowcontrol.go and its integer twin.
+	fmt.Println(pow(3, 2, 10), pow(3, 3, 20))
+	fmt.Println(PowLimit(3, 2, 10))
+	fmt.Println(PowLimit(3, 3, 20))
+	fmt.Println(PowLimit[int64](10, 30, math.MaxInt64))
+	fmt.Println(PowLimit[int64](-10, 19, 0))
+	fmt.Println(PowLimit(2, -1, 10))
+
+	fmt.Println("2^200 =", BigPow(2, 200))
+}
+
+func tryPowMod() {
+	v, _ := PowMod(4, 13, 497)
+	fmt.Println("4^13 mod 497 =", v)
+	// Fermat: a^(p-1) = 1 mod p, even for a prime close to 2^64.
+	p := uint64(18446744073709551557)
+	v, _ = PowMod(123456789, p-1, p)
+	fmt.Println("a^(p-1) mod p =", v)
+	_, err := PowMod(2, 3, 0)
+	fmt.Println(err)
+
+	var primes []uint64
+	for n := uint64(0); n < 60; n++ {
+		if IsPrime(n) {
+			primes = append(primes, n)
+		}
+	}
+	fmt.Println(primes)
+
+	// Compare against math/big over a range near 2^64, plus a Carmichael number.
+	mismatches := 0
+	for n := p - 10_000; n < p+50; n++ {
+		if IsPrime(n) != new(big.Int).SetUint64(n).ProbablyPrime(20) {
+			mismatches++
+		}
+	}
+	fmt.Println("mismatches with big.Int.ProbablyPrime:", mismatches)
+	fmt.Println("561 prime?", IsPrime(561), "p prime?", IsPrime(p))
+}
+
+func main() {
+	// integer powers
+	tryIntegerPow()
+
+	// modular powers and primality
+	tryPowMod()
+}
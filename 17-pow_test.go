@@ -0,0 +1,133 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+)
+
+// Every int8 and uint8 power with a small exponent, against math/big.
+func TestPowSmallTypes(t *testing.T) {
+	for base := math.MinInt8; base <= math.MaxInt8; base++ {
+		for exp := 0; exp <= 9; exp++ {
+			want := new(big.Int).Exp(big.NewInt(int64(base)), big.NewInt(int64(exp)), nil)
+			got, err := Pow(int8(base), int8(exp))
+			if fits := want.IsInt64() && want.Int64() >= math.MinInt8 && want.Int64() <= math.MaxInt8; fits {
+				if err != nil || int64(got) != want.Int64() {
+					t.Fatalf("Pow[int8](%d, %d) = %d, %v; want %v", base, exp, got, err, want)
+				}
+			} else if !errors.Is(err, ErrOverflow) {
+				t.Fatalf("Pow[int8](%d, %d) = %d, %v; want overflow (%v)", base, exp, got, err, want)
+			}
+			if base >= 0 {
+				got, err := Pow(uint8(base), uint8(exp))
+				if fits := want.Int64() <= math.MaxUint8; fits != (err == nil) || (fits && int64(got) != want.Int64()) {
+					t.Fatalf("Pow[uint8](%d, %d) = %d, %v; want %v", base, exp, got, err, want)
+				}
+			}
+		}
+	}
+}
+
+func TestPowEdges(t *testing.T) {
+	tests := []struct {
+		base, exp int64
+		want      int64
+		wantErr   error
+	}{
+		{0, 0, 1, nil},
+		{0, 5, 0, nil},
+		{2, 62, 1 << 62, nil},
+		{2, 63, 0, ErrOverflow},
+		{-2, 63, math.MinInt64, nil},
+		{-2, 64, 0, ErrOverflow},
+		{3037000499, 2, 3037000499 * 3037000499, nil},
+		{3037000500, 2, 0, ErrOverflow},
+		{1, -3, 1, nil},
+		{-1, -7, -1, nil},
+		{-1, -8, 1, nil},
+		{2, -1, 0, ErrNegativeExponent},
+	}
+	for _, tt := range tests {
+		got, err := Pow(tt.base, tt.exp)
+		if !errors.Is(err, tt.wantErr) || got != tt.want {
+			t.Errorf("Pow(%d, %d) = %d, %v; want %d, %v", tt.base, tt.exp, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestPowLimit(t *testing.T) {
+	tests := []struct {
+		x, n, lim int64
+		want      int64
+		wantErr   error
+	}{
+		// The examples from 2-flowcontrol.go.
+		{3, 2, 10, 9, nil},
+		{3, 3, 20, 20, nil},
+		{10, 30, math.MaxInt64, math.MaxInt64, nil},
+		{-3, 3, 0, -27, nil},
+		{-3, 4, 50, 50, nil},
+		{-3, 4, 100, 81, nil},
+		// Too big to hold, but far above lim either way.
+		{-10, 20, 5, 5, nil},
+		// Far below lim, and too small to hold.
+		{-10, 19, 0, 0, ErrOverflow},
+		{2, -1, 10, 0, ErrNegativeExponent},
+		{1, -1, 10, 1, nil},
+	}
+	for _, tt := range tests {
+		got, err := PowLimit(tt.x, tt.n, tt.lim)
+		if !errors.Is(err, tt.wantErr) || got != tt.want {
+			t.Errorf("PowLimit(%d, %d, %d) = %d, %v; want %d, %v", tt.x, tt.n, tt.lim, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestSaturatingPow(t *testing.T) {
+	if got := SaturatingPow[int8](-2, 9); got != math.MinInt8 {
+		t.Errorf("SaturatingPow[int8](-2, 9) = %d", got)
+	}
+	if got := SaturatingPow[int8](-2, 10); got != math.MaxInt8 {
+		t.Errorf("SaturatingPow[int8](-2, 10) = %d", got)
+	}
+	if got := SaturatingPow[uint16](10, 5); got != math.MaxUint16 {
+		t.Errorf("SaturatingPow[uint16](10, 5) = %d", got)
+	}
+}
+
+func TestPowMod(t *testing.T) {
+	const p = 18446744073709551557 // the largest prime below 2^64
+	for _, c := range [][3]uint64{{4, 13, 497}, {123456789, p - 1, p}, {2, 0, 1}, {0, 0, 7}, {math.MaxUint64, math.MaxUint64, p}} {
+		got, err := PowMod(c[0], c[1], c[2])
+		want := new(big.Int).Exp(new(big.Int).SetUint64(c[0]), new(big.Int).SetUint64(c[1]), new(big.Int).SetUint64(c[2]))
+		if err != nil || got != want.Uint64() {
+			t.Errorf("PowMod(%d, %d, %d) = %d, %v; want %v", c[0], c[1], c[2], got, err, want)
+		}
+	}
+	if _, err := PowMod(2, 3, 0); !errors.Is(err, ErrZeroModulus) {
+		t.Errorf("PowMod(2, 3, 0) error = %v", err)
+	}
+}
+
+func TestIsPrime(t *testing.T) {
+	const p = 18446744073709551557
+	check := func(n uint64) {
+		if got, want := IsPrime(n), new(big.Int).SetUint64(n).ProbablyPrime(20); got != want {
+			t.Errorf("IsPrime(%d) = %v, want %v", n, got, want)
+		}
+	}
+	for n := range uint64(2000) {
+		check(n)
+	}
+	for n := uint64(p - 2000); n != 0; n++ {
+		check(n)
+	}
+	// Carmichael numbers fool the Fermat test but not Miller–Rabin.
+	for _, n := range []uint64{561, 1105, 1729, 3215031751} {
+		if IsPrime(n) {
+			t.Errorf("IsPrime(%d) = true", n)
+		}
+	}
+}
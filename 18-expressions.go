@@ -0,0 +1,616 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+////// expressions: add (1-first.go) and compute(fn) (3-more-types.go) pass arithmetic around as Go
+// function values. Here arithmetic arrives as text, like "hypot(5, 12) * pow(3, 2) + Pi", is
+// parsed into a tree, and evaluated against a registry of named functions and constants.
+
+// Pos is a zero-based byte offset into the source.
+type Pos int
+
+// SyntaxError and EvalError point at the offending spot in the input.
+type SyntaxError struct {
+	Pos Pos
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("col %d: syntax error: %s", e.Pos+1, e.Msg)
+}
+
+type EvalError struct {
+	Pos Pos
+	Msg string
+	Err error
+}
+
+func (e *EvalError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("col %d: %s: %v", e.Pos+1, e.Msg, e.Err)
+	}
+	return fmt.Sprintf("col %d: %s", e.Pos+1, e.Msg)
+}
+
+func (e *EvalError) Unwrap() error {
+	return e.Err
+}
+
+var ErrDivisionByZero = errors.New("division by zero")
+
+////// tokens
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokIdent
+	tokOp
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  Pos
+}
+
+// isDigit is for numbers, which are ASCII only: strconv.ParseFloat doesn't read "٣".
+func isDigit(b byte) bool {
+	return '0' <= b && b <= '9'
+}
+
+// isIdentRune reports whether c may continue an identifier. Identifiers may contain dots, so
+// "math.Pow" is one name.
+func isIdentRune(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_' || c == '.'
+}
+
+// tokenize splits the input. It decodes UTF-8, so "π" is a letter and "×" is reported as one
+// unexpected character rather than as two stray bytes.
+func tokenize(src string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(src); {
+		c, size := utf8.DecodeRuneInString(src[i:])
+		switch {
+		case unicode.IsSpace(c):
+			i += size
+		case '0' <= c && c <= '9' || c == '.':
+			start := i
+			for i < len(src) && (isDigit(src[i]) || src[i] == '.') {
+				i++
+			}
+			// Exponent, as in 1e-3.
+			if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+				j := i + 1
+				if j < len(src) && (src[j] == '+' || src[j] == '-') {
+					j++
+				}
+				if j < len(src) && isDigit(src[j]) {
+					i = j
+					for i < len(src) && isDigit(src[i]) {
+						i++
+					}
+				}
+			}
+			toks = append(toks, token{tokNumber, src[start:i], Pos(start)})
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(src) {
+				c, size := utf8.DecodeRuneInString(src[i:])
+				if !isIdentRune(c) {
+					break
+				}
+				i += size
+			}
+			toks = append(toks, token{tokIdent, src[start:i], Pos(start)})
+		case strings.ContainsRune("+-*/%^", c):
+			toks = append(toks, token{tokOp, string(c), Pos(i)})
+			i++
+		case c == '(':
+			toks = append(toks, token{tokLParen, "(", Pos(i)})
+			i++
+		case c == ')':
+			toks = append(toks, token{tokRParen, ")", Pos(i)})
+			i++
+		case c == ',':
+			toks = append(toks, token{tokComma, ",", Pos(i)})
+			i++
+		case c == utf8.RuneError && size == 1:
+			return nil, &SyntaxError{Pos(i), "invalid UTF-8"}
+		default:
+			return nil, &SyntaxError{Pos(i), fmt.Sprintf("unexpected character %q", c)}
+		}
+	}
+	return append(toks, token{tokEOF, "", Pos(len(src))}), nil
+}
+
+////// AST
+
+type Node interface {
+	Pos() Pos
+	String() string
+}
+
+type Number struct {
+	At    Pos
+	Value float64
+}
+
+type Ident struct {
+	At   Pos
+	Name string
+}
+
+type Unary struct {
+	At Pos
+	Op string
+	X  Node
+}
+
+type Binary struct {
+	At   Pos
+	Op   string
+	X, Y Node
+}
+
+type Call struct {
+	At   Pos
+	Name string
+	Args []Node
+}
+
+func (n *Number) Pos() Pos { return n.At }
+func (n *Ident) Pos() Pos  { return n.At }
+func (n *Unary) Pos() Pos  { return n.At }
+func (n *Binary) Pos() Pos { return n.At }
+func (n *Call) Pos() Pos   { return n.At }
+
+func (n *Number) String() string { return strconv.FormatFloat(n.Value, 'g', -1, 64) }
+func (n *Ident) String() string  { return n.Name }
+func (n *Unary) String() string  { return "(" + n.Op + n.X.String() + ")" }
+func (n *Binary) String() string { return "(" + n.X.String() + " " + n.Op + " " + n.Y.String() + ")" }
+func (n *Call) String() string {
+	args := make([]string, len(n.Args))
+	for i, a := range n.Args {
+		args[i] = a.String()
+	}
+	return n.Name + "(" + strings.Join(args, ", ") + ")"
+}
+
+////// parser: precedence climbing. Higher binds tighter; ^ is right-associative, so 2^3^2 = 2^9.
+
+var precedence = map[string]int{
+	"+": 1, "-": 1,
+	"*": 2, "/": 2, "%": 2,
+	"^": 4,
+}
+
+// unaryPrec sits between * and ^: -2^2 is -(2^2), but -2*3 is (-2)*3.
+const unaryPrec = 3
+
+type parser struct {
+	toks []token
+	i    int
+}
+
+// describe names a token for error messages.
+func (t token) describe() string {
+	if t.kind == tokEOF {
+		return "end of input"
+	}
+	return strconv.Quote(t.text)
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.i]
+}
+
+func (p *parser) next() token {
+	t := p.toks[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+// Parse turns an expression into a tree.
+func Parse(src string) (Node, error) {
+	toks, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	n, err := p.expr(0)
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, &SyntaxError{t.pos, "unexpected " + t.describe()}
+	}
+	return n, nil
+}
+
+func (p *parser) expr(minPrec int) (Node, error) {
+	x, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		prec, ok := precedence[t.text]
+		if t.kind != tokOp || !ok || prec < minPrec {
+			return x, nil
+		}
+		p.next()
+		nextMin := prec + 1
+		if t.text == "^" {
+			nextMin = prec
+		}
+		y, err := p.expr(nextMin)
+		if err != nil {
+			return nil, err
+		}
+		x = &Binary{t.pos, t.text, x, y}
+	}
+}
+
+func (p *parser) unary() (Node, error) {
+	if t := p.peek(); t.kind == tokOp && (t.text == "-" || t.text == "+") {
+		p.next()
+		x, err := p.expr(unaryPrec)
+		if err != nil {
+			return nil, err
+		}
+		return &Unary{t.pos, t.text, x}, nil
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (Node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		v, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, &SyntaxError{t.pos, fmt.Sprintf("bad number %q", t.text)}
+		}
+		return &Number{t.pos, v}, nil
+	case tokIdent:
+		if p.peek().kind != tokLParen {
+			return &Ident{t.pos, t.text}, nil
+		}
+		p.next()
+		call := &Call{At: t.pos, Name: t.text}
+		if p.peek().kind == tokRParen {
+			p.next()
+			return call, nil
+		}
+		for {
+			arg, err := p.expr(0)
+			if err != nil {
+				return nil, err
+			}
+			call.Args = append(call.Args, arg)
+			switch sep := p.next(); sep.kind {
+			case tokComma:
+				continue
+			case tokRParen:
+				return call, nil
+			default:
+				return nil, &SyntaxError{sep.pos, fmt.Sprintf("expected ',' or ')' in call to %s, got %s", t.text, sep.describe())}
+			}
+		}
+	case tokLParen:
+		x, err := p.expr(0)
+		if err != nil {
+			return nil, err
+		}
+		if r := p.next(); r.kind != tokRParen {
+			return nil, &SyntaxError{r.pos, fmt.Sprintf("expected ')' to close '(' at col %d", t.pos+1)}
+		}
+		return x, nil
+	case tokEOF:
+		return nil, &SyntaxError{t.pos, "unexpected end of input"}
+	}
+	return nil, &SyntaxError{t.pos, fmt.Sprintf("unexpected %q", t.text)}
+}
+
+////// evaluation: a registry of functions and constants. Functions keep the compute shape,
+// func(float64, float64) float64, or take any number of arguments.
+
+// Func is a registered function. Arity -1 accepts any number of arguments.
+type Func struct {
+	Arity int
+	Call  func(args []float64) (float64, error)
+}
+
+type Env struct {
+	Funcs  map[string]Func
+	Consts map[string]float64
+}
+
+func NewEnv() *Env {
+	return &Env{Funcs: make(map[string]Func), Consts: make(map[string]float64)}
+}
+
+// Func1 and Func2 register plain Go function values, like the hypot passed to compute.
+func (env *Env) Func1(name string, fn func(float64) float64) {
+	env.Funcs[name] = Func{1, func(a []float64) (float64, error) { return fn(a[0]), nil }}
+}
+
+func (env *Env) Func2(name string, fn func(float64, float64) float64) {
+	env.Funcs[name] = Func{2, func(a []float64) (float64, error) { return fn(a[0], a[1]), nil }}
+}
+
+func add(x, y int) int {
+	return x + y
+}
+
+// DefaultEnv has the functions of the lessons and most of package math.
+func DefaultEnv() *Env {
+	env := NewEnv()
+	env.Consts["Pi"] = math.Pi
+	env.Consts["E"] = math.E
+	env.Consts["Phi"] = math.Phi
+	env.Consts["Sqrt2"] = math.Sqrt2
+
+	hypot := func(x, y float64) float64 {
+		return math.Sqrt(x*x + y*y)
+	}
+	env.Func2("hypot", hypot)
+	env.Func2("pow", math.Pow)
+	env.Func2("math.Pow", math.Pow)
+	env.Func2("atan2", math.Atan2)
+	for name, fn := range map[string]func(float64) float64{
+		"sqrt": math.Sqrt, "abs": math.Abs, "floor": math.Floor, "ceil": math.Ceil,
+		"sin": math.Sin, "cos": math.Cos, "tan": math.Tan, "exp": math.Exp, "ln": math.Log,
+	} {
+		env.Func1(name, fn)
+	}
+
+	// add from 1-first.go works on ints, so its arguments must be whole numbers.
+	env.Funcs["add"] = Func{2, func(a []float64) (float64, error) {
+		if a[0] != math.Trunc(a[0]) || a[1] != math.Trunc(a[1]) {
+			return 0, errors.New("add needs integer arguments")
+		}
+		return float64(add(int(a[0]), int(a[1]))), nil
+	}}
+	env.Funcs["sum"] = Func{-1, func(a []float64) (float64, error) {
+		total := 0.0
+		for _, v := range a {
+			total += v
+		}
+		return total, nil
+	}}
+	env.Funcs["max"] = Func{-1, func(a []float64) (float64, error) {
+		if len(a) == 0 {
+			return 0, errors.New("max needs at least one argument")
+		}
+		return slices.Max(a), nil
+	}}
+	return env
+}
+
+// Eval evaluates a tree.
+func (env *Env) Eval(n Node) (float64, error) {
+	switch n := n.(type) {
+	case *Number:
+		return n.Value, nil
+	case *Ident:
+		v, ok := env.Consts[n.Name]
+		if !ok {
+			return 0, &EvalError{Pos: n.At, Msg: fmt.Sprintf("unknown name %q", n.Name)}
+		}
+		return v, nil
+	case *Unary:
+		x, err := env.Eval(n.X)
+		if n.Op == "-" {
+			x = -x
+		}
+		return x, err
+	case *Binary:
+		x, err := env.Eval(n.X)
+		if err != nil {
+			return 0, err
+		}
+		y, err := env.Eval(n.Y)
+		if err != nil {
+			return 0, err
+		}
+		switch n.Op {
+		case "+":
+			return x + y, nil
+		case "-":
+			return x - y, nil
+		case "*":
+			return x * y, nil
+		case "/", "%":
+			if y == 0 {
+				return 0, &EvalError{Pos: n.At, Msg: "cannot evaluate " + n.String(), Err: ErrDivisionByZero}
+			}
+			if n.Op == "%" {
+				return math.Mod(x, y), nil
+			}
+			return x / y, nil
+		case "^":
+			return math.Pow(x, y), nil
+		}
+		return 0, &EvalError{Pos: n.At, Msg: "unknown operator " + n.Op}
+	case *Call:
+		fn, ok := env.Funcs[n.Name]
+		if !ok {
+			return 0, &EvalError{Pos: n.At, Msg: fmt.Sprintf("unknown function %q", n.Name)}
+		}
+		if fn.Arity >= 0 && len(n.Args) != fn.Arity {
+			return 0, &EvalError{Pos: n.At, Msg: fmt.Sprintf("%s takes %d arguments, got %d", n.Name, fn.Arity, len(n.Args))}
+		}
+		args := make([]float64, len(n.Args))
+		for i, a := range n.Args {
+			v, err := env.Eval(a)
+			if err != nil {
+				return 0, err
+			}
+			args[i] = v
+		}
+		v, err := fn.Call(args)
+		if err != nil {
+			return 0, &EvalError{Pos: n.At, Msg: "calling " + n.Name, Err: err}
+		}
+		return v, nil
+	}
+	return 0, fmt.Errorf("unknown node %T", n)
+}
+
+// EvalString parses and evaluates in one go.
+func (env *Env) EvalString(src string) (float64, error) {
+	n, err := Parse(src)
+	if err != nil {
+		return 0, err
+	}
+	return env.Eval(n)
+}
+
+// errorPos returns where an error from Parse or Eval points, if anywhere.
+func errorPos(err error) (Pos, bool) {
+	var se *SyntaxError
+	var ee *EvalError
+	switch {
+	case errors.As(err, &se):
+		return se.Pos, true
+	case errors.As(err, &ee):
+		return ee.Pos, true
+	}
+	return 0, false
+}
+
+// showError prints the input with a caret under the error position. Pos counts bytes, the caret
+// needs one space per character before it.
+func showError(src string, err error) {
+	if pos, ok := errorPos(err); ok && int(pos) <= len(src) {
+		fmt.Println(src)
+		fmt.Println(strings.Repeat(" ", utf8.RuneCountInString(src[:pos])) + "^")
+	}
+	fmt.Println(err)
+}
+
+////// REPL: `go run 18-expressions.go calc`. "name = expr" defines a constant, and the last
+// result is available as "ans".
+
+// splitAssignment splits "name = expr" into name and expr; a line without "=" is all expr.
+// The left side must be a single identifier, so "1 = 2" is a syntax error.
+func splitAssignment(line string) (name, expr string, err error) {
+	lhs, rhs, ok := strings.Cut(line, "=")
+	if !ok {
+		return "", line, nil
+	}
+	toks, err := tokenize(lhs)
+	if err != nil {
+		return "", "", err
+	}
+	if len(toks) != 2 || toks[0].kind != tokIdent {
+		return "", "", &SyntaxError{toks[0].pos, "left side of = must be a name"}
+	}
+	return toks[0].text, strings.TrimSpace(rhs), nil
+}
+
+func calc() {
+	env := DefaultEnv()
+	in := bufio.NewScanner(os.Stdin)
+	fmt.Print("> ")
+	for in.Scan() {
+		line := strings.TrimSpace(in.Text())
+		target, expr, err := splitAssignment(line)
+		switch {
+		case err != nil:
+			showError(line, err)
+		case expr == "":
+		case expr == "quit" || expr == "exit":
+			return
+		default:
+			v, err := env.EvalString(expr)
+			if err != nil {
+				showError(expr, err)
+				break
+			}
+			env.Consts["ans"] = v
+			if target != "" {
+				env.Consts[target] = v
+			}
+			fmt.Println(v)
+		}
+		fmt.Print("> ")
+	}
+}
+
+func compute(fn func(float64, float64) float64) float64 {
+	return fn(3, 4)
+}
+
+func tryExpressions() {
+	env := DefaultEnv()
+
+	n, _ := Parse("hypot(5, 12) * pow(3, 2) + Pi")
+	fmt.Println(n)
+	v, _ := env.Eval(n)
+	fmt.Println(v)
+
+	// The same answers as compute(hypot) and compute(math.Pow).
+	fmt.Println(compute(math.Pow), compute(func(x, y float64) float64 { return math.Sqrt(x*x + y*y) }))
+	for _, src := range []string{
+		"hypot(3, 4)",
+		"math.Pow(3, 4)",
+		"add(10, 11)",
+		"2 ^ 3 ^ 2",
+		"-2 ^ 2",
+		"(1 + 2) * -3",
+		"sum(1, 2, 3, 4) / max(2, 8)",
+		"1e3 % 7",
+	} {
+		v, err := env.EvalString(src)
+		fmt.Printf("%-28s = %v %v\n", src, v, err)
+	}
+
+	// Errors say where things went wrong.
+	for _, src := range []string{
+		"hypot(5, 12",
+		"2 * (3 + )",
+		"1 / (2 - 2)",
+		"nope(1)",
+		"hypot(1)",
+		"add(1.5, 2)",
+		"3 $ 4",
+		"3 × 4",
+	} {
+		_, err := env.EvalString(src)
+		showError(src, err)
+	}
+
+	// Registering a new function is the same as passing a function value to compute.
+	env.Func2("avg", func(x, y float64) float64 { return (x + y) / 2 })
+	fmt.Println(env.EvalString("avg(3, 4)"))
+}
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "calc" {
+		calc()
+		return
+	}
+
+	// expressions
+	tryExpressions()
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestTokenizeUnicode(t *testing.T) {
+	toks, err := tokenize("2 * π + été_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, tok := range toks[:len(toks)-1] {
+		got = append(got, tok.text)
+	}
+	want := []string{"2", "*", "π", "+", "été_1"}
+	if len(got) != len(want) {
+		t.Fatalf("tokens %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("tokens %q, want %q", got, want)
+		}
+	}
+	// Positions stay byte offsets: "é" is two bytes, "π" too.
+	if toks[4].pos != 9 {
+		t.Errorf("été_1 at %d, want 9", toks[4].pos)
+	}
+
+	for _, c := range []struct {
+		src string
+		pos Pos
+		msg string
+	}{
+		{"3 × 4", 2, `unexpected character '×'`},
+		{"3 \xff 4", 2, "invalid UTF-8"},
+		{"x  + 1", -1, ""}, // a non-breaking space is still space
+	} {
+		_, err := tokenize(c.src)
+		var se *SyntaxError
+		switch {
+		case c.pos < 0 && err != nil:
+			t.Errorf("tokenize(%q): %v", c.src, err)
+		case c.pos < 0:
+		case !errors.As(err, &se) || se.Pos != c.pos || se.Msg != c.msg:
+			t.Errorf("tokenize(%q): %v, want col %d: %s", c.src, err, c.pos+1, c.msg)
+		}
+	}
+}
+
+func TestEvalUnicodeNames(t *testing.T) {
+	env := DefaultEnv()
+	env.Consts["π"] = math.Pi
+	if v, err := env.EvalString("2 * π"); err != nil || v != 2*math.Pi {
+		t.Errorf("2 * π = %v, %v", v, err)
+	}
+}
+
+func TestSplitAssignment(t *testing.T) {
+	for _, c := range []struct {
+		line, name, expr string
+		bad              bool
+	}{
+		{"1 + 2", "", "1 + 2", false},
+		{"x = 1 + 2", "x", "1 + 2", false},
+		{"  rate=ans * 2", "rate", "ans * 2", false},
+		{"π = 3.14", "π", "3.14", false},
+		{"1 = 2", "", "", true},
+		{"x y = 2", "", "", true},
+		{"= 2", "", "", true},
+		{"(x) = 2", "", "", true},
+	} {
+		name, expr, err := splitAssignment(c.line)
+		var se *SyntaxError
+		switch {
+		case c.bad && !errors.As(err, &se):
+			t.Errorf("splitAssignment(%q) = %q, %q, %v; want a syntax error", c.line, name, expr, err)
+		case !c.bad && (err != nil || name != c.name || expr != c.expr):
+			t.Errorf("splitAssignment(%q) = %q, %q, %v; want %q, %q", c.line, name, expr, err, c.name, c.expr)
+		}
+	}
+}
+
+func TestEvalString(t *testing.T) {
+	env := DefaultEnv()
+	for _, c := range []struct {
+		src  string
+		want float64
+	}{
+		{"hypot(3, 4)", 5},
+		{"2 ^ 3 ^ 2", 512},
+		{"-2 ^ 2", -4},
+		{"(1 + 2) * -3", -9},
+		{"sum(1, 2, 3, 4) / max(2, 8)", 1.25},
+		{"1e3 % 7", 6},
+	} {
+		if v, err := env.EvalString(c.src); err != nil || v != c.want {
+			t.Errorf("%s = %v, %v; want %v", c.src, v, err, c.want)
+		}
+	}
+	if _, err := env.EvalString("1 / (2 - 2)"); !errors.Is(err, ErrDivisionByZero) {
+		t.Errorf("1 / (2 - 2): %v, want ErrDivisionByZero", err)
+	}
+}
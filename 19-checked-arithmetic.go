@@ -0,0 +1,259 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"math/bits"
+	"unsafe"
+)
+
+////// checked arithmetic: add, split and variadicFunctionSum (1-first.go) wrap around silently when
+// the numbers get big: add(math.MaxInt, 1) is math.MinInt. The functions here either report the
+// overflow, clamp to the limits of the type, or sum in a wider accumulator.
+
+// Integer, signed and Limits are the same as in 17-pow.go. Every lesson is a program of its own,
+// so they are copied rather than imported; keep the copies identical.
+
+// Integer is every integer type; the same set as golang.org/x/exp/constraints.Integer.
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+var ErrOverflow = errors.New("integer overflow")
+
+func signed[T Integer]() bool {
+	var zero T
+	return zero-1 < zero
+}
+
+// Limits returns the smallest and largest values of T.
+func Limits[T Integer]() (lo, hi T) {
+	bits := unsafe.Sizeof(lo) * 8
+	if signed[T]() {
+		lo = T(1) << (bits - 1)
+		return lo, ^lo
+	}
+	return 0, ^T(0)
+}
+
+func overflow[T Integer](op string, a, b T) error {
+	return fmt.Errorf("%v %s %v: %w", a, op, b, ErrOverflow)
+}
+
+// AddChecked returns a+b, or ErrOverflow if it doesn't fit in T.
+func AddChecked[T Integer](a, b T) (T, error) {
+	r := a + b
+	if signed[T]() {
+		// Overflow flips the sign: both operands share a sign the result doesn't have.
+		if (a >= 0) == (b >= 0) && (r >= 0) != (a >= 0) {
+			return 0, overflow("+", a, b)
+		}
+	} else if r < a {
+		return 0, overflow("+", a, b)
+	}
+	return r, nil
+}
+
+// SubChecked returns a-b, or ErrOverflow if it doesn't fit in T.
+func SubChecked[T Integer](a, b T) (T, error) {
+	r := a - b
+	if signed[T]() {
+		if (a >= 0) != (b >= 0) && (r >= 0) != (a >= 0) {
+			return 0, overflow("-", a, b)
+		}
+	} else if b > a {
+		return 0, overflow("-", a, b)
+	}
+	return r, nil
+}
+
+// MulChecked returns a*b, or ErrOverflow if it doesn't fit in T.
+func MulChecked[T Integer](a, b T) (T, error) {
+	if a == 0 || b == 0 {
+		return 0, nil
+	}
+	r := a * b
+	if r/b != a {
+		return 0, overflow("*", a, b)
+	}
+	// lo * -1 wraps to lo again, which the division above can't see.
+	if lo, _ := Limits[T](); signed[T]() && ((a == lo && b == ^T(0)) || (b == lo && a == ^T(0))) {
+		return 0, overflow("*", a, b)
+	}
+	return r, nil
+}
+
+// SumChecked adds nums, stopping at the first overflow. Like variadicFunctionSum, but it fails
+// instead of wrapping.
+func SumChecked[T Integer](nums ...T) (T, error) {
+	var total T
+	for _, n := range nums {
+		var err error
+		if total, err = AddChecked(total, n); err != nil {
+			return 0, err
+		}
+	}
+	return total, nil
+}
+
+////// saturating arithmetic: on overflow the result sticks at the limit, like a gauge pinned
+// at its maximum. Useful for counters and budgets where "a lot" is good enough.
+
+func AddSat[T Integer](a, b T) T {
+	r, err := AddChecked(a, b)
+	if err == nil {
+		return r
+	}
+	lo, hi := Limits[T]()
+	if signed[T]() && a < 0 {
+		return lo
+	}
+	return hi
+}
+
+func SubSat[T Integer](a, b T) T {
+	r, err := SubChecked(a, b)
+	if err == nil {
+		return r
+	}
+	lo, hi := Limits[T]()
+	if signed[T]() && a >= 0 {
+		return hi
+	}
+	return lo
+}
+
+func MulSat[T Integer](a, b T) T {
+	r, err := MulChecked(a, b)
+	if err == nil {
+		return r
+	}
+	lo, hi := Limits[T]()
+	if (a < 0) != (b < 0) {
+		return lo
+	}
+	return hi
+}
+
+func SumSat[T Integer](nums ...T) T {
+	var total T
+	for _, n := range nums {
+		total = AddSat(total, n)
+	}
+	return total
+}
+
+////// wide accumulator: intermediate sums may overflow even when the final total fits, e.g.
+// MaxInt64 + 1 - 2. Acc keeps a 128-bit two's complement total, which no realistic number of
+// 64-bit additions can overflow, and only checks the range when the result is read.
+
+type Acc struct {
+	hi, lo uint64
+}
+
+// AddTo adds any integer to the accumulator. It is a function because methods can't take
+// type parameters.
+func AddTo[T Integer](acc *Acc, n T) {
+	v := uint64(n)
+	var ext uint64
+	if signed[T]() && n < 0 {
+		// Sign-extend into the upper word.
+		ext = math.MaxUint64
+	}
+	var carry uint64
+	acc.lo, carry = bits.Add64(acc.lo, v, 0)
+	acc.hi, _ = bits.Add64(acc.hi, ext, carry)
+}
+
+// Big returns the exact total.
+func (acc *Acc) Big() *big.Int {
+	v := new(big.Int).SetUint64(acc.hi)
+	v.Lsh(v, 64)
+	v.Or(v, new(big.Int).SetUint64(acc.lo))
+	if acc.hi>>63 == 1 {
+		v.Sub(v, new(big.Int).Lsh(big.NewInt(1), 128))
+	}
+	return v
+}
+
+// Result returns the total as T, or ErrOverflow if it doesn't fit.
+func Result[T Integer](acc *Acc) (T, error) {
+	total := acc.Big()
+	lo, hi := Limits[T]()
+	if total.Cmp(toBig(lo)) < 0 || total.Cmp(toBig(hi)) > 0 {
+		return 0, fmt.Errorf("sum %v: %w", total, ErrOverflow)
+	}
+	if total.Sign() < 0 {
+		return T(total.Int64()), nil
+	}
+	return T(total.Uint64()), nil
+}
+
+// SumWide sums nums in an Acc, so only the final total has to fit in T.
+func SumWide[T Integer](nums ...T) (T, error) {
+	var acc Acc
+	for _, n := range nums {
+		AddTo(&acc, n)
+	}
+	return Result[T](&acc)
+}
+
+func toBig[T Integer](v T) *big.Int {
+	if signed[T]() {
+		return big.NewInt(int64(v))
+	}
+	return new(big.Int).SetUint64(uint64(v))
+}
+
+// split from 1-first.go, checked: sum*4 overflows long before sum itself does.
+func SplitChecked(sum int) (x, y int, err error) {
+	prod, err := MulChecked(sum, 4)
+	if err != nil {
+		return 0, 0, err
+	}
+	x = prod / 9
+	y = sum - x
+	return x, y, nil
+}
+
+func add(x, y int) int {
+	return x + y
+}
+
+func split(sum int) (x, y int) {
+	x = sum * 4 / 9
+	y = sum - x
+	return
+}
+
+func tryCheckedArithmetic() {
+	// The originals wrap around.
+	fmt.Println(add(math.MaxInt, 1))
+	fmt.Println(split(math.MaxInt / 2))
+
+	fmt.Println(AddChecked(math.MaxInt, 1))
+	fmt.Println(AddChecked(10, 11))
+	fmt.Println(SplitChecked(17))
+	fmt.Println(SplitChecked(math.MaxInt / 2))
+	fmt.Println(MulChecked[int64](math.MinInt64, -1))
+	fmt.Println(SubChecked[uint8](3, 5))
+	fmt.Println(SumChecked[int8](100, 20, 7))
+	fmt.Println(SumChecked[int8](100, 20, 8))
+
+	fmt.Println(AddSat[int8](100, 100), AddSat[int8](-100, -100), SubSat[uint](3, 5), MulSat[int16](-300, 300))
+	fmt.Println(SumSat[uint8](200, 100, 50))
+
+	// The wide accumulator only cares about the final total.
+	fmt.Println(SumChecked[int64](math.MaxInt64, 1, -2))
+	fmt.Println(SumWide[int64](math.MaxInt64, 1, -2))
+	fmt.Println(SumWide[int64](math.MaxInt64, math.MaxInt64))
+	fmt.Println(SumWide[uint64](math.MaxUint64, 1))
+}
+
+func main() {
+	// checked and saturating arithmetic; 19-checked-arithmetic_test.go fuzzes them against math/big
+	tryCheckedArithmetic()
+}
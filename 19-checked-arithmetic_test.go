@@ -0,0 +1,139 @@
+package main
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+// bigResult is the reference answer: the exact result, and whether it fits in T.
+func bigResult[T Integer](v *big.Int) (T, bool) {
+	lo, hi := Limits[T]()
+	if v.Cmp(toBig(lo)) < 0 || v.Cmp(toBig(hi)) > 0 {
+		return 0, false
+	}
+	if v.Sign() < 0 {
+		return T(v.Int64()), true
+	}
+	return T(v.Uint64()), true
+}
+
+// checkOp compares a checked operation and its saturating twin with the exact math/big result.
+func checkOp[T Integer](t *testing.T, a, b T, symbol string,
+	checked func(a, b T) (T, error), sat func(a, b T) T, exact func(z, x, y *big.Int) *big.Int) {
+	t.Helper()
+	ex := exact(new(big.Int), toBig(a), toBig(b))
+	want, fits := bigResult[T](ex)
+	if got, err := checked(a, b); fits != (err == nil) || (fits && got != want) {
+		t.Fatalf("%T: %v %s %v = %v, %v; want %v (fits %v)", a, a, symbol, b, got, err, ex, fits)
+	}
+
+	if lo, hi := Limits[T](); !fits && ex.Sign() < 0 {
+		want = lo
+	} else if !fits {
+		want = hi
+	}
+	if got := sat(a, b); got != want {
+		t.Fatalf("%T: %v %s %v saturates to %v, want %v", a, a, symbol, b, got, want)
+	}
+}
+
+func checkAdd[T Integer](t *testing.T, a, b T) {
+	t.Helper()
+	checkOp(t, a, b, "+", AddChecked[T], AddSat[T], (*big.Int).Add)
+}
+
+func checkSub[T Integer](t *testing.T, a, b T) {
+	t.Helper()
+	checkOp(t, a, b, "-", SubChecked[T], SubSat[T], (*big.Int).Sub)
+}
+
+func checkMul[T Integer](t *testing.T, a, b T) {
+	t.Helper()
+	checkOp(t, a, b, "*", MulChecked[T], MulSat[T], (*big.Int).Mul)
+}
+
+// addSeeds adds the edges of every width, where overflows happen. The fuzz functions truncate
+// the int64 operands to each type, so these are the narrow types' edges too.
+func addSeeds(f *testing.F) {
+	edges := []int64{0, 1, -1, 2, math.MaxInt8, math.MinInt8, math.MaxUint8, math.MaxInt32, math.MinInt32,
+		math.MaxInt64, math.MinInt64, math.MaxInt64 / 2, 3037000500}
+	for _, a := range edges {
+		for _, b := range edges {
+			f.Add(a, b)
+		}
+	}
+}
+
+func FuzzAdd(f *testing.F) {
+	addSeeds(f)
+	f.Fuzz(func(t *testing.T, a, b int64) {
+		checkAdd(t, int8(a), int8(b))
+		checkAdd(t, uint8(a), uint8(b))
+		checkAdd(t, int32(a), int32(b))
+		checkAdd(t, a, b)
+		checkAdd(t, uint64(a), uint64(b))
+		checkAdd(t, int(a), int(b))
+	})
+}
+
+func FuzzSub(f *testing.F) {
+	addSeeds(f)
+	f.Fuzz(func(t *testing.T, a, b int64) {
+		checkSub(t, int8(a), int8(b))
+		checkSub(t, uint8(a), uint8(b))
+		checkSub(t, int32(a), int32(b))
+		checkSub(t, a, b)
+		checkSub(t, uint64(a), uint64(b))
+		checkSub(t, int(a), int(b))
+	})
+}
+
+func FuzzMul(f *testing.F) {
+	addSeeds(f)
+	f.Fuzz(func(t *testing.T, a, b int64) {
+		checkMul(t, int8(a), int8(b))
+		checkMul(t, uint8(a), uint8(b))
+		checkMul(t, int32(a), int32(b))
+		checkMul(t, a, b)
+		checkMul(t, uint64(a), uint64(b))
+		checkMul(t, int(a), int(b))
+	})
+}
+
+// FuzzSumWide takes the numbers as 8-byte little-endian chunks of data.
+func FuzzSumWide(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f, 1, 0, 0, 0, 0, 0, 0, 0})
+	f.Add([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f, 1, 0, 0, 0, 0, 0, 0, 0, 0xfe, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var nums []int64
+		want := new(big.Int)
+		for ; len(data) >= 8; data = data[8:] {
+			var n int64
+			for i := range 8 {
+				n |= int64(data[i]) << (8 * i)
+			}
+			nums = append(nums, n)
+			want.Add(want, big.NewInt(n))
+		}
+		got, err := SumWide(nums...)
+		w, fits := bigResult[int64](want)
+		if fits != (err == nil) || (fits && got != w) {
+			t.Fatalf("SumWide(%v) = %v, %v; want %v (fits %v)", nums, got, err, want, fits)
+		}
+		// SumChecked may fail on an intermediate sum, but when it succeeds it must agree.
+		if got, err := SumChecked(nums...); err == nil && (!fits || got != w) {
+			t.Fatalf("SumChecked(%v) = %v; want %v (fits %v)", nums, got, want, fits)
+		}
+	})
+}
+
+func TestSplitChecked(t *testing.T) {
+	if x, y, err := SplitChecked(17); x != 7 || y != 10 || err != nil {
+		t.Errorf("SplitChecked(17) = %d, %d, %v", x, y, err)
+	}
+	if _, _, err := SplitChecked(math.MaxInt / 2); err == nil {
+		t.Error("SplitChecked(MaxInt/2) didn't overflow")
+	}
+}
@@ -0,0 +1,414 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+)
+
+////// statistics: variadicFunctionSum (1-first.go) prints its numbers and their total. These functions
+// take the same variadic input and describe it: mean, median, mode, spread, percentiles and a
+// histogram. They are generic, so ints, floats and time.Duration latencies all work.
+//
+// NaN stands for a missing value: every function here, and Accumulator, skips NaNs. Data with
+// nothing but NaNs is ErrEmpty.
+
+// Number is every integer and float type.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+var (
+	ErrEmpty            = errors.New("stats: no data")
+	ErrInsufficientData = errors.New("stats: not enough data")
+	ErrInfinite         = errors.New("stats: infinite value")
+)
+
+// isNaN reports whether v is a NaN; only floats can be.
+func isNaN[T Number](v T) bool {
+	return math.IsNaN(float64(v))
+}
+
+func Sum[T Number](nums ...T) float64 {
+	total := 0.0
+	for _, n := range nums {
+		if !isNaN(n) {
+			total += float64(n)
+		}
+	}
+	return total
+}
+
+func Mean[T Number](nums ...T) (float64, error) {
+	// Welford's running mean stays accurate where a big sum would lose digits.
+	var acc Accumulator
+	for _, n := range nums {
+		acc.Add(float64(n))
+	}
+	if acc.Count() == 0 {
+		return 0, ErrEmpty
+	}
+	return acc.Mean(), nil
+}
+
+// Median is the middle value, or the mean of the two middle values.
+func Median[T Number](nums ...T) (float64, error) {
+	return Percentile(50, Linear, nums...)
+}
+
+// Mode returns the most frequent values, smallest first. Ties return all of them.
+func Mode[T Number](nums ...T) ([]T, error) {
+	counts := make(map[T]int)
+	best := 0
+	for _, n := range nums {
+		if isNaN(n) {
+			continue
+		}
+		counts[n]++
+		best = max(best, counts[n])
+	}
+	if len(counts) == 0 {
+		return nil, ErrEmpty
+	}
+	var modes []T
+	for n, c := range counts {
+		if c == best {
+			modes = append(modes, n)
+		}
+	}
+	slices.Sort(modes)
+	return modes, nil
+}
+
+// Variance is the sample variance (divided by n-1); StdDev is its square root. A single value
+// has no spread to estimate, which is ErrInsufficientData rather than ErrEmpty.
+func Variance[T Number](nums ...T) (float64, error) {
+	var acc Accumulator
+	for _, n := range nums {
+		acc.Add(float64(n))
+	}
+	switch acc.Count() {
+	case 0:
+		return 0, ErrEmpty
+	case 1:
+		return 0, fmt.Errorf("variance needs two values: %w", ErrInsufficientData)
+	}
+	return acc.Variance(), nil
+}
+
+func StdDev[T Number](nums ...T) (float64, error) {
+	v, err := Variance(nums...)
+	return math.Sqrt(v), err
+}
+
+////// percentiles: the p-th percentile sits at rank p/100 * (n-1) in the sorted data. When that
+// rank falls between two values, the method decides what to report.
+
+type Method int
+
+const (
+	// Linear interpolates between the two neighbours (Excel's PERCENTILE, NumPy's default).
+	Linear Method = iota
+	// Lower and Higher take the neighbour below or above.
+	Lower
+	Higher
+	// Nearest takes the closer neighbour, rounding halves to even.
+	Nearest
+	// Midpoint averages the two neighbours.
+	Midpoint
+)
+
+var methodName = map[Method]string{
+	Linear:   "linear",
+	Lower:    "lower",
+	Higher:   "higher",
+	Nearest:  "nearest",
+	Midpoint: "midpoint",
+}
+
+func (m Method) String() string {
+	return methodName[m]
+}
+
+func Percentile[T Number](p float64, method Method, nums ...T) (float64, error) {
+	ps, err := Percentiles([]float64{p}, method, nums...)
+	if err != nil {
+		return 0, err
+	}
+	return ps[0], nil
+}
+
+// Percentiles computes several percentiles with one sort.
+func Percentiles[T Number](ps []float64, method Method, nums ...T) ([]float64, error) {
+	sorted := slices.DeleteFunc(slices.Clone(nums), isNaN)
+	slices.Sort(sorted)
+	out := make([]float64, len(ps))
+	for i, p := range ps {
+		if len(sorted) == 0 {
+			return nil, ErrEmpty
+		}
+		if p < 0 || p > 100 || math.IsNaN(p) {
+			return nil, fmt.Errorf("stats: percentile %v is outside [0, 100]", p)
+		}
+		out[i] = percentileSorted(sorted, p, method)
+	}
+	return out, nil
+}
+
+func percentileSorted[T Number](sorted []T, p float64, method Method) float64 {
+	rank := p / 100 * float64(len(sorted)-1)
+	lo, hi := int(math.Floor(rank)), int(math.Ceil(rank))
+	a, b := float64(sorted[lo]), float64(sorted[hi])
+	switch method {
+	case Lower:
+		return a
+	case Higher:
+		return b
+	case Nearest:
+		return float64(sorted[int(math.RoundToEven(rank))])
+	case Midpoint:
+		return (a + b) / 2
+	default:
+		return a + (rank-float64(lo))*(b-a)
+	}
+}
+
+////// histograms
+
+type Bin struct {
+	Lo, Hi float64
+	Count  int
+}
+
+// Histogram splits [min, max] into n equal bins. Every bin includes its lower edge; the last
+// one includes the maximum too. NaNs belong in no bin and are skipped; an infinite value can't
+// be binned either, and is ErrInfinite.
+func Histogram[T Number](n int, nums ...T) ([]Bin, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("stats: need at least one bin, got %d", n)
+	}
+	vals := make([]float64, 0, len(nums))
+	for _, v := range nums {
+		f := float64(v)
+		switch {
+		case isNaN(v):
+			continue
+		case math.IsInf(f, 0):
+			return nil, fmt.Errorf("histogram of %v: %w", f, ErrInfinite)
+		}
+		vals = append(vals, f)
+	}
+	if len(vals) == 0 {
+		return nil, ErrEmpty
+	}
+	lo, hi := slices.Min(vals), slices.Max(vals)
+	width := (hi - lo) / float64(n)
+	if width == 0 {
+		// Every value is the same; one bin holds them all.
+		width, n = 1, 1
+	}
+	bins := make([]Bin, n)
+	for i := range bins {
+		bins[i] = Bin{Lo: lo + float64(i)*width, Hi: lo + float64(i+1)*width}
+	}
+	for _, v := range vals {
+		i := int((v - lo) / width)
+		bins[min(i, n-1)].Count++
+	}
+	return bins, nil
+}
+
+// PrintHistogram draws bins as rows of '#', scaled to width characters.
+func PrintHistogram(bins []Bin, width int, label func(float64) string) {
+	most := 0
+	for _, b := range bins {
+		most = max(most, b.Count)
+	}
+	for _, b := range bins {
+		bar := 0
+		if most > 0 {
+			bar = b.Count * width / most
+		}
+		fmt.Printf("%10s - %-10s %5d %s\n", label(b.Lo), label(b.Hi), b.Count, strings.Repeat("#", bar))
+	}
+}
+
+////// streaming: an Accumulator sees each value once and keeps only a few numbers, using
+// Welford's online algorithm for mean and variance. Two accumulators merge exactly (Chan et
+// al.), so goroutines can each summarise a share of the data, like sum in 6-concurrency.go,
+// and the partial results are combined at the end.
+
+// Accumulator is not safe for concurrent use; give each goroutine its own and Merge them.
+type Accumulator struct {
+	n        int
+	mean, m2 float64
+	min, max float64
+}
+
+// Add adds x to the summary; a NaN is skipped.
+func (a *Accumulator) Add(x float64) {
+	if math.IsNaN(x) {
+		return
+	}
+	a.n++
+	if a.n == 1 {
+		a.min, a.max = x, x
+	} else {
+		a.min, a.max = min(a.min, x), max(a.max, x)
+	}
+	delta := x - a.mean
+	a.mean += delta / float64(a.n)
+	a.m2 += delta * (x - a.mean)
+}
+
+// Merge folds another accumulator's values into a.
+func (a *Accumulator) Merge(b Accumulator) {
+	switch {
+	case b.n == 0:
+		return
+	case a.n == 0:
+		*a = b
+		return
+	}
+	n := a.n + b.n
+	delta := b.mean - a.mean
+	a.m2 += b.m2 + delta*delta*float64(a.n)*float64(b.n)/float64(n)
+	a.mean += delta * float64(b.n) / float64(n)
+	a.min, a.max = min(a.min, b.min), max(a.max, b.max)
+	a.n = n
+}
+
+func (a *Accumulator) Count() int {
+	return a.n
+}
+
+func (a *Accumulator) Mean() float64 {
+	return a.mean
+}
+
+func (a *Accumulator) Min() float64 {
+	return a.min
+}
+
+func (a *Accumulator) Max() float64 {
+	return a.max
+}
+
+// Variance is the sample variance; 0 until there are two values.
+func (a *Accumulator) Variance() float64 {
+	if a.n < 2 {
+		return 0
+	}
+	return a.m2 / float64(a.n-1)
+}
+
+func (a *Accumulator) StdDev() float64 {
+	return math.Sqrt(a.Variance())
+}
+
+func (a *Accumulator) String() string {
+	return fmt.Sprintf("n=%d mean=%.4g sd=%.4g min=%.4g max=%.4g", a.n, a.mean, a.StdDev(), a.min, a.max)
+}
+
+// summarise is sum from 6-concurrency.go, sending an Accumulator instead of a total.
+func summarise[T Number](s []T, c chan Accumulator) {
+	var acc Accumulator
+	for _, v := range s {
+		acc.Add(float64(v))
+	}
+	c <- acc
+}
+
+func variadicFunctionSum(nums ...int) {
+	fmt.Print(nums, " ")
+	total := 0
+	for _, num := range nums {
+		total += num
+	}
+	fmt.Println(total)
+}
+
+func tryStats() {
+	nums := []int{1, 2, 3, 4, 4, 7, 9, 12}
+	variadicFunctionSum(nums...)
+
+	mean, _ := Mean(nums...)
+	median, _ := Median(nums...)
+	mode, _ := Mode(nums...)
+	sd, _ := StdDev(nums...)
+	fmt.Printf("mean=%v median=%v mode=%v sd=%.4f\n", mean, median, mode, sd)
+
+	for _, m := range []Method{Linear, Lower, Higher, Nearest, Midpoint} {
+		p, _ := Percentile(40, m, nums...)
+		fmt.Printf("p40 %-8v = %v\n", m, p)
+	}
+
+	_, err := Mean[float64]()
+	fmt.Println(err)
+	_, err = Percentile(101, Linear, 1, 2)
+	fmt.Println(err)
+
+	// Welford stays accurate where the textbook sum-of-squares formula cancels out.
+	big := []float64{1e9 + 4, 1e9 + 7, 1e9 + 13, 1e9 + 16}
+	v, _ := Variance(big...)
+	s, sq := 0.0, 0.0
+	for _, x := range big {
+		s += x
+		sq += x * x
+	}
+	naive := (sq - s*s/4) / 3
+	fmt.Println("variance:", v, "naive:", naive)
+}
+
+func tryLatencies() {
+	// A day of request latencies: mostly quick, with a slow tail.
+	rng := rand.New(rand.NewPCG(5, 6))
+	latencies := make([]time.Duration, 100_000)
+	for i := range latencies {
+		ms := rng.ExpFloat64()*20 + 5
+		if rng.IntN(100) == 0 {
+			ms += 300
+		}
+		latencies[i] = time.Duration(ms * float64(time.Millisecond))
+	}
+
+	ps, _ := Percentiles([]float64{50, 90, 99, 99.9}, Linear, latencies...)
+	for i, p := range []string{"p50", "p90", "p99", "p99.9"} {
+		fmt.Printf("%-6s %v\n", p, time.Duration(ps[i]).Round(time.Microsecond))
+	}
+
+	// Split the work across goroutines and merge the partial results.
+	c := make(chan Accumulator)
+	const parts = 4
+	size := len(latencies) / parts
+	for i := 0; i < parts; i++ {
+		go summarise(latencies[i*size:(i+1)*size], c)
+	}
+	var total Accumulator
+	for i := 0; i < parts; i++ {
+		total.Merge(<-c)
+	}
+	mean, _ := Mean(latencies...)
+	sd, _ := StdDev(latencies...)
+	fmt.Println("merged:", time.Duration(total.Mean()).Round(time.Microsecond), time.Duration(total.StdDev()).Round(time.Microsecond), total.Count())
+	fmt.Println("direct:", time.Duration(mean).Round(time.Microsecond), time.Duration(sd).Round(time.Microsecond))
+
+	bins, _ := Histogram(10, latencies...)
+	PrintHistogram(bins, 40, func(v float64) string {
+		return time.Duration(v).Round(time.Millisecond).String()
+	})
+}
+
+func main() {
+	// descriptive statistics
+	tryStats()
+
+	// latencies
+	tryLatencies()
+}
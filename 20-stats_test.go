@@ -0,0 +1,200 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestVarianceErrors(t *testing.T) {
+	if _, err := Variance[float64](); !errors.Is(err, ErrEmpty) {
+		t.Errorf("no values: err = %v, want %v", err, ErrEmpty)
+	}
+	_, err := Variance(3.5)
+	if !errors.Is(err, ErrInsufficientData) || errors.Is(err, ErrEmpty) {
+		t.Errorf("one value: err = %v, want %v", err, ErrInsufficientData)
+	}
+	if _, err := StdDev(7); !errors.Is(err, ErrInsufficientData) {
+		t.Errorf("StdDev of one value: err = %v", err)
+	}
+	if v, err := Variance(2, 4, 4, 4, 5, 5, 7, 9); err != nil || math.Abs(v-32.0/7) > 1e-12 {
+		t.Errorf("Variance = %v, %v; want %v", v, err, 32.0/7)
+	}
+}
+
+func counts(bins []Bin) []int {
+	var out []int
+	for _, b := range bins {
+		out = append(out, b.Count)
+	}
+	return out
+}
+
+func TestHistogram(t *testing.T) {
+	nan, inf := math.NaN(), math.Inf(1)
+	tests := []struct {
+		name    string
+		n       int
+		nums    []float64
+		want    []int
+		wantErr error
+	}{
+		{"maximum in the last bin", 3, []float64{0, 1, 2, 3, 4, 5, 6}, []int{2, 2, 3}, nil},
+		{"all the same", 4, []float64{2, 2, 2}, []int{3}, nil},
+		{"NaNs skipped", 2, []float64{nan, 0, 1, nan, 2, 3}, []int{2, 2}, nil},
+		{"only NaN", 2, []float64{nan, nan}, nil, ErrEmpty},
+		{"empty", 2, nil, nil, ErrEmpty},
+		{"infinite", 2, []float64{1, inf}, nil, ErrInfinite},
+		{"negative infinity", 2, []float64{-inf, 1}, nil, ErrInfinite},
+	}
+	for _, tt := range tests {
+		bins, err := Histogram(tt.n, tt.nums...)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if got := counts(bins); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: counts = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPercentileMethods(t *testing.T) {
+	nums := []int{12, 1, 4, 2, 9, 3, 7, 4} // sorted: 1 2 3 4 4 7 9 12
+	fives := []float64{10, 20, 30, 40, 50}
+	tests := []struct {
+		p      float64
+		method Method
+		nums   []float64
+		want   float64
+	}{
+		// p40 of eight values is at rank 2.8, between 3 and 4.
+		{40, Linear, nil, 3.8},
+		{40, Lower, nil, 3},
+		{40, Higher, nil, 4},
+		{40, Nearest, nil, 4},
+		{40, Midpoint, nil, 3.5},
+		// Ranks 1.5 and 2.5: Nearest rounds halves to even, both to 30.
+		{37.5, Nearest, fives, 30},
+		{62.5, Nearest, fives, 30},
+		{37.5, Linear, fives, 25},
+		{62.5, Midpoint, fives, 35},
+		// On an exact rank every method agrees.
+		{25, Linear, fives, 20},
+		{25, Lower, fives, 20},
+		{25, Higher, fives, 20},
+		{25, Nearest, fives, 20},
+		{25, Midpoint, fives, 20},
+		{0, Linear, fives, 10},
+		{100, Higher, fives, 50},
+		{100, Linear, fives, 50},
+	}
+	for _, tt := range tests {
+		var got float64
+		var err error
+		if tt.nums == nil {
+			got, err = Percentile(tt.p, tt.method, nums...)
+		} else {
+			got, err = Percentile(tt.p, tt.method, tt.nums...)
+		}
+		if err != nil || math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("p%v %v = %v, %v; want %v", tt.p, tt.method, got, err, tt.want)
+		}
+	}
+
+	if m, _ := Median(nums...); m != 4 {
+		t.Errorf("Median = %v, want 4", m)
+	}
+	if m, _ := Median(3, 1, 2); m != 2 {
+		t.Errorf("Median of three = %v, want 2", m)
+	}
+	ps, err := Percentiles([]float64{0, 50, 100}, Linear, fives...)
+	if err != nil || !slices.Equal(ps, []float64{10, 30, 50}) {
+		t.Errorf("Percentiles = %v, %v", ps, err)
+	}
+	for _, p := range []float64{-1, 101, math.NaN()} {
+		if _, err := Percentile(p, Linear, 1, 2); err == nil {
+			t.Errorf("Percentile(%v) succeeded", p)
+		}
+	}
+}
+
+// NaN is a missing value everywhere, as in Histogram.
+func TestNaNSkipped(t *testing.T) {
+	nan := math.NaN()
+	with := []float64{nan, 1, 2, nan, 3, 6}
+	without := []float64{1, 2, 3, 6}
+
+	for _, m := range []Method{Linear, Lower, Higher, Nearest, Midpoint} {
+		got, err1 := Percentile(50, m, with...)
+		want, err2 := Percentile(50, m, without...)
+		if got != want || err1 != nil || err2 != nil {
+			t.Errorf("p50 %v with NaNs = %v, without = %v", m, got, want)
+		}
+	}
+	if got, _ := Mean(with...); got != 3 {
+		t.Errorf("Mean = %v, want 3", got)
+	}
+	if got, _ := Variance(with...); got != 14.0/3 {
+		t.Errorf("Variance = %v, want %v", got, 14.0/3)
+	}
+	if got := Sum(with...); got != 12 {
+		t.Errorf("Sum = %v, want 12", got)
+	}
+	if got, _ := Mode(nan, 2, nan, 2, 3); !slices.Equal(got, []float64{2}) {
+		t.Errorf("Mode = %v, want [2]", got)
+	}
+	if _, err := Variance(nan, 5); !errors.Is(err, ErrInsufficientData) {
+		t.Errorf("Variance of one value and a NaN: %v", err)
+	}
+
+	only := []float64{nan, nan}
+	if _, err := Mean(only...); !errors.Is(err, ErrEmpty) {
+		t.Errorf("Mean of NaNs: %v", err)
+	}
+	if _, err := Median(only...); !errors.Is(err, ErrEmpty) {
+		t.Errorf("Median of NaNs: %v", err)
+	}
+	if _, err := Mode(only...); !errors.Is(err, ErrEmpty) {
+		t.Errorf("Mode of NaNs: %v", err)
+	}
+}
+
+// Merging partial accumulators gives what one accumulator over all the data gives.
+func TestAccumulatorMerge(t *testing.T) {
+	rng := rand.New(rand.NewPCG(7, 8))
+	data := make([]float64, 1000)
+	for i := range data {
+		data[i] = rng.NormFloat64()*50 + 1e6
+	}
+	var whole Accumulator
+	for _, v := range data {
+		whole.Add(v)
+	}
+
+	// Uneven parts, including empty ones at either end and in the middle.
+	for _, cuts := range [][]int{{0, 1000}, {0, 0, 1000}, {0, 1, 1000}, {0, 300, 300, 301, 1000, 1000}} {
+		var merged Accumulator
+		for i := 0; i+1 < len(cuts); i++ {
+			var part Accumulator
+			for _, v := range data[cuts[i]:cuts[i+1]] {
+				part.Add(v)
+			}
+			merged.Merge(part)
+		}
+		if merged.Count() != whole.Count() || merged.Min() != whole.Min() || merged.Max() != whole.Max() {
+			t.Errorf("cuts %v: %v, want %v", cuts, &merged, &whole)
+		}
+		if math.Abs(merged.Mean()/whole.Mean()-1) > 1e-12 || math.Abs(merged.Variance()/whole.Variance()-1) > 1e-9 {
+			t.Errorf("cuts %v: mean %v variance %v, want %v %v", cuts, merged.Mean(), merged.Variance(), whole.Mean(), whole.Variance())
+		}
+	}
+
+	var empty Accumulator
+	empty.Merge(Accumulator{})
+	if empty.Count() != 0 || empty.Variance() != 0 {
+		t.Errorf("merging two empty accumulators: %v", &empty)
+	}
+}
@@ -0,0 +1,373 @@
+package main
+
+import (
+	"fmt"
+	"iter"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+////// functional helpers: compute (3-more-types.go) takes a function value and adder() returns a
+// closure with state. These helpers build on the same two ideas: functions that take or return
+// functions, over iter.Seq sequences and plain slices.
+
+// Map, Filter and Reduce work lazily on sequences; nothing runs until the sequence is ranged over.
+func Map[T, U any](seq iter.Seq[T], f func(T) U) iter.Seq[U] {
+	return func(yield func(U) bool) {
+		for v := range seq {
+			if !yield(f(v)) {
+				return
+			}
+		}
+	}
+}
+
+func Filter[T any](seq iter.Seq[T], keep func(T) bool) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for v := range seq {
+			if keep(v) && !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+func Reduce[T, A any](seq iter.Seq[T], initial A, f func(A, T) A) A {
+	acc := initial
+	for v := range seq {
+		acc = f(acc, v)
+	}
+	return acc
+}
+
+// MapSlice, FilterSlice and ReduceSlice are the eager versions for slices.
+func MapSlice[T, U any](s []T, f func(T) U) []U {
+	out := make([]U, len(s))
+	for i, v := range s {
+		out[i] = f(v)
+	}
+	return out
+}
+
+func FilterSlice[T any](s []T, keep func(T) bool) []T {
+	var out []T
+	for _, v := range s {
+		if keep(v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func ReduceSlice[T, A any](s []T, initial A, f func(A, T) A) A {
+	return Reduce(slices.Values(s), initial, f)
+}
+
+////// composing functions
+
+// Compose returns g(f(x)): f runs first.
+func Compose[A, B, C any](f func(A) B, g func(B) C) func(A) C {
+	return func(a A) C {
+		return g(f(a))
+	}
+}
+
+// Partial fixes the first argument of a two-argument function, e.g. Partial(math.Pow, 2) is 2^y.
+func Partial[A, B, R any](f func(A, B) R, a A) func(B) R {
+	return func(b B) R {
+		return f(a, b)
+	}
+}
+
+// Curry turns f(a, b) into f(a)(b).
+func Curry[A, B, R any](f func(A, B) R) func(A) func(B) R {
+	return func(a A) func(B) R {
+		return Partial(f, a)
+	}
+}
+
+func Curry3[A, B, C, R any](f func(A, B, C) R) func(A) func(B) func(C) R {
+	return func(a A) func(B) func(C) R {
+		return func(b B) func(C) R {
+			return func(c C) R {
+				return f(a, b, c)
+			}
+		}
+	}
+}
+
+////// Memoize: remember results by argument. Safe for concurrent use. With a ttl > 0 a result is
+// recomputed once it is older than ttl. Concurrent calls with the same argument wait for a
+// single call of fn and share its result. The lock is not held while fn runs, so a memoized
+// function may call itself with other arguments, as the recursive Fibonacci below does; with
+// the same argument it would wait for itself forever.
+
+type memoEntry[V any] struct {
+	value   V
+	expires time.Time
+}
+
+// memoCall is a call of fn in progress. value and ok are set before done is closed.
+type memoCall[V any] struct {
+	done  chan struct{}
+	value V
+	ok    bool // false if fn panicked
+}
+
+type memo[K comparable, V any] struct {
+	fn  func(K) V
+	ttl time.Duration
+
+	mu        sync.Mutex
+	cache     map[K]memoEntry[V]
+	calls     map[K]*memoCall[V]
+	lastSweep time.Time
+}
+
+func Memoize[K comparable, V any](fn func(K) V, ttl time.Duration) func(K) V {
+	return newMemo(fn, ttl).get
+}
+
+func newMemo[K comparable, V any](fn func(K) V, ttl time.Duration) *memo[K, V] {
+	return &memo[K, V]{fn: fn, ttl: ttl, cache: make(map[K]memoEntry[V]), calls: make(map[K]*memoCall[V])}
+}
+
+func (m *memo[K, V]) get(k K) V {
+	for {
+		m.mu.Lock()
+		if e, ok := m.cache[k]; ok {
+			if m.ttl <= 0 || time.Now().Before(e.expires) {
+				m.mu.Unlock()
+				return e.value
+			}
+			delete(m.cache, k)
+		}
+		if c, ok := m.calls[k]; ok {
+			m.mu.Unlock()
+			<-c.done
+			if c.ok {
+				return c.value
+			}
+			// fn panicked in the goroutine that called it; try again here.
+			continue
+		}
+		c := &memoCall[V]{done: make(chan struct{})}
+		m.calls[k] = c
+		m.mu.Unlock()
+		m.call(k, c)
+		return c.value
+	}
+}
+
+// call runs fn and stores the result. Even if fn panics, the call is finished so that
+// waiting callers wake up.
+func (m *memo[K, V]) call(k K, c *memoCall[V]) {
+	defer func() {
+		m.mu.Lock()
+		delete(m.calls, k)
+		if c.ok {
+			now := time.Now()
+			m.cache[k] = memoEntry[V]{c.value, now.Add(m.ttl)}
+			m.sweep(now)
+		}
+		m.mu.Unlock()
+		close(c.done)
+	}()
+	c.value = m.fn(k)
+	c.ok = true
+}
+
+// sweep drops expired entries, even for arguments that are never asked for again. It scans
+// the cache at most once per ttl, so on average it costs O(1) per call.
+func (m *memo[K, V]) sweep(now time.Time) {
+	if m.ttl <= 0 || now.Sub(m.lastSweep) < m.ttl {
+		return
+	}
+	m.lastSweep = now
+	for k, e := range m.cache {
+		if !now.Before(e.expires) {
+			delete(m.cache, k)
+		}
+	}
+}
+
+// Once returns a function that calls fn the first time and returns that result forever after.
+func Once[T any](fn func() T) func() T {
+	var once sync.Once
+	var v T
+	return func() T {
+		once.Do(func() { v = fn() })
+		return v
+	}
+}
+
+////// timing: Debounce waits for calls to stop before acting once, like saving after the user
+// stops typing. Throttle acts at most once per interval and drops the calls in between.
+
+// Debounce returns a function that runs fn wait after the last call to it.
+func Debounce(fn func(), wait time.Duration) func() {
+	var mu sync.Mutex
+	var timer *time.Timer
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if timer != nil {
+			timer.Stop()
+		}
+		timer = time.AfterFunc(wait, fn)
+	}
+}
+
+// Throttle returns a function that runs fn at most once per interval and reports whether this
+// call ran it.
+func Throttle(fn func(), interval time.Duration) func() bool {
+	var mu sync.Mutex
+	var last time.Time
+	return func() bool {
+		mu.Lock()
+		now := time.Now()
+		if !last.IsZero() && now.Sub(last) < interval {
+			mu.Unlock()
+			return false
+		}
+		last = now
+		mu.Unlock()
+		fn()
+		return true
+	}
+}
+
+func compute(fn func(float64, float64) float64) float64 {
+	return fn(3, 4)
+}
+
+func adder() func(int) int {
+	sum := 0
+	return func(x int) int {
+		sum += x
+		return sum
+	}
+}
+
+func tryCombinators() {
+	nums := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+
+	// Square the even numbers and add them up, lazily.
+	evens := Filter(slices.Values(nums), func(n int) bool { return n%2 == 0 })
+	squares := Map(evens, func(n int) int { return n * n })
+	fmt.Println(slices.Collect(squares), Reduce(squares, 0, func(a, n int) int { return a + n }))
+
+	// adder() is a Reduce with hidden state; running totals fall out of MapSlice.
+	pos := adder()
+	fmt.Println(MapSlice(nums, pos))
+
+	words := strings.Fields("the quick brown fox jumps over the lazy dog")
+	long := FilterSlice(words, func(w string) bool { return len(w) > 3 })
+	fmt.Println(long, ReduceSlice(words, 0, func(n int, w string) int { return n + len(w) }))
+
+	// compute takes a two-argument function; Partial and Curry make one-argument ones from it.
+	hypot := func(x, y float64) float64 { return math.Sqrt(x*x + y*y) }
+	fmt.Println(compute(hypot), Partial(hypot, 3)(4), Curry(math.Pow)(3)(4))
+
+	powersOfTwo := Partial(math.Pow, 2)
+	fmt.Println(slices.Collect(Map(slices.Values([]float64{0, 1, 10}), powersOfTwo)))
+
+	shout := Compose(strings.ToUpper, func(s string) string { return s + "!" })
+	fmt.Println(shout("hello"))
+
+	volume := Curry3(func(l, w, h float64) float64 { return l * w * h })
+	fmt.Println(volume(2)(3)(4))
+
+	calls := 0
+	config := Once(func() string {
+		calls++
+		return "loaded"
+	})
+	fmt.Println(config(), config(), calls)
+}
+
+func tryMemoize() {
+	// A memoized function is cached by argument and expires after the ttl.
+	var calls atomic.Int32
+	slowSquare := Memoize(func(n int) int {
+		calls.Add(1)
+		return n * n
+	}, 50*time.Millisecond)
+	slowSquare(4)
+	slowSquare(4)
+	fmt.Println("calls:", calls.Load())
+	time.Sleep(60 * time.Millisecond)
+	slowSquare(4)
+	fmt.Println("calls after ttl:", calls.Load())
+
+	// Concurrent callers share one cache.
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slowSquare(i % 10)
+		}()
+	}
+	wg.Wait()
+	// 11: the nine new keys are computed once each, however many goroutines ask at once.
+	fmt.Println("calls after 100 concurrent:", calls.Load())
+
+	// BenchmarkFib in 21-functional_test.go times the two against each other.
+	fmt.Println(fib(30), memoFib()(30))
+}
+
+func fib(n int) int {
+	if n < 2 {
+		return n
+	}
+	return fib(n-1) + fib(n-2)
+}
+
+func memoFib() func(int) int {
+	var f func(int) int
+	f = Memoize(func(n int) int {
+		if n < 2 {
+			return n
+		}
+		return f(n-1) + f(n-2)
+	}, 0)
+	return f
+}
+
+func tryDebounceThrottle() {
+	// Ten quick calls, one debounced save at the end.
+	saves := make(chan time.Time, 10)
+	save := Debounce(func() { saves <- time.Now() }, 30*time.Millisecond)
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		save()
+		time.Sleep(5 * time.Millisecond)
+	}
+	t := <-saves
+	fmt.Println("debounced saves:", 1+len(saves), "after", t.Sub(start).Round(10*time.Millisecond))
+
+	// 20 calls over ~100ms, throttled to one per 30ms.
+	ran := 0
+	tick := Throttle(func() { ran++ }, 30*time.Millisecond)
+	for i := 0; i < 20; i++ {
+		tick()
+		time.Sleep(5 * time.Millisecond)
+	}
+	fmt.Println("throttled runs:", ran)
+}
+
+func main() {
+	// combinators
+	tryCombinators()
+
+	// memoization
+	tryMemoize()
+
+	// debounce and throttle
+	tryDebounceThrottle()
+}
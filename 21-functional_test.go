@@ -0,0 +1,127 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoizeExpiry(t *testing.T) {
+	var calls atomic.Int32
+	m := newMemo(func(n int) int {
+		calls.Add(1)
+		return n * n
+	}, 20*time.Millisecond)
+
+	for n := range 5 {
+		m.get(n)
+		m.get(n)
+	}
+	if calls.Load() != 5 {
+		t.Fatalf("calls = %d, want 5", calls.Load())
+	}
+	time.Sleep(30 * time.Millisecond)
+	if v := m.get(2); v != 4 || calls.Load() != 6 {
+		t.Errorf("after ttl: %d, calls = %d; want 4, 6", v, calls.Load())
+	}
+	// Keys 0, 1, 3 and 4 are never asked for again, but the sweep still drops them.
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.cache) != 1 {
+		t.Errorf("cache has %d entries after the ttl, want 1", len(m.cache))
+	}
+}
+
+// Callers that miss at the same time share one call of fn.
+func TestMemoizeConcurrentMisses(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	slow := Memoize(func(n int) int {
+		calls.Add(1)
+		<-release
+		return n + 1
+	}, 0)
+
+	var wg sync.WaitGroup
+	results := make([]int, 50)
+	for i := range results {
+		wg.Go(func() { results[i] = slow(i % 2) })
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if calls.Load() != 2 {
+		t.Errorf("calls = %d, want 2", calls.Load())
+	}
+	for i, r := range results {
+		if r != i%2+1 {
+			t.Fatalf("results[%d] = %d", i, r)
+		}
+	}
+}
+
+// A panic in fn reaches its caller, and a waiting caller tries again instead of hanging.
+func TestMemoizePanic(t *testing.T) {
+	var calls atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	f := Memoize(func(n int) int {
+		if calls.Add(1) == 1 {
+			close(started)
+			<-release
+			panic("first call fails")
+		}
+		return n
+	}, 0)
+
+	panicked := make(chan any)
+	go func() {
+		defer func() { panicked <- recover() }()
+		f(7)
+	}()
+	<-started
+	got := make(chan int)
+	go func() { got <- f(7) }()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	if p := <-panicked; p != "first call fails" {
+		t.Errorf("first caller recovered %v", p)
+	}
+	select {
+	case v := <-got:
+		if v != 7 || calls.Load() != 2 {
+			t.Errorf("second caller got %d after %d calls", v, calls.Load())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("second caller is still waiting")
+	}
+}
+
+func TestMemoFib(t *testing.T) {
+	if got, want := memoFib()(40), 102334155; got != want {
+		t.Errorf("memoFib()(40) = %d, want %d", got, want)
+	}
+}
+
+// BenchmarkFib compares recursive Fibonacci with its memoized twin.
+func BenchmarkFib(b *testing.B) {
+	b.Run("recursive", func(b *testing.B) {
+		for b.Loop() {
+			fib(25)
+		}
+	})
+	b.Run("memoized", func(b *testing.B) {
+		for b.Loop() {
+			// A fresh cache each time, so this measures computing, not just one lookup.
+			memoFib()(25)
+		}
+	})
+	b.Run("memoized warm", func(b *testing.B) {
+		f := memoFib()
+		for b.Loop() {
+			f(25)
+		}
+	})
+}
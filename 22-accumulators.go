@@ -0,0 +1,348 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+////// accumulators: adder() (3-more-types.go) keeps its sum hidden inside a closure, so the pos/neg
+// demo can add to it but never look at it or start over. Each accumulator here comes in two
+// forms: a closure like adder(), and a struct with Value() and Reset() that is safe for
+// concurrent use, for lightweight in-process metrics.
+
+// Number is every integer and float type.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+////// running sum
+
+type Sum[T Number] struct {
+	mu  sync.Mutex
+	sum T
+}
+
+// Add adds x and returns the new total, like the closure from adder().
+func (s *Sum[T]) Add(x T) T {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sum += x
+	return s.sum
+}
+
+func (s *Sum[T]) Value() T {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sum
+}
+
+func (s *Sum[T]) Reset() {
+	s.mu.Lock()
+	s.sum = 0
+	s.mu.Unlock()
+}
+
+// Adder is adder() for any number type.
+func Adder[T Number]() func(T) T {
+	var s Sum[T]
+	return s.Add
+}
+
+////// min/max
+
+type MinMax[T Number] struct {
+	mu       sync.Mutex
+	min, max T
+	seen     bool
+}
+
+func (m *MinMax[T]) Add(x T) (lo, hi T) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.seen {
+		m.min, m.max, m.seen = x, x, true
+	}
+	m.min, m.max = min(m.min, x), max(m.max, x)
+	return m.min, m.max
+}
+
+// Value returns the smallest and largest values so far; ok is false before the first Add.
+func (m *MinMax[T]) Value() (lo, hi T, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.min, m.max, m.seen
+}
+
+func (m *MinMax[T]) Reset() {
+	m.mu.Lock()
+	m.min, m.max, m.seen = 0, 0, false
+	m.mu.Unlock()
+}
+
+func MinMaxer[T Number]() func(T) (T, T) {
+	var m MinMax[T]
+	return m.Add
+}
+
+////// exponentially weighted moving average: every new value moves the average a fraction alpha
+// of the way towards it, so old values fade out smoothly without being stored. alpha close to 1
+// follows the input closely; close to 0 smooths hard.
+
+type EWMA struct {
+	Alpha float64
+
+	mu    sync.Mutex
+	value float64
+	seen  bool
+}
+
+// NewEWMAHalfLife picks alpha so a value's weight halves every halfLife samples.
+func NewEWMAHalfLife(halfLife float64) *EWMA {
+	return &EWMA{Alpha: 1 - math.Exp(-math.Ln2/halfLife)}
+}
+
+func (e *EWMA) Add(x float64) float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.seen {
+		// Start at the first value rather than dragging up from zero.
+		e.value, e.seen = x, true
+	} else {
+		e.value += e.Alpha * (x - e.value)
+	}
+	return e.value
+}
+
+func (e *EWMA) Value() float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.value
+}
+
+func (e *EWMA) Reset() {
+	e.mu.Lock()
+	e.value, e.seen = 0, false
+	e.mu.Unlock()
+}
+
+func EWMAer(alpha float64) func(float64) float64 {
+	e := &EWMA{Alpha: alpha}
+	return e.Add
+}
+
+////// windowed moving average: the mean of the last n values, kept in a ring buffer with a
+// running total so each Add is O(1).
+
+type MovingAverage struct {
+	mu     sync.Mutex
+	window []float64
+	next   int
+	full   bool
+	total  float64
+}
+
+var ErrWindowSize = errors.New("moving average window must hold at least one value")
+
+func NewMovingAverage(n int) (*MovingAverage, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("window of %d: %w", n, ErrWindowSize)
+	}
+	return &MovingAverage{window: make([]float64, n)}, nil
+}
+
+func (m *MovingAverage) Add(x float64) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.total += x - m.window[m.next]
+	m.window[m.next] = x
+	m.next = (m.next + 1) % len(m.window)
+	if m.next == 0 {
+		m.full = true
+		// Recompute now and then so floating-point drift can't build up.
+		m.total = 0
+		for _, v := range m.window {
+			m.total += v
+		}
+	}
+	return m.mean()
+}
+
+func (m *MovingAverage) mean() float64 {
+	n := m.next
+	if m.full {
+		n = len(m.window)
+	}
+	if n == 0 {
+		return 0
+	}
+	return m.total / float64(n)
+}
+
+func (m *MovingAverage) Value() float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.mean()
+}
+
+func (m *MovingAverage) Reset() {
+	m.mu.Lock()
+	clear(m.window)
+	m.next, m.full, m.total = 0, false, 0
+	m.mu.Unlock()
+}
+
+func MovingAverager(n int) (func(float64) float64, error) {
+	m, err := NewMovingAverage(n)
+	if err != nil {
+		return nil, err
+	}
+	return m.Add, nil
+}
+
+////// reservoir sampling: keep a uniform random sample of k values from a stream of unknown
+// length. The i-th value replaces a random slot with probability k/i (Vitter's algorithm R).
+
+type Reservoir[T any] struct {
+	mu     sync.Mutex
+	sample []T
+	k      int
+	seen   int
+	rng    *rand.Rand
+}
+
+func NewReservoir[T any](k int, seed uint64) *Reservoir[T] {
+	return &Reservoir[T]{k: k, rng: rand.New(rand.NewPCG(seed, seed^0x5bd1e995))}
+}
+
+// Add offers x to the sample and returns how many values have been seen.
+func (r *Reservoir[T]) Add(x T) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seen++
+	if len(r.sample) < r.k {
+		r.sample = append(r.sample, x)
+	} else if j := r.rng.IntN(r.seen); j < r.k {
+		r.sample[j] = x
+	}
+	return r.seen
+}
+
+// Value returns a copy of the current sample.
+func (r *Reservoir[T]) Value() []T {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]T(nil), r.sample...)
+}
+
+func (r *Reservoir[T]) Reset() {
+	r.mu.Lock()
+	r.sample, r.seen = nil, 0
+	r.mu.Unlock()
+}
+
+func Sampler[T any](k int, seed uint64) func(T) int {
+	return NewReservoir[T](k, seed).Add
+}
+
+func tryAccumulators() {
+	// The pos/neg demo from 3-more-types.go with the closure form.
+	pos, neg := Adder[int](), Adder[int]()
+	for i := 0; i < 10; i++ {
+		pos(i)
+		neg(-2 * i)
+	}
+	fmt.Println(pos(0), neg(0))
+
+	// The struct form can be read and reset.
+	var requests Sum[int]
+	for i := 0; i < 10; i++ {
+		requests.Add(i)
+	}
+	fmt.Println(requests.Value())
+	requests.Reset()
+	fmt.Println(requests.Value())
+
+	var mm MinMax[time.Duration]
+	_, _, ok := mm.Value()
+	fmt.Println(ok)
+	for _, d := range []time.Duration{30 * time.Millisecond, 5 * time.Millisecond, 120 * time.Millisecond} {
+		mm.Add(d)
+	}
+	lo, hi, ok := mm.Value()
+	fmt.Println(lo, hi, ok)
+
+	// A step from 10 to 20: the EWMA glides, the moving average gets there after its window.
+	ewma := NewEWMAHalfLife(2)
+	ma, _ := NewMovingAverage(4)
+	for i := 0; i < 8; i++ {
+		x := 10.0
+		if i >= 3 {
+			x = 20
+		}
+		fmt.Printf("in=%v ewma=%.2f ma=%.2f\n", x, ewma.Add(x), ma.Add(x))
+	}
+
+	// Every value of 1..1000 should land in a 10-value sample about 1% of the time.
+	hits := make([]int, 1000)
+	for trial := uint64(0); trial < 2000; trial++ {
+		r := NewReservoir[int](10, trial)
+		for i := 0; i < 1000; i++ {
+			r.Add(i)
+		}
+		for _, v := range r.Value() {
+			hits[v]++
+		}
+	}
+	lowest, highest := hits[0], hits[0]
+	for _, h := range hits {
+		lowest, highest = min(lowest, h), max(highest, h)
+	}
+	fmt.Printf("each value sampled between %d and %d times (expected 20)\n", lowest, highest)
+
+	// A window needs room for at least one value.
+	_, err := NewMovingAverage(0)
+	fmt.Println(err)
+}
+
+func tryConcurrentMetrics() {
+	// Many goroutines recording into shared accumulators.
+	var total Sum[int64]
+	var latency MinMax[time.Duration]
+	rate := &EWMA{Alpha: 0.1}
+	window, _ := NewMovingAverage(100)
+	sample := NewReservoir[int](5, 42)
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				total.Add(1)
+				latency.Add(time.Duration(w*1000+i) * time.Microsecond)
+				rate.Add(float64(i))
+				window.Add(float64(w))
+				sample.Add(w*1000 + i)
+			}
+		}()
+	}
+	wg.Wait()
+
+	lo, hi, _ := latency.Value()
+	fmt.Println("total:", total.Value(), "latency:", lo, "-", hi)
+	fmt.Printf("ewma=%.1f window=%.2f sample=%v\n", rate.Value(), window.Value(), sample.Value())
+}
+
+func main() {
+	// accumulators
+	tryAccumulators()
+
+	// concurrent use
+	tryConcurrentMetrics()
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMovingAverageWindowSize(t *testing.T) {
+	for _, n := range []int{0, -1} {
+		if m, err := NewMovingAverage(n); m != nil || !errors.Is(err, ErrWindowSize) {
+			t.Errorf("NewMovingAverage(%d) = %v, %v; want %v", n, m, err, ErrWindowSize)
+		}
+		if f, err := MovingAverager(n); f != nil || !errors.Is(err, ErrWindowSize) {
+			t.Errorf("MovingAverager(%d): err = %v, want %v", n, err, ErrWindowSize)
+		}
+	}
+}
+
+func TestMovingAverage(t *testing.T) {
+	add, err := MovingAverager(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The mean of what has been seen until the window fills, then of the last three.
+	for i, want := range []float64{3, 4.5, 6, 9, 12} {
+		if got := add(float64(3 * (i + 1))); got != want {
+			t.Errorf("value %d: average %v, want %v", i+1, got, want)
+		}
+	}
+
+	m, _ := NewMovingAverage(1)
+	m.Add(5)
+	m.Reset()
+	if v := m.Value(); v != 0 {
+		t.Errorf("after Reset: %v", v)
+	}
+	if v := m.Add(2); v != 2 {
+		t.Errorf("window of one: %v", v)
+	}
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unsafe"
+)
+
+////// slice inspector: printSlice (3-more-types.go) shows len, cap and values, but the slice lessons
+// are really about aliasing: nameB[0] = "XXX" changes names because both look at the same
+// backing array. The inspector finds which slices share an array, using unsafe.SliceData to get
+// at the pointers, and draws the array with each slice's window on it.
+
+// View is a slice with a name for the diagram.
+type View[T any] struct {
+	Name string
+	S    []T
+}
+
+func V[T any](name string, s []T) View[T] {
+	return View[T]{name, s}
+}
+
+// span is the memory a slice can reach: from its first element to the end of its capacity.
+// ptr is the first element again, kept as a real pointer so the memory stays reachable.
+type span struct {
+	ptr        unsafe.Pointer
+	start, end uintptr
+}
+
+func spanOf[T any](s []T) span {
+	var zero T
+	ptr := unsafe.Pointer(unsafe.SliceData(s))
+	start := uintptr(ptr)
+	return span{ptr, start, start + uintptr(cap(s))*unsafe.Sizeof(zero)}
+}
+
+// union is the smallest span covering both.
+func (a span) union(b span) span {
+	if b.start < a.start {
+		a.ptr, a.start = b.ptr, b.start
+	}
+	a.end = max(a.end, b.end)
+	return a
+}
+
+func (a span) overlaps(b span) bool {
+	return a.start < b.end && b.start < a.end
+}
+
+// Group is a set of views that share one backing array, and the part of the array they reach.
+type Group[T any] struct {
+	Views []View[T]
+	span  span
+}
+
+// Len is the number of array elements the group's views can reach. Elements of a zero-size
+// type, like struct{}, take no memory and all sit at one address, so there the caps are all
+// there is to go on.
+func (g Group[T]) Len() int {
+	var zero T
+	size := unsafe.Sizeof(zero)
+	if size == 0 {
+		n := 0
+		for _, v := range g.Views {
+			n = max(n, cap(v.S))
+		}
+		return n
+	}
+	return int((g.span.end - g.span.start) / size)
+}
+
+// offset is the index of a view's first element within the group's array.
+func (g Group[T]) offset(v View[T]) int {
+	var zero T
+	size := unsafe.Sizeof(zero)
+	if size == 0 {
+		return 0
+	}
+	return int((spanOf(v.S).start - g.span.start) / size)
+}
+
+// Groups partitions views by backing array: two slices share one when the memory they can
+// reach overlaps. Views with no capacity don't point at any array and get a group each, and so
+// do views of a zero-size type, which reach no memory at all.
+func Groups[T any](views ...View[T]) []Group[T] {
+	// Groups are built from indices into views, so the order survives duplicate names.
+	type group struct {
+		members []int
+		span    span
+	}
+	var groups []group
+	for i, v := range views {
+		merged := group{members: []int{i}, span: spanOf(v.S)}
+		// v may bridge several existing groups; fold them all into one.
+		var rest []group
+		for _, g := range groups {
+			if cap(v.S) > 0 && g.span.overlaps(merged.span) {
+				merged.members = append(g.members, merged.members...)
+				merged.span = merged.span.union(g.span)
+			} else {
+				rest = append(rest, g)
+			}
+		}
+		groups = append(rest, merged)
+	}
+	// Keep the order the views were given in.
+	for _, g := range groups {
+		slices.Sort(g.members)
+	}
+	slices.SortFunc(groups, func(a, b group) int { return a.members[0] - b.members[0] })
+	out := make([]Group[T], len(groups))
+	for i, g := range groups {
+		out[i].span = g.span
+		for _, m := range g.members {
+			out[i].Views = append(out[i].Views, views[m])
+		}
+	}
+	return out
+}
+
+// Inspect draws every backing array and the windows of the slices on it. '=' marks elements
+// inside a slice's len, '-' the extra room up to its cap.
+func Inspect[T any](views ...View[T]) string {
+	var sb strings.Builder
+	for i, g := range Groups(views...) {
+		n := g.Len()
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		names := make([]string, len(g.Views))
+		for j, v := range g.Views {
+			names[j] = v.Name
+		}
+		fmt.Fprintf(&sb, "array %d: %d elements at %#x, shared by %s\n", i+1, n, g.span.start, strings.Join(names, ", "))
+		if n == 0 {
+			continue
+		}
+
+		// Reading the whole reachable array is safe: every element is within some slice's cap.
+		array := unsafe.Slice((*T)(g.span.ptr), n)
+		cells := make([]string, n)
+		width := 1
+		for k, v := range array {
+			cells[k] = fmt.Sprint(v)
+			width = max(width, len(cells[k]), len(fmt.Sprint(k)))
+		}
+		label := 5
+		for _, v := range g.Views {
+			label = max(label, len(v.Name))
+		}
+
+		fmt.Fprintf(&sb, "%-*s", label, "index")
+		for k := range array {
+			fmt.Fprintf(&sb, " %-*d", width, k)
+		}
+		fmt.Fprintf(&sb, "\n%-*s", label, "value")
+		for _, c := range cells {
+			fmt.Fprintf(&sb, " %-*s", width, c)
+		}
+		sb.WriteString("\n")
+		for _, v := range g.Views {
+			off := g.offset(v)
+			fmt.Fprintf(&sb, "%-*s", label, v.Name)
+			for k := 0; k < n; k++ {
+				mark := " "
+				switch {
+				case k >= off && k < off+len(v.S):
+					mark = "="
+				case k >= off && k < off+cap(v.S):
+					mark = "-"
+				}
+				fmt.Fprintf(&sb, " %s", strings.Repeat(mark, width))
+			}
+			fmt.Fprintf(&sb, "  [%d:%d] len=%d cap=%d\n", off, off+len(v.S), len(v.S), cap(v.S))
+		}
+	}
+	// Padding leaves trailing spaces on short rows.
+	lines := strings.Split(sb.String(), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(l, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+////// append: when the slice has room, append writes into the shared array, where other slices
+// may see the new values; when it doesn't, it copies everything to a new array and the result
+// no longer shares anything with the original.
+
+// AppendReport describes what an append did.
+type AppendReport struct {
+	Reallocated      bool
+	OldCap, NewCap   int
+	OldData, NewData uintptr
+	// Clobbered names the other views whose visible elements the append overwrote.
+	Clobbered []string
+}
+
+func (r AppendReport) String() string {
+	if r.Reallocated {
+		return fmt.Sprintf("reallocated: cap %d -> %d, data %#x -> %#x", r.OldCap, r.NewCap, r.OldData, r.NewData)
+	}
+	s := fmt.Sprintf("in place: cap %d, data %#x", r.NewCap, r.NewData)
+	if len(r.Clobbered) > 0 {
+		s += ", overwrote elements of " + strings.Join(r.Clobbered, ", ")
+	}
+	return s
+}
+
+// Append appends like the builtin and reports whether it reallocated and whom it clobbered.
+func Append[T any](s []T, others []View[T], vals ...T) ([]T, AppendReport) {
+	before := spanOf(s)
+	// The memory the new elements will go to, if they stay in place.
+	var zero T
+	size := unsafe.Sizeof(zero)
+	written := span{start: before.start + uintptr(len(s))*size, end: before.start + uintptr(len(s)+len(vals))*size}
+
+	out := append(s, vals...)
+	r := AppendReport{
+		OldCap:  cap(s),
+		NewCap:  cap(out),
+		OldData: before.start,
+		NewData: spanOf(out).start,
+	}
+	// A bigger cap always means a new array; for zero-size elements, which all share one
+	// address, it is the only sign.
+	r.Reallocated = r.OldData != r.NewData || r.NewCap > r.OldCap
+	if !r.Reallocated {
+		for _, o := range others {
+			start := spanOf(o.S).start
+			visible := span{start: start, end: start + uintptr(len(o.S))*size}
+			if len(o.S) > 0 && visible.overlaps(written) {
+				r.Clobbered = append(r.Clobbered, o.Name)
+			}
+		}
+	}
+	return out, r
+}
+
+func printSlice2(s string, x []int) {
+	fmt.Printf("%s len=%d cap=%d %v\n", s, len(x), cap(x), x)
+}
+
+func tryInspectingSlices() {
+	// From 3-more-types.go: two windows on one array.
+	names := [4]string{"John", "Paul", "George", "Ringo"}
+	nameA := names[0:2]
+	nameB := names[1:3]
+	nameB[0] = "XXX"
+	fmt.Print(Inspect(V("names", names[:]), V("nameA", nameA), V("nameB", nameB)))
+	fmt.Println()
+
+	// make, reslicing past len, and an unrelated slice.
+	b2 := make([]int, 0, 5)
+	c2 := b2[:2]
+	d2 := c2[2:5]
+	printSlice2("d", d2)
+	other := []int{7, 8, 9}
+	fmt.Print(Inspect(V("b2", b2), V("c2", c2), V("d2", d2), V("other", other)))
+	fmt.Println()
+
+	// A full slice expression limits cap, but still shares the array.
+	primes := []int{2, 3, 5, 7, 11, 13}
+	head := primes[0:2:3]
+	fmt.Print(Inspect(V("primes", primes), V("head", head)))
+}
+
+func tryAppendReports() {
+	names := [4]string{"John", "Paul", "George", "Ringo"}
+	nameA := names[0:2]
+	nameB := names[1:3]
+
+	// nameA has room, so append writes names[2] under nameB's nose.
+	nameA, r := Append(nameA, []View[string]{V("nameB", nameB)}, "Yoko")
+	fmt.Println(r)
+	fmt.Println(nameA, nameB, names)
+
+	// Now it has to grow: a new array, and writes no longer show through.
+	nameA, r = Append(nameA, []View[string]{V("nameB", nameB)}, "Linda", "Brian")
+	fmt.Println(r)
+	nameA[0] = "Johnny"
+	fmt.Println(nameA, names)
+	fmt.Print(Inspect(V("names", names[:]), V("nameA", nameA), V("nameB", nameB)))
+
+	// appendToSlice from 3-more-types.go, watching the reallocations.
+	var s []int
+	for i := 0; i < 6; i++ {
+		var r AppendReport
+		s, r = Append(s, nil, i)
+		fmt.Printf("append %d: %v\n", i, r.Reallocated)
+	}
+}
+
+func main() {
+	// inspecting slices
+	tryInspectingSlices()
+
+	// append reports
+	tryAppendReports()
+}
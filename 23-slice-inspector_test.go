@@ -0,0 +1,91 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func groupNames[T any](groups []Group[T]) [][]string {
+	var out [][]string
+	for _, g := range groups {
+		var names []string
+		for _, v := range g.Views {
+			names = append(names, v.Name)
+		}
+		out = append(out, names)
+	}
+	return out
+}
+
+func TestGroups(t *testing.T) {
+	names := [4]string{"John", "Paul", "George", "Ringo"}
+	other := []string{"Yoko"}
+	groups := Groups(V("nameB", names[1:3]), V("other", other), V("nameA", names[0:2]), V("tail", names[3:]))
+	want := [][]string{{"nameB", "nameA", "tail"}, {"other"}}
+	if got := groupNames(groups); !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("groups = %v, want %v", got, want)
+	}
+	if n := groups[0].Len(); n != 4 {
+		t.Errorf("shared array has %d elements, want 4", n)
+	}
+}
+
+// Views with the same name stay separate and in the order given.
+func TestGroupsDuplicateNames(t *testing.T) {
+	a, b := []int{1, 2}, []int{3, 4}
+	shared := make([]int, 4)
+	groups := Groups(V("x", b), V("x", a), V("y", shared[2:]), V("x", shared[:1]))
+	got := groupNames(groups)
+	want := [][]string{{"x"}, {"x"}, {"y", "x"}}
+	if !slices.EqualFunc(got, want, slices.Equal) {
+		t.Fatalf("groups = %v, want %v", got, want)
+	}
+	if &groups[0].Views[0].S[0] != &b[0] || &groups[1].Views[0].S[0] != &a[0] {
+		t.Error("views with the same name were reordered")
+	}
+	if off := groups[2].offset(groups[2].Views[0]); off != 2 {
+		t.Errorf("offset of y = %d, want 2", off)
+	}
+}
+
+func TestZeroSizeElements(t *testing.T) {
+	s := make([]struct{}, 3, 5)
+	groups := Groups(V("s", s), V("t", s[1:2]))
+	if len(groups) != 2 || groups[0].Len() != 5 || groups[1].Len() != 4 {
+		t.Errorf("groups = %v", groups)
+	}
+	out := Inspect(V("s", s), V("empty", []struct{}{}))
+	if !strings.Contains(out, "s     == == == -- --  [0:3] len=3 cap=5") {
+		t.Errorf("Inspect:\n%s", out)
+	}
+
+	grown, r := Append(s, nil, struct{}{}, struct{}{}, struct{}{})
+	if len(grown) != 6 || !r.Reallocated {
+		t.Errorf("append past cap: len %d, %v", len(grown), r)
+	}
+}
+
+func TestAppendReport(t *testing.T) {
+	var empty []int
+	out, r := Append(empty, nil)
+	if out != nil || r.Reallocated {
+		t.Errorf("appending nothing to nil: %v", r)
+	}
+	if _, r := Append(make([]int, 0), nil); r.Reallocated {
+		t.Errorf("appending nothing to an empty slice: %v", r)
+	}
+	if _, r := Append(empty, nil, 1); !r.Reallocated {
+		t.Errorf("appending to nil: %v", r)
+	}
+
+	names := [4]string{"John", "Paul", "George", "Ringo"}
+	nameA, nameB := names[0:2], names[1:3]
+	nameA, r = Append(nameA, []View[string]{V("nameB", nameB)}, "Yoko")
+	if r.Reallocated || !slices.Equal(r.Clobbered, []string{"nameB"}) || names[2] != "Yoko" {
+		t.Errorf("append with room: %v, names %v", r, names)
+	}
+	if _, r := Append(nameA, []View[string]{V("nameB", nameB)}, "Linda", "Brian"); !r.Reallocated || r.Clobbered != nil {
+		t.Errorf("append past cap: %v", r)
+	}
+}
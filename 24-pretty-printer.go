@@ -0,0 +1,406 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+////// pretty printing: describe (4-methods.go) prints (%v, %T), which is fine for 42 or "hello" but
+// unreadable for nested structs, maps of Vertex2 or pointers, and a cyclic List[T] would never
+// finish. The Printer walks any value with reflect instead: one field per line, map keys sorted,
+// pointers followed, cycles detected, and long or deep values cut short.
+
+// Printer holds the options. The zero value prints everything, indented by two spaces.
+type Printer struct {
+	// Indent is repeated once per nesting level; "" means two spaces.
+	Indent string
+	// MaxDepth stops descending below this many levels; 0 means no limit.
+	MaxDepth int
+	// MaxItems shows at most this many elements of a slice, array or map; 0 means all.
+	MaxItems int
+	// Unexported also shows unexported struct fields.
+	Unexported bool
+	// Color adds ANSI colours for terminals.
+	Color bool
+	// Compact puts everything on one line.
+	Compact bool
+}
+
+// Pretty formats v with the default options.
+func Pretty(v any) string {
+	var p Printer
+	return p.Sprint(v)
+}
+
+func (p *Printer) Sprint(v any) string {
+	s := &state{p: p, visiting: make(map[visit]bool)}
+	s.value(reflect.ValueOf(v), 0)
+	return s.sb.String()
+}
+
+const (
+	ansiReset   = "\033[0m"
+	ansiField   = "\033[36m" // cyan
+	ansiString  = "\033[32m" // green
+	ansiNumber  = "\033[33m" // yellow
+	ansiType    = "\033[34m" // blue
+	ansiSpecial = "\033[35m" // magenta: nil, bools, cycles, cut-offs
+)
+
+// visit identifies a pointer-like value on the current path: the same address with the same
+// type means we are going round in a circle.
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+type state struct {
+	p        *Printer
+	sb       strings.Builder
+	visiting map[visit]bool
+}
+
+func (s *state) color(code, text string) {
+	if s.p.Color {
+		s.sb.WriteString(code + text + ansiReset)
+		return
+	}
+	s.sb.WriteString(text)
+}
+
+// newline starts a new line at the given depth, or writes a space in compact mode.
+func (s *state) newline(depth int) {
+	if s.p.Compact {
+		s.sb.WriteString(" ")
+		return
+	}
+	indent := s.p.Indent
+	if indent == "" {
+		indent = "  "
+	}
+	s.sb.WriteString("\n" + strings.Repeat(indent, depth))
+}
+
+var stringerType = reflect.TypeFor[fmt.Stringer]()
+var errorType = reflect.TypeFor[error]()
+
+func (s *state) value(v reflect.Value, depth int) {
+	if !v.IsValid() {
+		s.color(ansiSpecial, "nil")
+		return
+	}
+
+	// Types that know how to print themselves, like time.Time and time.Duration. Only for
+	// values we may call methods on; unexported fields can't be.
+	if v.CanInterface() && v.Kind() != reflect.Pointer && v.Kind() != reflect.Interface &&
+		(v.Type().Implements(stringerType) || v.Type().Implements(errorType)) {
+		s.color(ansiString, fmt.Sprint(v.Interface()))
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		s.color(ansiSpecial, strconv.FormatBool(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		s.color(ansiNumber, strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		s.color(ansiNumber, strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		s.color(ansiNumber, strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()))
+	case reflect.Complex64, reflect.Complex128:
+		s.color(ansiNumber, strconv.FormatComplex(v.Complex(), 'g', -1, v.Type().Bits()))
+	case reflect.String:
+		s.color(ansiString, strconv.Quote(v.String()))
+	case reflect.Interface:
+		s.value(v.Elem(), depth)
+	case reflect.Pointer:
+		s.pointer(v, depth)
+	case reflect.Struct:
+		s.structValue(v, depth)
+	case reflect.Map:
+		s.mapValue(v, depth)
+	case reflect.Slice, reflect.Array:
+		s.list(v, depth)
+	default:
+		// Funcs, channels and unsafe pointers: the type and where it lives.
+		if v.IsNil() {
+			s.color(ansiSpecial, "nil")
+			return
+		}
+		s.color(ansiType, v.Type().String())
+		fmt.Fprintf(&s.sb, "(%#x)", v.Pointer())
+	}
+}
+
+// enter records a pointer-like value on the path; it reports false for a cycle.
+func (s *state) enter(v reflect.Value) (visit, bool) {
+	key := visit{v.Pointer(), v.Type()}
+	if s.visiting[key] {
+		s.color(ansiSpecial, fmt.Sprintf("<cycle %s>", v.Type()))
+		return key, false
+	}
+	s.visiting[key] = true
+	return key, true
+}
+
+// tooDeep prints a cut-off marker when the depth limit is reached.
+func (s *state) tooDeep(depth int, open, close string) bool {
+	if s.p.MaxDepth > 0 && depth >= s.p.MaxDepth {
+		s.color(ansiSpecial, open+"..."+close)
+		return true
+	}
+	return false
+}
+
+func (s *state) pointer(v reflect.Value, depth int) {
+	if v.IsNil() {
+		s.color(ansiSpecial, "nil")
+		return
+	}
+	key, ok := s.enter(v)
+	if !ok {
+		return
+	}
+	defer delete(s.visiting, key)
+	s.sb.WriteString("&")
+	s.value(v.Elem(), depth)
+}
+
+func (s *state) structValue(v reflect.Value, depth int) {
+	t := v.Type()
+	s.color(ansiType, t.String())
+	if s.tooDeep(depth, "{", "}") {
+		return
+	}
+	s.sb.WriteString("{")
+	wrote := false
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() && !s.p.Unexported {
+			continue
+		}
+		if wrote && s.p.Compact {
+			s.sb.WriteString(",")
+		}
+		s.newline(depth + 1)
+		s.color(ansiField, f.Name)
+		s.sb.WriteString(": ")
+		s.value(v.Field(i), depth+1)
+		if !s.p.Compact {
+			s.sb.WriteString(",")
+		}
+		wrote = true
+	}
+	s.close(wrote, depth, "}")
+}
+
+// close ends a composite: on its own line when it had contents, right away when empty.
+func (s *state) close(wrote bool, depth int, bracket string) {
+	if wrote {
+		if s.p.Compact {
+			s.sb.WriteString(" ")
+		} else {
+			s.newline(depth)
+		}
+	}
+	s.sb.WriteString(bracket)
+}
+
+// more notes how many elements MaxItems left out.
+func (s *state) more(n int, depth int) {
+	if s.p.MaxItems > 0 && n > s.p.MaxItems {
+		if s.p.Compact {
+			s.sb.WriteString(",")
+		}
+		s.newline(depth + 1)
+		s.color(ansiSpecial, fmt.Sprintf("... %d more", n-s.p.MaxItems))
+		if !s.p.Compact {
+			s.sb.WriteString(",")
+		}
+	}
+}
+
+func (s *state) list(v reflect.Value, depth int) {
+	if v.Kind() == reflect.Slice {
+		if v.IsNil() {
+			s.color(ansiType, v.Type().String())
+			s.color(ansiSpecial, "(nil)")
+			return
+		}
+		key, ok := s.enter(v)
+		if !ok {
+			return
+		}
+		defer delete(s.visiting, key)
+	}
+	s.color(ansiType, v.Type().String())
+	if s.tooDeep(depth, "{", "}") {
+		return
+	}
+	s.sb.WriteString("{")
+	n := v.Len()
+	shown := n
+	if s.p.MaxItems > 0 {
+		shown = min(n, s.p.MaxItems)
+	}
+	for i := 0; i < shown; i++ {
+		if i > 0 && s.p.Compact {
+			s.sb.WriteString(",")
+		}
+		s.newline(depth + 1)
+		s.value(v.Index(i), depth+1)
+		if !s.p.Compact {
+			s.sb.WriteString(",")
+		}
+	}
+	s.more(n, depth)
+	s.close(n > 0, depth, "}")
+}
+
+func (s *state) mapValue(v reflect.Value, depth int) {
+	if v.IsNil() {
+		s.color(ansiType, v.Type().String())
+		s.color(ansiSpecial, "(nil)")
+		return
+	}
+	key, ok := s.enter(v)
+	if !ok {
+		return
+	}
+	defer delete(s.visiting, key)
+	s.color(ansiType, v.Type().String())
+	if s.tooDeep(depth, "{", "}") {
+		return
+	}
+	s.sb.WriteString("{")
+	// MapRange rather than MapIndex: a NaN key is never equal to itself, so looking it up
+	// finds nothing.
+	var entries []mapEntry
+	for it := v.MapRange(); it.Next(); {
+		entries = append(entries, mapEntry{it.Key(), it.Value()})
+	}
+	slices.SortFunc(entries, func(a, b mapEntry) int {
+		// Only NaN keys tie; their values keep the output stable.
+		return cmp.Or(compareKeys(a.key, b.key), cmp.Compare(fmt.Sprint(a.val), fmt.Sprint(b.val)))
+	})
+	shown := len(entries)
+	if s.p.MaxItems > 0 {
+		shown = min(shown, s.p.MaxItems)
+	}
+	for i, e := range entries[:shown] {
+		if i > 0 && s.p.Compact {
+			s.sb.WriteString(",")
+		}
+		s.newline(depth + 1)
+		s.value(e.key, depth+1)
+		s.sb.WriteString(": ")
+		s.value(e.val, depth+1)
+		if !s.p.Compact {
+			s.sb.WriteString(",")
+		}
+	}
+	s.more(len(entries), depth)
+	s.close(len(entries) > 0, depth, "}")
+}
+
+type mapEntry struct {
+	key, val reflect.Value
+}
+
+// compareKeys orders numbers numerically and strings alphabetically; any other key type falls
+// back to comparing its %v form, which is at least stable.
+func compareKeys(a, b reflect.Value) int {
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp.Compare(a.Int(), b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return cmp.Compare(a.Uint(), b.Uint())
+	case reflect.Float32, reflect.Float64:
+		return cmp.Compare(a.Float(), b.Float())
+	case reflect.String:
+		return cmp.Compare(a.String(), b.String())
+	}
+	return cmp.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+// The lesson types this is meant for.
+type Vertex struct {
+	X, Y float64
+}
+
+type Vertex2 struct {
+	Lat, Long float64
+}
+
+type List[T any] struct {
+	next *List[T]
+	val  T
+}
+
+type Server struct {
+	Name     string
+	Started  time.Time
+	Timeout  time.Duration
+	Tags     []string
+	Limits   map[string]int
+	Primary  *Vertex
+	Handlers map[string]func()
+	secret   string
+}
+
+func describe(i interface{}) {
+	fmt.Printf("(%v, %T)\n", i, i)
+}
+
+func tryPrettyPrinter() {
+	m := map[string]Vertex2{
+		"Google":    {37.42202, -122.08408},
+		"Bell Labs": {40.68433, -74.39967},
+	}
+	describe(m)
+	fmt.Println(Pretty(m))
+
+	srv := Server{
+		Name:     "api",
+		Started:  time.Date(2009, 11, 10, 23, 0, 0, 0, time.UTC),
+		Timeout:  3 * time.Second,
+		Tags:     []string{"prod", "eu"},
+		Limits:   map[string]int{"rps": 100, "burst": 20},
+		Primary:  &Vertex{3, 4},
+		Handlers: map[string]func(){"hello": nil},
+		secret:   "hunter2",
+	}
+	describe(srv)
+	fmt.Println(Pretty(srv))
+
+	// Unexported fields on request, like List's next and val.
+	list := &List[string]{val: "first", next: &List[string]{val: "second"}}
+	describe(list)
+	p := Printer{Unexported: true}
+	fmt.Println(p.Sprint(list))
+
+	// A cycle: the last element points back at the first.
+	list.next.next = list
+	fmt.Println(p.Sprint(list))
+
+	// Limits on depth and length, and the compact form.
+	deep := map[int][]int{3: {1, 2, 3, 4, 5, 6, 7, 8}, 1: {1}, 2: nil}
+	limited := Printer{MaxItems: 2, MaxDepth: 1}
+	fmt.Println(limited.Sprint(deep))
+	compact := Printer{Compact: true, Unexported: true}
+	fmt.Println(compact.Sprint(srv))
+
+	// Colour for terminals.
+	colour := Printer{Color: true, Compact: true}
+	fmt.Println(colour.Sprint(m))
+}
+
+func main() {
+	// pretty printing
+	tryPrettyPrinter()
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSprint(t *testing.T) {
+	cycle := &List[int]{val: 1, next: &List[int]{val: 2}}
+	cycle.next.next = cycle
+	self := []any{1, nil}
+	self[1] = self
+	// The same pointer twice is not a cycle.
+	shared := &Vertex{1, 2}
+	srv := Server{
+		Name:    "api",
+		Started: time.Date(2009, 11, 10, 23, 0, 0, 0, time.UTC),
+		Timeout: 3 * time.Second,
+		Tags:    []string{"prod"},
+		secret:  "hunter2",
+	}
+
+	tests := []struct {
+		name string
+		p    Printer
+		v    any
+		want string
+	}{
+		{"scalars", Printer{}, []any{1, -2.5, "hi", true, nil}, `[]interface {}{
+  1,
+  -2.5,
+  "hi",
+  true,
+  nil,
+}`},
+		{"struct hides unexported", Printer{}, srv, `main.Server{
+  Name: "api",
+  Started: 2009-11-10 23:00:00 +0000 UTC,
+  Timeout: 3s,
+  Tags: []string{
+    "prod",
+  },
+  Limits: map[string]int(nil),
+  Primary: nil,
+  Handlers: map[string]func()(nil),
+}`},
+		{"unexported", Printer{Unexported: true}, &List[string]{val: "a"}, `&main.List[string]{
+  next: nil,
+  val: "a",
+}`},
+		{"compact", Printer{Compact: true, Unexported: true}, srv,
+			`main.Server{ Name: "api", Started: 2009-11-10 23:00:00 +0000 UTC, Timeout: 3s, Tags: []string{ "prod" }, Limits: map[string]int(nil), Primary: nil, Handlers: map[string]func()(nil), secret: "hunter2" }`},
+		{"empty composites", Printer{Compact: true}, []any{[]int{}, map[string]int{}, struct{}{}},
+			`[]interface {}{ []int{}, map[string]int{}, struct {}{} }`},
+		{"indent", Printer{Indent: "\t"}, Vertex{1, 2}, "main.Vertex{\n\tX: 1,\n\tY: 2,\n}"},
+		{"max depth", Printer{MaxDepth: 1}, map[string][]Vertex{"a": {{1, 2}}}, `map[string][]main.Vertex{
+  "a": []main.Vertex{...},
+}`},
+		{"max depth 2", Printer{MaxDepth: 2, Compact: true}, map[string][]Vertex{"a": {{1, 2}}},
+			`map[string][]main.Vertex{ "a": []main.Vertex{ main.Vertex{...} } }`},
+		{"max items", Printer{MaxItems: 2, Compact: true}, []int{1, 2, 3, 4, 5}, `[]int{ 1, 2, ... 3 more }`},
+		{"max items map", Printer{MaxItems: 1}, map[int]bool{2: true, 1: false, 3: true}, `map[int]bool{
+  1: false,
+  ... 2 more,
+}`},
+		{"max items not reached", Printer{MaxItems: 3, Compact: true}, []int{1, 2, 3}, `[]int{ 1, 2, 3 }`},
+		{"cycle", Printer{Unexported: true, Compact: true}, cycle,
+			`&main.List[int]{ next: &main.List[int]{ next: <cycle *main.List[int]>, val: 2 }, val: 1 }`},
+		{"slice cycle", Printer{Compact: true}, self, `[]interface {}{ 1, <cycle []interface {}> }`},
+		{"shared pointer", Printer{Compact: true}, []*Vertex{shared, shared},
+			`[]*main.Vertex{ &main.Vertex{ X: 1, Y: 2 }, &main.Vertex{ X: 1, Y: 2 } }`},
+		{"string keys", Printer{Compact: true}, map[string]int{"b": 2, "a": 1, "c": 3}, `map[string]int{ "a": 1, "b": 2, "c": 3 }`},
+		// Numbers, not their text: 10 comes after 9.
+		{"int keys", Printer{Compact: true}, map[int]string{10: "ten", 9: "nine", -1: "minus one"},
+			`map[int]string{ -1: "minus one", 9: "nine", 10: "ten" }`},
+		{"uint keys", Printer{Compact: true}, map[uint8]int{200: 1, 3: 2}, `map[uint8]int{ 3: 2, 200: 1 }`},
+		{"float keys", Printer{Compact: true}, map[float64]int{2.5: 1, -1: 2, math.Inf(1): 3}, `map[float64]int{ -1: 2, 2.5: 1, +Inf: 3 }`},
+		// NaN keys sort first, and keep their values even though no lookup can find them.
+		{"NaN keys", Printer{Compact: true}, map[float64]string{math.NaN(): "b", 1: "one", math.NaN(): "a"},
+			`map[float64]string{ NaN: "a", NaN: "b", 1: "one" }`},
+		{"struct keys", Printer{Compact: true}, map[Vertex]int{{2, 1}: 1, {1, 2}: 2}, `map[main.Vertex]int{ main.Vertex{ X: 1, Y: 2 }: 2, main.Vertex{ X: 2, Y: 1 }: 1 }`},
+		{"color", Printer{Color: true, Compact: true}, map[string]int{"a": 1},
+			"\033[34mmap[string]int\033[0m{ \033[32m\"a\"\033[0m: \033[33m1\033[0m }"},
+	}
+	for _, tt := range tests {
+		if got := tt.p.Sprint(tt.v); got != tt.want {
+			t.Errorf("%s:\ngot\n%s\nwant\n%s", tt.name, got, tt.want)
+		}
+	}
+}
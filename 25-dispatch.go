@@ -0,0 +1,275 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"time"
+)
+
+////// dispatch: do (4-methods.go) is a type switch with int and string hard-coded and a default
+// that prints "I don't know about type". A Dispatcher is a type switch whose cases are added at
+// run time: handlers are registered per type with generics, interface types match anything that
+// implements them, and an unhandled value comes back as an error.
+
+var (
+	// ErrUnhandled matches every *UnhandledError with errors.Is.
+	ErrUnhandled = errors.New("dispatch: no handler")
+	// ErrNext can be returned by a handler to pass the value on to the next matching one.
+	ErrNext = errors.New("dispatch: try next handler")
+)
+
+// UnhandledError reports a value no handler matched. Type is nil for a nil value.
+type UnhandledError struct {
+	Type reflect.Type
+}
+
+func (e *UnhandledError) Error() string {
+	if e.Type == nil {
+		return "dispatch: no handler for nil"
+	}
+	return fmt.Sprintf("dispatch: no handler for type %v", e.Type)
+}
+
+func (e *UnhandledError) Is(target error) bool {
+	return target == ErrUnhandled
+}
+
+type handler struct {
+	typ      reflect.Type
+	priority int
+	seq      int
+	call     func(any) error
+}
+
+// exact reports whether the handler is for a concrete type rather than an interface.
+func (h handler) exact() bool {
+	return h.typ.Kind() != reflect.Interface
+}
+
+// Dispatcher routes values to handlers by type. The zero value is ready to use; it is not safe to
+// register handlers while dispatching from other goroutines.
+type Dispatcher struct {
+	handlers []handler
+	seq      int
+}
+
+// Register adds a handler for T at priority 0. T may be a concrete type, like int, or an
+// interface, like Abser or error.
+func Register[T any](d *Dispatcher, fn func(T) error) {
+	RegisterPriority(d, 0, fn)
+}
+
+// RegisterPriority adds a handler for T. Among the handlers that match a value, higher priorities
+// run first; at equal priority exact types beat interfaces, then earlier registrations win.
+func RegisterPriority[T any](d *Dispatcher, priority int, fn func(T) error) {
+	d.seq++
+	d.handlers = append(d.handlers, handler{
+		typ:      reflect.TypeFor[T](),
+		priority: priority,
+		seq:      d.seq,
+		call: func(v any) error {
+			// Only nil fails the assertion, and only for an any handler; it gets nil as T.
+			t, _ := v.(T)
+			return fn(t)
+		},
+	})
+}
+
+var anyType = reflect.TypeFor[any]()
+
+// matching returns the handlers for v in the order they should run. nil has no type, so only
+// catch-all handlers for any take it.
+func (d *Dispatcher) matching(v any) []handler {
+	t := reflect.TypeOf(v)
+	var hs []handler
+	for _, h := range d.handlers {
+		switch {
+		case t == nil:
+			if h.typ == anyType {
+				hs = append(hs, h)
+			}
+		case (h.exact() && h.typ == t) || (!h.exact() && t.Implements(h.typ)):
+			hs = append(hs, h)
+		}
+	}
+	slices.SortFunc(hs, func(a, b handler) int {
+		if c := cmp.Compare(b.priority, a.priority); c != 0 {
+			return c
+		}
+		if a.exact() != b.exact() {
+			if a.exact() {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Compare(a.seq, b.seq)
+	})
+	return hs
+}
+
+// Dispatch runs the first matching handler, moving on to the next one while handlers return
+// ErrNext. It returns an *UnhandledError when nothing takes the value.
+func (d *Dispatcher) Dispatch(v any) error {
+	for _, h := range d.matching(v) {
+		if err := h.call(v); !errors.Is(err, ErrNext) {
+			return err
+		}
+	}
+	return &UnhandledError{Type: reflect.TypeOf(v)}
+}
+
+// DispatchAll runs every matching handler in order and joins their errors. Like Dispatch, it
+// returns an *UnhandledError when nothing takes the value: no handler matched, or every one
+// returned ErrNext.
+func (d *Dispatcher) DispatchAll(v any) error {
+	handled := false
+	var errs []error
+	for _, h := range d.matching(v) {
+		err := h.call(v)
+		if errors.Is(err, ErrNext) {
+			continue
+		}
+		handled = true
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if !handled {
+		return &UnhandledError{Type: reflect.TypeOf(v)}
+	}
+	return errors.Join(errs...)
+}
+
+// The types from 4-methods.go.
+type Abser interface {
+	Abs() float64
+}
+
+type Vertex struct {
+	X, Y float64
+}
+
+// A pointer receiver, as in the tour, so a Vertex (not *Vertex) is not an Abser.
+func (v *Vertex) Abs() float64 {
+	return math.Sqrt(v.X*v.X + v.Y*v.Y)
+}
+
+type MyFloat float64
+
+func (f MyFloat) Abs() float64 {
+	if f < 0 {
+		return float64(-f)
+	}
+	return float64(f)
+}
+
+type MyError struct {
+	When time.Time
+	What string
+}
+
+func (e *MyError) Error() string {
+	return fmt.Sprintf("at %v, %s", e.When, e.What)
+}
+
+func do(i interface{}) {
+	switch v := i.(type) {
+	case int:
+		fmt.Printf("Twice %v is %v\n", v, v*2)
+	case string:
+		fmt.Printf("%q is %v bytes long\n", v, len(v))
+	default:
+		fmt.Printf("I don't know about type %T!\n", v)
+	}
+}
+
+func tryDispatcher() {
+	// do, rebuilt: the same two cases...
+	var d Dispatcher
+	Register(&d, func(v int) error {
+		fmt.Printf("Twice %v is %v\n", v, v*2)
+		return nil
+	})
+	Register(&d, func(v string) error {
+		fmt.Printf("%q is %v bytes long\n", v, len(v))
+		return nil
+	})
+	// ...plus interface cases that cover whole families of types.
+	Register(&d, func(a Abser) error {
+		fmt.Printf("%T has Abs() = %v\n", a, a.Abs())
+		return nil
+	})
+	Register(&d, func(err error) error {
+		fmt.Println("an error:", err)
+		return nil
+	})
+
+	do(21)
+	do("hello")
+	do(true)
+	for _, v := range []any{21, "hello", MyFloat(-math.Sqrt2), &Vertex{3, 4}, &MyError{time.Date(2009, 11, 10, 23, 0, 0, 0, time.UTC), "it didn't work"}} {
+		if err := d.Dispatch(v); err != nil {
+			fmt.Println(err)
+		}
+	}
+
+	// Unhandled types are errors, not messages.
+	for _, v := range []any{true, Vertex{3, 4}, nil} {
+		err := d.Dispatch(v)
+		var ue *UnhandledError
+		fmt.Println(err, errors.Is(err, ErrUnhandled), errors.As(err, &ue))
+	}
+}
+
+func tryPriorities() {
+	var d Dispatcher
+	Register(&d, func(a Abser) error {
+		fmt.Println("  Abser handler")
+		return nil
+	})
+	Register(&d, func(f MyFloat) error {
+		if f >= 0 {
+			fmt.Println("  MyFloat handler")
+			return nil
+		}
+		// Let someone else deal with negatives.
+		fmt.Println("  MyFloat handler passes")
+		return ErrNext
+	})
+	RegisterPriority(&d, 10, func(s fmt.Stringer) error {
+		fmt.Println("  Stringer handler, priority 10")
+		return ErrNext
+	})
+	RegisterPriority(&d, -1, func(v any) error {
+		fmt.Printf("  catch-all for %T\n", v)
+		return nil
+	})
+
+	// Exact beats interface at the same priority; ErrNext falls through.
+	fmt.Println("MyFloat(2):")
+	d.Dispatch(MyFloat(2))
+	fmt.Println("MyFloat(-2):")
+	d.Dispatch(MyFloat(-2))
+	// time.Duration is a Stringer, and nothing else matches but the catch-all.
+	fmt.Println("time.Second:")
+	d.Dispatch(time.Second)
+
+	// nil has no type, but the catch-all still takes it.
+	fmt.Println("nil:")
+	d.Dispatch(nil)
+
+	fmt.Println("all handlers for MyFloat(2):")
+	fmt.Println(d.DispatchAll(MyFloat(2)))
+}
+
+func main() {
+	// dispatch by type
+	tryDispatcher()
+
+	// priorities
+	tryPriorities()
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// recorder registers handlers that note their name when called.
+type recorder struct {
+	d     Dispatcher
+	calls []string
+}
+
+func record[T any](r *recorder, name string, priority int, result error) {
+	RegisterPriority(&r.d, priority, func(T) error {
+		r.calls = append(r.calls, name)
+		return result
+	})
+}
+
+func TestDispatchNil(t *testing.T) {
+	var r recorder
+	record[error](&r, "error", 0, nil)
+	record[fmt.Stringer](&r, "Stringer", 0, nil)
+	if err := r.d.Dispatch(nil); !errors.Is(err, ErrUnhandled) {
+		t.Errorf("nil without a catch-all: err = %v", err)
+	}
+
+	var got any = "not called"
+	RegisterPriority(&r.d, -1, func(v any) error {
+		got = v
+		return nil
+	})
+	if err := r.d.Dispatch(nil); err != nil || got != nil {
+		t.Errorf("nil with a catch-all: err = %v, handler got %v", err, got)
+	}
+	if len(r.calls) != 0 {
+		t.Errorf("typed handlers called for nil: %v", r.calls)
+	}
+}
+
+func TestDispatchOrder(t *testing.T) {
+	var r recorder
+	record[Abser](&r, "Abser", 0, nil)
+	record[MyFloat](&r, "MyFloat", 0, ErrNext)
+	record[fmt.Stringer](&r, "Stringer", 10, ErrNext)
+	record[any](&r, "any", -1, nil)
+
+	if err := r.d.Dispatch(MyFloat(-2)); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"MyFloat", "Abser"}; !slices.Equal(r.calls, want) {
+		t.Errorf("MyFloat calls = %v, want %v", r.calls, want)
+	}
+
+	r.calls = nil
+	r.d.DispatchAll(MyFloat(2))
+	if want := []string{"MyFloat", "Abser", "any"}; !slices.Equal(r.calls, want) {
+		t.Errorf("DispatchAll calls = %v, want %v", r.calls, want)
+	}
+}
+
+// Dispatch and DispatchAll agree on what counts as unhandled.
+func TestUnhandledConsistent(t *testing.T) {
+	boom := errors.New("boom")
+	tests := []struct {
+		name      string
+		results   []error
+		unhandled bool
+	}{
+		{"no handlers", nil, true},
+		{"every handler passes", []error{ErrNext, ErrNext}, true},
+		{"one takes it", []error{ErrNext, nil}, false},
+		{"one fails", []error{boom, ErrNext}, false},
+	}
+	for _, tt := range tests {
+		var r recorder
+		for i, res := range tt.results {
+			record[int](&r, fmt.Sprint(i), 0, res)
+		}
+		one, all := r.d.Dispatch(1), r.d.DispatchAll(1)
+		if errors.Is(one, ErrUnhandled) != tt.unhandled || errors.Is(all, ErrUnhandled) != tt.unhandled {
+			t.Errorf("%s: Dispatch = %v, DispatchAll = %v; want unhandled=%v", tt.name, one, all, tt.unhandled)
+		}
+		if slices.Contains(tt.results, boom) && (!errors.Is(one, boom) || !errors.Is(all, boom)) {
+			t.Errorf("%s: Dispatch = %v, DispatchAll = %v; want %v", tt.name, one, all, boom)
+		}
+	}
+}
@@ -0,0 +1,470 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+////// safe type assertions: typeAssertions (4-methods.go) shows that i.(float64) on a string panics,
+// which is why main has the call commented out. As never panics, and Convert goes further: it
+// turns numbers of one kind into another, parses strings, and checks that nothing is lost.
+
+// ConversionError says what was asked for and why it failed.
+type ConversionError struct {
+	Value any
+	To    reflect.Type
+	Err   error
+}
+
+func (e *ConversionError) Error() string {
+	return fmt.Sprintf("cannot convert %v (%T) to %v: %v", e.Value, e.Value, e.To, e.Err)
+}
+
+func (e *ConversionError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	ErrWrongType    = errors.New("wrong type")
+	ErrOverflow     = errors.New("value out of range")
+	ErrLossy        = errors.New("value would lose its fractional part")
+	ErrNaN          = errors.New("value is not a number")
+	ErrNil          = errors.New("value is nil")
+	ErrUnsupported  = errors.New("unsupported conversion")
+	ErrPathNotFound = errors.New("path not found")
+)
+
+func fail[T any](v any, err error) (T, error) {
+	var zero T
+	return zero, &ConversionError{Value: v, To: reflect.TypeFor[T](), Err: err}
+}
+
+// As is the comma-ok assertion v.(T) with an error that says what went wrong.
+func As[T any](v any) (T, error) {
+	if t, ok := v.(T); ok {
+		return t, nil
+	}
+	if v == nil {
+		return fail[T](v, ErrNil)
+	}
+	return fail[T](v, ErrWrongType)
+}
+
+// MustAs panics like v.(T) does, but with the descriptive error.
+func MustAs[T any](v any) T {
+	t, err := As[T](v)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+////// Convert: between numeric kinds with range checks, from and to strings with strconv, to
+// time.Duration from strings like "1m30s" or from a number of nanoseconds, and through pointers.
+
+func Convert[T any](v any) (T, error) {
+	if t, ok := v.(T); ok {
+		return t, nil
+	}
+	var zero T
+	target := reflect.TypeFor[T]()
+	out := reflect.New(target).Elem()
+	if err := convertInto(out, reflect.ValueOf(v)); err != nil {
+		if ce := (*ConversionError)(nil); errors.As(err, &ce) {
+			return zero, err
+		}
+		return fail[T](v, err)
+	}
+	return out.Interface().(T), nil
+}
+
+var durationType = reflect.TypeFor[time.Duration]()
+
+// convertInto stores src, converted, into dst.
+func convertInto(dst, src reflect.Value) error {
+	// Unwrap interfaces and follow pointers on the way in.
+	for src.IsValid() && (src.Kind() == reflect.Interface || src.Kind() == reflect.Pointer) {
+		if src.IsNil() {
+			return ErrNil
+		}
+		src = src.Elem()
+	}
+	if !src.IsValid() {
+		return ErrNil
+	}
+
+	// A pointer target: convert to the element type and point at it.
+	if dst.Kind() == reflect.Pointer {
+		elem := reflect.New(dst.Type().Elem())
+		if err := convertInto(elem.Elem(), src); err != nil {
+			return err
+		}
+		dst.Set(elem)
+		return nil
+	}
+
+	if src.Type().AssignableTo(dst.Type()) {
+		dst.Set(src)
+		return nil
+	}
+
+	if dst.Type() == durationType {
+		return toDuration(dst, src)
+	}
+
+	switch dst.Kind() {
+	case reflect.String:
+		return toString(dst, src)
+	case reflect.Bool:
+		return toBool(dst, src)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		// Integers, and strings like "0x1f" or "1_000", convert exactly without a float64 in
+		// between, which can't hold every int64.
+		if i, ok := asInt64(src); ok {
+			if dst.OverflowInt(i) {
+				return ErrOverflow
+			}
+			dst.SetInt(i)
+			return nil
+		}
+		f, err := wholeNumber(src)
+		if err != nil {
+			return err
+		}
+		if f < math.MinInt64 || f >= math.MaxInt64 || dst.OverflowInt(int64(f)) {
+			return ErrOverflow
+		}
+		dst.SetInt(int64(f))
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if u, ok := asUint64(src); ok {
+			if dst.OverflowUint(u) {
+				return ErrOverflow
+			}
+			dst.SetUint(u)
+			return nil
+		}
+		f, err := wholeNumber(src)
+		if err != nil {
+			return err
+		}
+		if f < 0 || f >= math.MaxUint64 || dst.OverflowUint(uint64(f)) {
+			return ErrOverflow
+		}
+		dst.SetUint(uint64(f))
+		return nil
+	case reflect.Float32, reflect.Float64:
+		f, err := number(src)
+		if err != nil {
+			return err
+		}
+		// NaN and the infinities are floats too; only finite values can be out of range.
+		if !math.IsInf(f, 0) && dst.OverflowFloat(f) {
+			return ErrOverflow
+		}
+		dst.SetFloat(f)
+		return nil
+	}
+	return ErrUnsupported
+}
+
+// number reads any numeric or numeric-string value as a float64, which may be NaN or infinite.
+// Large integers lose precision on the way, so integer targets try asInt64/asUint64 first.
+func number(src reflect.Value) (float64, error) {
+	switch src.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(src.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(src.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return src.Float(), nil
+	case reflect.Bool:
+		if src.Bool() {
+			return 1, nil
+		}
+		return 0, nil
+	case reflect.String:
+		f, err := strconv.ParseFloat(strings.TrimSpace(src.String()), 64)
+		if err == nil {
+			return f, nil
+		}
+		// Base prefixes like "0x1f" are only for integers.
+		if i, ok := asInt64(src); ok {
+			return float64(i), nil
+		}
+		if u, ok := asUint64(src); ok {
+			return float64(u), nil
+		}
+		return 0, err
+	}
+	return 0, ErrUnsupported
+}
+
+// wholeNumber is number for integer targets: NaN, infinities and fractions have no integer
+// value.
+func wholeNumber(src reflect.Value) (float64, error) {
+	f, err := number(src)
+	switch {
+	case err != nil:
+		return 0, err
+	case math.IsNaN(f):
+		return 0, ErrNaN
+	case math.IsInf(f, 0):
+		return 0, ErrOverflow
+	case f != math.Trunc(f):
+		return 0, ErrLossy
+	}
+	return f, nil
+}
+
+func asInt64(src reflect.Value) (int64, bool) {
+	switch src.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return src.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u := src.Uint()
+		return int64(u), u <= math.MaxInt64
+	case reflect.String:
+		i, err := strconv.ParseInt(strings.TrimSpace(src.String()), 0, 64)
+		return i, err == nil
+	}
+	return 0, false
+}
+
+func asUint64(src reflect.Value) (uint64, bool) {
+	switch src.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i := src.Int()
+		return uint64(i), i >= 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return src.Uint(), true
+	case reflect.String:
+		u, err := strconv.ParseUint(strings.TrimSpace(src.String()), 0, 64)
+		return u, err == nil
+	}
+	return 0, false
+}
+
+func toString(dst, src reflect.Value) error {
+	switch src.Kind() {
+	case reflect.String:
+		dst.SetString(src.String())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if src.Type() == durationType {
+			dst.SetString(time.Duration(src.Int()).String())
+			return nil
+		}
+		dst.SetString(strconv.FormatInt(src.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		dst.SetString(strconv.FormatUint(src.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		dst.SetString(strconv.FormatFloat(src.Float(), 'g', -1, src.Type().Bits()))
+	case reflect.Bool:
+		dst.SetString(strconv.FormatBool(src.Bool()))
+	default:
+		return ErrUnsupported
+	}
+	return nil
+}
+
+func toBool(dst, src reflect.Value) error {
+	if src.Kind() == reflect.String {
+		b, err := strconv.ParseBool(strings.TrimSpace(src.String()))
+		if err != nil {
+			return err
+		}
+		dst.SetBool(b)
+		return nil
+	}
+	f, err := number(src)
+	if err != nil {
+		return err
+	}
+	if math.IsNaN(f) {
+		return ErrNaN
+	}
+	dst.SetBool(f != 0)
+	return nil
+}
+
+// toDuration accepts "1m30s", a plain number of seconds as a string ("2.5"), or any number,
+// which counts nanoseconds like time.Duration itself.
+func toDuration(dst, src reflect.Value) error {
+	if src.Kind() == reflect.String {
+		s := strings.TrimSpace(src.String())
+		if d, err := time.ParseDuration(s); err == nil {
+			dst.SetInt(int64(d))
+			return nil
+		}
+		secs, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("%q is neither a duration nor a number of seconds", s)
+		}
+		if math.IsNaN(secs) {
+			return ErrNaN
+		}
+		if math.Abs(secs) > math.MaxInt64/float64(time.Second) {
+			return ErrOverflow
+		}
+		dst.SetInt(int64(secs * float64(time.Second)))
+		return nil
+	}
+	var ns int64
+	if err := convertInto(reflect.ValueOf(&ns).Elem(), src); err != nil {
+		return err
+	}
+	dst.SetInt(ns)
+	return nil
+}
+
+////// paths: decoded JSON is a tree of map[string]any and []any. Get walks a path like
+// "a.b[2].c" through it and converts whatever it finds.
+
+// Get looks up path in m and converts the value to T.
+func Get[T any](m map[string]any, path string) (T, error) {
+	v, err := lookup(m, path)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	t, err := Convert[T](v)
+	if err != nil {
+		var zero T
+		return zero, fmt.Errorf("%s: %w", path, err)
+	}
+	return t, nil
+}
+
+// GetOr returns def when the path is missing or won't convert.
+func GetOr[T any](m map[string]any, path string, def T) T {
+	if v, err := Get[T](m, path); err == nil {
+		return v
+	}
+	return def
+}
+
+func lookup(m map[string]any, path string) (any, error) {
+	var cur any = m
+	walked := ""
+	for _, part := range strings.Split(path, ".") {
+		// "b[2][0]" is the key "b" followed by two indexes. Every part starts with a key, so
+		// "", "a..b" and "a.[0]" are mistakes rather than ways to name the whole object.
+		key, rest, _ := strings.Cut(part, "[")
+		if key == "" {
+			return nil, fmt.Errorf("%q: empty segment in path", path)
+		}
+		walked = strings.TrimPrefix(walked+"."+key, ".")
+		obj, ok := cur.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s: %w: not an object", walked, ErrPathNotFound)
+		}
+		if cur, ok = obj[key]; !ok {
+			return nil, fmt.Errorf("%s: %w", walked, ErrPathNotFound)
+		}
+		for rest != "" {
+			idx, after, ok := strings.Cut(rest, "]")
+			if !ok {
+				return nil, fmt.Errorf("%s: unclosed '[' in path", path)
+			}
+			i, err := strconv.Atoi(idx)
+			if err != nil {
+				return nil, fmt.Errorf("%s: bad index %q", path, idx)
+			}
+			walked += "[" + idx + "]"
+			arr, ok := cur.([]any)
+			if !ok {
+				return nil, fmt.Errorf("%s: %w: not an array", walked, ErrPathNotFound)
+			}
+			if i < 0 || i >= len(arr) {
+				return nil, fmt.Errorf("%s: %w: index out of range (len %d)", walked, ErrPathNotFound, len(arr))
+			}
+			cur = arr[i]
+			rest = strings.TrimPrefix(after, "[")
+		}
+	}
+	return cur, nil
+}
+
+func typeAssertionsSafely() {
+	var i interface{} = "hello"
+
+	s, err := As[string](i)
+	fmt.Println(s, err)
+
+	// This is the line that panics in typeAssertions.
+	f, err := As[float64](i)
+	fmt.Println(f, err)
+	fmt.Println(errors.Is(err, ErrWrongType))
+
+	func() {
+		defer func() { fmt.Println("recovered:", recover()) }()
+		MustAs[float64](i)
+	}()
+}
+
+func tryConvert() {
+	fmt.Println(Convert[int]("42"))
+	fmt.Println(Convert[int8](300))
+	fmt.Println(Convert[uint](-1))
+	fmt.Println(Convert[int](3.5))
+	fmt.Println(Convert[int](3.0))
+	fmt.Println(Convert[int]("0x1f"))
+	fmt.Println(Convert[int](math.NaN()))
+	fmt.Println(Convert[float32](1e40))
+	fmt.Println(Convert[int64](uint64(math.MaxUint64)))
+	fmt.Println(Convert[uint64]("18446744073709551615"))
+	fmt.Println(Convert[string](math.Pi))
+	fmt.Println(Convert[bool]("true"))
+	fmt.Println(Convert[time.Duration]("1m30s"))
+	fmt.Println(Convert[time.Duration]("2.5"))
+	fmt.Println(Convert[time.Duration](int64(1500)))
+	fmt.Println(Convert[string](90 * time.Second))
+
+	// Through pointers in both directions.
+	n := 7
+	fmt.Println(Convert[float64](&n))
+	p, err := Convert[*int]("12")
+	fmt.Println(*p, err)
+	var nilPtr *int
+	_, err = Convert[int](nilPtr)
+	fmt.Println(err)
+	_, err = Convert[int]([]int{1})
+	fmt.Println(err, errors.Is(err, ErrUnsupported))
+}
+
+func tryPaths() {
+	var m map[string]any
+	json.Unmarshal([]byte(`{
+		"a": {"b": [10, 20, {"c": "300"}, [1, [2, 3]]]},
+		"timeout": "1m30s",
+		"debug": "true",
+		"ratio": 0.75
+	}`), &m)
+
+	fmt.Println(Get[int](m, "a.b[2].c"))
+	fmt.Println(Get[int](m, "a.b[0]"))
+	fmt.Println(Get[int](m, "a.b[3][1][0]"))
+	fmt.Println(Get[time.Duration](m, "timeout"))
+	fmt.Println(Get[bool](m, "debug"))
+	fmt.Println(Get[int](m, "ratio"))
+	fmt.Println(Get[int](m, "a.b[7]"))
+	fmt.Println(Get[int](m, "a.x.y"))
+	fmt.Println(GetOr(m, "retries", 3))
+}
+
+func main() {
+	// safe type assertions
+	typeAssertionsSafely()
+
+	// conversions
+	tryConvert()
+
+	// paths into maps
+	tryPaths()
+}
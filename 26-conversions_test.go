@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestConvertToInt(t *testing.T) {
+	tests := []struct {
+		in      any
+		want    int64
+		wantErr error
+	}{
+		{"42", 42, nil},
+		{" -7 ", -7, nil},
+		{"0x1f", 31, nil},
+		{"0b101", 5, nil},
+		{"0o17", 15, nil},
+		{"1_000", 1000, nil},
+		{"1e3", 1000, nil},
+		{3.0, 3, nil},
+		{true, 1, nil},
+		{uint64(math.MaxInt64), math.MaxInt64, nil},
+		{"9223372036854775807", math.MaxInt64, nil},
+		{3.5, 0, ErrLossy},
+		{"2.5", 0, ErrLossy},
+		{math.NaN(), 0, ErrNaN},
+		{"NaN", 0, ErrNaN},
+		{math.Inf(1), 0, ErrOverflow},
+		{uint64(math.MaxUint64), 0, ErrOverflow},
+		{"0xffffffffffffffff", 0, ErrOverflow},
+		{1e19, 0, ErrOverflow},
+		{[]int{1}, 0, ErrUnsupported},
+	}
+	for _, tt := range tests {
+		got, err := Convert[int64](tt.in)
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Convert[int64](%#v) error = %v, want %v", tt.in, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Convert[int64](%#v) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestConvertToUint(t *testing.T) {
+	if got, err := Convert[uint8]("0xff"); got != 255 || err != nil {
+		t.Errorf(`Convert[uint8]("0xff") = %v, %v`, got, err)
+	}
+	if got, err := Convert[uint64]("0xffffffffffffffff"); got != math.MaxUint64 || err != nil {
+		t.Errorf(`Convert[uint64]("0xffffffffffffffff") = %v, %v`, got, err)
+	}
+	if _, err := Convert[uint8]("0x100"); !errors.Is(err, ErrOverflow) {
+		t.Errorf(`Convert[uint8]("0x100") error = %v`, err)
+	}
+	if _, err := Convert[uint](-1); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Convert[uint](-1) error = %v", err)
+	}
+	if _, err := Convert[uint](float32(math.NaN())); !errors.Is(err, ErrNaN) {
+		t.Errorf("Convert[uint](NaN) error = %v", err)
+	}
+}
+
+func TestConvertToFloat(t *testing.T) {
+	if got, err := Convert[float64]("0x10"); got != 16 || err != nil {
+		t.Errorf(`Convert[float64]("0x10") = %v, %v`, got, err)
+	}
+	if got, err := Convert[float64](float32(math.NaN())); !math.IsNaN(got) || err != nil {
+		t.Errorf("Convert[float64](NaN) = %v, %v", got, err)
+	}
+	if got, err := Convert[float32](math.Inf(-1)); !math.IsInf(float64(got), -1) || err != nil {
+		t.Errorf("Convert[float32](-Inf) = %v, %v", got, err)
+	}
+	if _, err := Convert[float32](1e40); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Convert[float32](1e40) error = %v", err)
+	}
+}
+
+func TestConvertNaNElsewhere(t *testing.T) {
+	if _, err := Convert[bool](math.NaN()); !errors.Is(err, ErrNaN) {
+		t.Errorf("Convert[bool](NaN) error = %v", err)
+	}
+	if _, err := Convert[time.Duration]("NaN"); !errors.Is(err, ErrNaN) {
+		t.Errorf(`Convert[time.Duration]("NaN") error = %v`, err)
+	}
+}
+
+func TestGet(t *testing.T) {
+	var m map[string]any
+	err := json.Unmarshal([]byte(`{
+		"a": {"b": [10, 20, {"c": "300"}, [1, [2, 3]]]},
+		"timeout": "1m30s",
+		"debug": "true",
+		"ratio": 0.75,
+		"name": "gopher"
+	}`), &m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ints := []struct {
+		path    string
+		want    int
+		wantErr error
+	}{
+		{"a.b[0]", 10, nil},
+		{"a.b[2].c", 300, nil},
+		{"a.b[3][1][0]", 2, nil},
+		{"a.b[3][1][1]", 3, nil},
+		// Missing keys and indexes.
+		{"missing", 0, ErrPathNotFound},
+		{"a.x.y", 0, ErrPathNotFound},
+		{"a.b[7]", 0, ErrPathNotFound},
+		{"a.b[-1]", 0, ErrPathNotFound},
+		// The wrong kind of node on the way.
+		{"a[0]", 0, ErrPathNotFound},
+		{"a.b.c", 0, ErrPathNotFound},
+		{"name.first", 0, ErrPathNotFound},
+		// Found, but won't convert.
+		{"name", 0, strconv.ErrSyntax},
+		{"ratio", 0, ErrLossy},
+		{"a.b", 0, ErrUnsupported},
+	}
+	for _, tt := range ints {
+		got, err := Get[int](m, tt.path)
+		if !errors.Is(err, tt.wantErr) || got != tt.want {
+			t.Errorf("Get[int](%q) = %d, %v; want %d, %v", tt.path, got, err, tt.want, tt.wantErr)
+		}
+	}
+
+	// Malformed paths are errors, not lookups of something else.
+	for _, path := range []string{"", ".", "a..b", "a.", ".a", "a.[0]", "a.b[0", "a.b[x]"} {
+		if v, err := Get[any](m, path); err == nil {
+			t.Errorf("Get(%q) = %v, want an error", path, v)
+		}
+	}
+
+	if d, err := Get[time.Duration](m, "timeout"); err != nil || d != 90*time.Second {
+		t.Errorf("Get[Duration](timeout) = %v, %v", d, err)
+	}
+	if b, err := Get[bool](m, "debug"); err != nil || !b {
+		t.Errorf("Get[bool](debug) = %v, %v", b, err)
+	}
+	if s, err := Get[string](m, "a.b[1]"); err != nil || s != "20" {
+		t.Errorf("Get[string](a.b[1]) = %q, %v", s, err)
+	}
+	if got := GetOr(m, "retries", 3); got != 3 {
+		t.Errorf("GetOr(retries) = %d, want 3", got)
+	}
+	if got := GetOr(m, "a.b[1]", 3); got != 20 {
+		t.Errorf("GetOr(a.b[1]) = %d, want 20", got)
+	}
+}
@@ -0,0 +1,584 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+////// deep equality and diffs: checking a result by comparing fmt.Println(m2) with a string breaks
+// as soon as map order or float formatting changes, and reflect.DeepEqual only says yes or no.
+// Diff walks both values with reflect and lists every place they differ, with a path to it:
+//
+//	.next.val: "a" != "b"
+//	["Google"].Lat: 37.4 != 37.5
+
+// Difference is one place where the values disagree. A and B are printed forms; "<missing>"
+// stands for an element or key that only one side has.
+type Difference struct {
+	Path string
+	A, B string
+}
+
+func (d Difference) String() string {
+	path := d.Path
+	if path == "" {
+		path = "(root)"
+	}
+	return fmt.Sprintf("%s: %s != %s", path, d.A, d.B)
+}
+
+// Options changes what counts as equal. The zero value compares exactly.
+type Options struct {
+	// FloatTolerance is the largest absolute difference at which two floats are still equal.
+	FloatTolerance float64
+	// Ignore lists paths (".next.val", `["Google"]`) or bare field names ("When") to skip.
+	Ignore []string
+	// IgnoreUnexported skips unexported struct fields.
+	IgnoreUnexported bool
+}
+
+// Diff compares a and b exactly.
+func Diff(a, b any) []Difference {
+	var o Options
+	return o.Diff(a, b)
+}
+
+// Equal reports whether Diff finds nothing.
+func Equal(a, b any) bool {
+	return len(Diff(a, b)) == 0
+}
+
+func (o Options) Diff(a, b any) []Difference {
+	d := &differ{o: o, visited: make(map[visitPair]bool)}
+	d.compare("", reflect.ValueOf(a), reflect.ValueOf(b))
+	return d.diffs
+}
+
+func (o Options) Equal(a, b any) bool {
+	return len(o.Diff(a, b)) == 0
+}
+
+// visitPair is a pair of pointers already being compared; meeting it again means a cycle, and
+// the values are equal along it unless something else differs.
+type visitPair struct {
+	a, b uintptr
+	typ  reflect.Type
+}
+
+type differ struct {
+	o       Options
+	diffs   []Difference
+	visited map[visitPair]bool
+}
+
+func (d *differ) report(path string, a, b string) {
+	d.diffs = append(d.diffs, Difference{path, a, b})
+}
+
+func (d *differ) ignored(path, field string) bool {
+	return slices.Contains(d.o.Ignore, path) || (field != "" && slices.Contains(d.o.Ignore, field))
+}
+
+const missing = "<missing>"
+
+func (d *differ) compare(path string, a, b reflect.Value) {
+	if !a.IsValid() || !b.IsValid() {
+		if a.IsValid() != b.IsValid() {
+			d.report(path, show(a), show(b))
+		}
+		return
+	}
+	if a.Type() != b.Type() {
+		d.report(path, show(a)+" ("+a.Type().String()+")", show(b)+" ("+b.Type().String()+")")
+		return
+	}
+
+	// Nils first: an Equal method with a pointer receiver need not expect a nil one.
+	if k := a.Kind(); (k == reflect.Pointer || k == reflect.Interface) && (a.IsNil() || b.IsNil()) {
+		if a.IsNil() != b.IsNil() {
+			d.report(path, show(a), show(b))
+		}
+		return
+	}
+
+	// Types like time.Time define their own equality; two instants in different zones are the
+	// same time even though their fields differ.
+	if eq, ok := equalMethod(a, b); ok {
+		if !eq {
+			d.report(path, show(a), show(b))
+		}
+		return
+	}
+
+	switch a.Kind() {
+	case reflect.Float32, reflect.Float64:
+		x, y := a.Float(), b.Float()
+		// NaN never equals itself, but two NaNs in the same place are not a difference.
+		if math.IsNaN(x) && math.IsNaN(y) {
+			return
+		}
+		if x != y && !(math.Abs(x-y) <= d.o.FloatTolerance) {
+			d.report(path, show(a), show(b))
+		}
+	case reflect.Complex64, reflect.Complex128:
+		if diff := a.Complex() - b.Complex(); a.Complex() != b.Complex() &&
+			!(math.Hypot(real(diff), imag(diff)) <= d.o.FloatTolerance) {
+			d.report(path, show(a), show(b))
+		}
+	case reflect.Pointer, reflect.Interface:
+		if a.Kind() == reflect.Pointer {
+			if !d.enter(a, b) {
+				return
+			}
+		}
+		// Paths go straight through pointers: .next.val, not .next.*.val.
+		d.compare(path, a.Elem(), b.Elem())
+	case reflect.Struct:
+		t := a.Type()
+		for i := range t.NumField() {
+			f := t.Field(i)
+			p := path + "." + f.Name
+			if (!f.IsExported() && d.o.IgnoreUnexported) || d.ignored(p, f.Name) {
+				continue
+			}
+			d.compare(p, a.Field(i), b.Field(i))
+		}
+	case reflect.Slice:
+		if a.IsNil() != b.IsNil() {
+			d.report(path, show(a), show(b))
+			return
+		}
+		if !d.enter(a, b) {
+			return
+		}
+		d.list(path, a, b)
+	case reflect.Array:
+		d.list(path, a, b)
+	case reflect.Map:
+		if a.IsNil() != b.IsNil() {
+			d.report(path, show(a), show(b))
+			return
+		}
+		if !d.enter(a, b) {
+			return
+		}
+		d.mapValues(path, a, b)
+	case reflect.Func:
+		// Funcs can only be compared with nil.
+		if !a.IsNil() || !b.IsNil() {
+			d.report(path, show(a), show(b))
+		}
+	default:
+		if !a.Equal(b) {
+			d.report(path, show(a), show(b))
+		}
+	}
+}
+
+// enter records a pair of pointer-like values; it reports false when the pair is already being
+// compared further up, or when both are the same memory and so trivially equal.
+func (d *differ) enter(a, b reflect.Value) bool {
+	if a.Pointer() == b.Pointer() && (a.Kind() != reflect.Slice || a.Len() == b.Len()) {
+		return false
+	}
+	key := visitPair{a.Pointer(), b.Pointer(), a.Type()}
+	if d.visited[key] {
+		return false
+	}
+	d.visited[key] = true
+	return true
+}
+
+func (d *differ) list(path string, a, b reflect.Value) {
+	for i := range max(a.Len(), b.Len()) {
+		p := path + "[" + strconv.Itoa(i) + "]"
+		if d.ignored(p, "") {
+			continue
+		}
+		switch {
+		case i >= a.Len():
+			d.report(p, missing, show(b.Index(i)))
+		case i >= b.Len():
+			d.report(p, show(a.Index(i)), missing)
+		default:
+			d.compare(p, a.Index(i), b.Index(i))
+		}
+	}
+}
+
+func (d *differ) mapValues(path string, a, b reflect.Value) {
+	// Every key from either side, in a stable order so the output is too.
+	keys := a.MapKeys()
+	for _, k := range b.MapKeys() {
+		if !a.MapIndex(k).IsValid() {
+			keys = append(keys, k)
+		}
+	}
+	slices.SortFunc(keys, compareKeys)
+	for _, k := range keys {
+		p := path + "[" + show(k) + "]"
+		if d.ignored(p, "") {
+			continue
+		}
+		x, y := a.MapIndex(k), b.MapIndex(k)
+		switch {
+		case !x.IsValid():
+			d.report(p, missing, show(y))
+		case !y.IsValid():
+			d.report(p, show(x), missing)
+		default:
+			d.compare(p, x, y)
+		}
+	}
+}
+
+// equalMethod calls a.Equal(b) for types that have func (T) Equal(T) bool. For pointers and
+// interfaces, compare has already made sure neither is nil.
+func equalMethod(a, b reflect.Value) (eq, ok bool) {
+	if !a.CanInterface() || !b.CanInterface() {
+		return false, false
+	}
+	m, found := a.Type().MethodByName("Equal")
+	if !found || m.Type.NumIn() != 2 || m.Type.In(1) != a.Type() ||
+		m.Type.NumOut() != 1 || m.Type.Out(0).Kind() != reflect.Bool {
+		return false, false
+	}
+	return a.MethodByName("Equal").Call([]reflect.Value{b})[0].Bool(), true
+}
+
+// compareKeys orders numbers numerically and strings alphabetically; anything else by its %v.
+func compareKeys(a, b reflect.Value) int {
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp.Compare(a.Int(), b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return cmp.Compare(a.Uint(), b.Uint())
+	case reflect.Float32, reflect.Float64:
+		return cmp.Compare(a.Float(), b.Float())
+	case reflect.String:
+		return cmp.Compare(a.String(), b.String())
+	}
+	return cmp.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+// show prints a value for a difference: strings quoted, pointers as &{...}, nil as nil.
+func show(v reflect.Value) string {
+	if !v.IsValid() {
+		return "nil"
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return strconv.Quote(v.String())
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		if v.IsNil() {
+			return "nil"
+		}
+	}
+	if v.CanInterface() {
+		if s, ok := v.Interface().(fmt.Stringer); ok {
+			return s.String()
+		}
+	}
+	// fmt prints a reflect.Value as the value it holds, unexported fields included.
+	return fmt.Sprintf("%v", v)
+}
+
+////// unified diff: for a test failure, the whole value laid out one field per line with - and
+// + marking what changed reads better than a list of paths. Both sides are rendered the same
+// way, then the lines are diffed with a longest common subsequence.
+
+// Unified renders a and b line by line and returns a unified diff with context lines around
+// each change, or "" if the renderings are the same.
+func Unified(a, b any, context int) string {
+	la, lb := render(a), render(b)
+	ops := diffLines(la, lb)
+	if !slices.ContainsFunc(ops, func(o lineOp) bool { return o.kind != ' ' }) {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("--- a\n+++ b\n")
+	for i := 0; i < len(ops); {
+		// Find the next change and the run of changes near it, then print it as a hunk.
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		start := max(0, i-context)
+		end := i
+		for j := i; j < len(ops); j++ {
+			if ops[j].kind != ' ' {
+				end = j
+			} else if j-end > 2*context {
+				break
+			}
+		}
+		end = min(len(ops), end+context+1)
+
+		hunk := ops[start:end]
+		aStart, bStart := ops[start].a, ops[start].b
+		aLen, bLen := 0, 0
+		for _, o := range hunk {
+			if o.kind != '+' {
+				aLen++
+			}
+			if o.kind != '-' {
+				bLen++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", aStart+1, aLen, bStart+1, bLen)
+		for _, o := range hunk {
+			fmt.Fprintf(&sb, "%c%s\n", o.kind, o.text)
+		}
+		i = end
+	}
+	return sb.String()
+}
+
+// lineOp is one line of the diff: ' ' kept, '-' only in a, '+' only in b. a and b are the
+// line numbers reached on each side when it starts.
+type lineOp struct {
+	kind rune
+	text string
+	a, b int
+}
+
+func diffLines(a, b []string) []lineOp {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var ops []lineOp
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, lineOp{' ', a[i], i, j})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			// Deletions before insertions, as diff(1) prints them.
+			ops = append(ops, lineOp{'-', a[i], i, j})
+			i++
+		default:
+			ops = append(ops, lineOp{'+', b[j], i, j})
+			j++
+		}
+	}
+	return ops
+}
+
+// render lays a value out one field, element or key per line.
+func render(v any) []string {
+	r := &renderer{visiting: make(map[uintptr]bool)}
+	r.value(reflect.ValueOf(v), "", 0)
+	return r.lines
+}
+
+type renderer struct {
+	lines    []string
+	visiting map[uintptr]bool
+}
+
+func (r *renderer) add(depth int, s string) {
+	r.lines = append(r.lines, strings.Repeat("  ", depth)+s)
+}
+
+// value writes v, prefixed by label ("Lat: ", `"Google": `), at the given depth.
+func (r *renderer) value(v reflect.Value, label string, depth int) {
+	if !v.IsValid() {
+		r.add(depth, label+"nil")
+		return
+	}
+	if v.CanInterface() {
+		if _, ok := v.Interface().(fmt.Stringer); ok && v.Kind() != reflect.Pointer {
+			r.add(depth, label+show(v))
+			return
+		}
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			r.add(depth, label+"nil")
+			return
+		}
+		if v.Kind() == reflect.Pointer {
+			if r.visiting[v.Pointer()] {
+				r.add(depth, label+"<cycle>")
+				return
+			}
+			r.visiting[v.Pointer()] = true
+			defer delete(r.visiting, v.Pointer())
+			label += "&"
+		}
+		r.value(v.Elem(), label, depth)
+	case reflect.Struct:
+		r.add(depth, label+v.Type().String()+"{")
+		for i := range v.NumField() {
+			r.value(v.Field(i), v.Type().Field(i).Name+": ", depth+1)
+		}
+		r.add(depth, "}")
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			r.add(depth, label+"nil")
+			return
+		}
+		r.add(depth, label+v.Type().String()+"{")
+		for i := range v.Len() {
+			r.value(v.Index(i), "", depth+1)
+		}
+		r.add(depth, "}")
+	case reflect.Map:
+		if v.IsNil() {
+			r.add(depth, label+"nil")
+			return
+		}
+		r.add(depth, label+v.Type().String()+"{")
+		keys := v.MapKeys()
+		slices.SortFunc(keys, compareKeys)
+		for _, k := range keys {
+			r.value(v.MapIndex(k), show(k)+": ", depth+1)
+		}
+		r.add(depth, "}")
+	default:
+		r.add(depth, label+show(v))
+	}
+}
+
+// The lesson types.
+type Vertex struct {
+	X, Y float64
+}
+
+type Vertex2 struct {
+	Lat, Long float64
+}
+
+type List[T any] struct {
+	next *List[T]
+	val  T
+}
+
+type Event struct {
+	Name  string
+	When  time.Time
+	Where Vertex2
+	Tags  []string
+}
+
+func tryDiff() {
+	// The list from 5-generics.go.
+	a := &List[string]{val: "first", next: &List[string]{val: "a"}}
+	b := &List[string]{val: "first", next: &List[string]{val: "b"}}
+	for _, d := range Diff(a, b) {
+		fmt.Println(d)
+	}
+	b.next.next = &List[string]{val: "third"}
+	for _, d := range Diff(a, b) {
+		fmt.Println(d)
+	}
+
+	// The maps from 3-more-types.go.
+	m2 := map[string]Vertex2{
+		"Bell Labs": {40.68433, -74.39967},
+		"Google":    {37.4, -122.08408},
+	}
+	m3 := map[string]Vertex2{
+		"Bell Labs": {40.68433, -74.39967},
+		"Google":    {37.5, -122.08408},
+		"Gophers":   {1, 2},
+	}
+	for _, d := range Diff(m2, m3) {
+		fmt.Println(d)
+	}
+
+	// Top-level differences, types and nils.
+	fmt.Println(Diff(Vertex{1, 2}, Vertex{1, 2}), Equal(Vertex{1, 2}, Vertex{1, 2}))
+	fmt.Println(Diff(1, "1"))
+	fmt.Println(Diff([]int(nil), []int{}))
+	fmt.Println(Diff([]int{1, 2, 3}, []int{1, 3}))
+
+	// Cycles end instead of recursing forever.
+	c1 := &List[int]{val: 1}
+	c1.next = c1
+	c2 := &List[int]{val: 1}
+	c2.next = c2
+	fmt.Println(Equal(c1, c2))
+	c2.next = &List[int]{val: 2, next: c2}
+	fmt.Println(Diff(c1, c2))
+}
+
+func tryOptions() {
+	// 0.1 + 0.2 is not 0.3, but is within any sensible tolerance. As constants they would be
+	// added exactly at compile time and compare equal, so the sum has to happen in float64.
+	x, y, z := 0.1, 0.2, 0.3
+	fmt.Println(Diff(x+y, z))
+	loose := Options{FloatTolerance: 1e-9}
+	fmt.Println(loose.Diff(x+y, z), loose.Equal(Vertex{x + y, 1}, Vertex{z, 1}))
+
+	// The same instant in two zones is Equal by time.Time's own rule.
+	utc := time.Date(2009, 11, 10, 23, 0, 0, 0, time.UTC)
+	est := utc.In(time.FixedZone("EST", -5*60*60))
+	e1 := Event{"launch", utc, Vertex2{37.42202, -122.08408}, []string{"go"}}
+	e2 := Event{"launch", est, Vertex2{37.42202, -122.08408}, []string{"go"}}
+	fmt.Println(Diff(e1, e2))
+
+	// Ignoring by field name or by path.
+	e2.When = e2.When.Add(time.Hour)
+	e2.Where.Lat = 40
+	e2.Tags = append(e2.Tags, "gopher")
+	for _, d := range Diff(e1, e2) {
+		fmt.Println(d)
+	}
+	skip := Options{Ignore: []string{"When", ".Where.Lat", ".Tags[1]"}}
+	fmt.Println(skip.Diff(e1, e2))
+
+	// Unexported fields: the lists differ only in val.
+	quiet := Options{IgnoreUnexported: true}
+	fmt.Println(quiet.Equal(&List[int]{val: 1}, &List[int]{val: 2}))
+}
+
+func tryUnified() {
+	m2 := map[string]Vertex2{
+		"Bell Labs": {40.68433, -74.39967},
+		"Google":    {37.42202, -122.08408},
+	}
+	m3 := map[string]Vertex2{
+		"Bell Labs": {40.68433, -74.39967},
+		"Google":    {37.5, -122.08408},
+		"Gophers":   {1, 2},
+	}
+	fmt.Print(Unified(m2, m3, 1))
+
+	a := &List[string]{val: "first", next: &List[string]{val: "second"}}
+	b := &List[string]{val: "first", next: &List[string]{val: "2nd"}}
+	fmt.Print(Unified(a, b, 3))
+	fmt.Printf("%q\n", Unified(a, a, 3))
+}
+
+func main() {
+	// structural diff
+	tryDiff()
+
+	// tolerances and ignored fields
+	tryOptions()
+
+	// unified view
+	tryUnified()
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestFloatTolerance(t *testing.T) {
+	// Variables, not constants: constant 0.1+0.2 is exactly 0.3.
+	x, y, z := 0.1, 0.2, 0.3
+	if x+y == z {
+		t.Fatal("0.1+0.2 == 0.3 in float64")
+	}
+	loose := Options{FloatTolerance: 1e-9}
+	tests := []struct {
+		name  string
+		o     Options
+		a, b  any
+		equal bool
+	}{
+		{"exact floats", Options{}, x + y, z, false},
+		{"loose floats", loose, x + y, z, true},
+		{"exact vertices", Options{}, Vertex{x + y, 1}, Vertex{z, 1}, false},
+		{"loose vertices", loose, Vertex{x + y, 1}, Vertex{z, 1}, true},
+		{"loose but too far", loose, Vertex{z, 1}, Vertex{z + 1e-6, 1}, false},
+		{"float32", loose, float32(x) + float32(y), float32(z), true},
+	}
+	for _, tt := range tests {
+		diffs := tt.o.Diff(tt.a, tt.b)
+		if (len(diffs) == 0) != tt.equal {
+			t.Errorf("%s: Diff(%v, %v) = %v, want equal=%v", tt.name, tt.a, tt.b, diffs, tt.equal)
+		}
+	}
+}
+
+func TestDiffCycles(t *testing.T) {
+	c1 := &List[int]{val: 1}
+	c1.next = c1
+	c2 := &List[int]{val: 1}
+	c2.next = c2
+	if !Equal(c1, c2) {
+		t.Errorf("equal cycles: %v", Diff(c1, c2))
+	}
+	c2.next = &List[int]{val: 2, next: c2}
+	if diffs := Diff(c1, c2); len(diffs) != 1 || diffs[0].Path != ".next.val" {
+		t.Errorf("different cycles: %v", diffs)
+	}
+}
+
+// Point has Equal on a pointer receiver, which must never see a nil one.
+type Point struct{ X int }
+
+func (p *Point) Equal(q *Point) bool { return p.X == q.X }
+
+func TestDiffNilWithEqualMethod(t *testing.T) {
+	tests := []struct {
+		a, b  *Point
+		diffs int
+	}{
+		{nil, nil, 0},
+		{nil, &Point{1}, 1},
+		{&Point{1}, nil, 1},
+		{&Point{1}, &Point{1}, 0},
+		{&Point{1}, &Point{2}, 1},
+	}
+	for _, tt := range tests {
+		if diffs := Diff(tt.a, tt.b); len(diffs) != tt.diffs {
+			t.Errorf("Diff(%v, %v) = %v, want %d differences", tt.a, tt.b, diffs, tt.diffs)
+		}
+	}
+}
+
+func TestDiffPaths(t *testing.T) {
+	utc := time.Date(2009, 11, 10, 23, 0, 0, 0, time.UTC)
+	e1 := Event{"launch", utc, Vertex2{37.42202, -122.08408}, []string{"go"}}
+	e2 := Event{"launch", utc.Add(time.Hour), Vertex2{40, -122.08408}, []string{"go", "gopher"}}
+	m2 := map[string]Vertex2{"Bell Labs": {40.68433, -74.39967}, "Google": {37.4, -122.08408}}
+	m3 := map[string]Vertex2{"Bell Labs": {40.68433, -74.39967}, "Google": {37.5, -122.08408}, "Gophers": {1, 2}}
+
+	tests := []struct {
+		name string
+		o    Options
+		a, b any
+		want []string
+	}{
+		{"maps", Options{}, m2, m3, []string{
+			`["Google"].Lat: 37.4 != 37.5`,
+			`["Gophers"]: <missing> != {1 2}`,
+		}},
+		{"ignore map key", Options{Ignore: []string{`["Gophers"]`}}, m2, m3, []string{
+			`["Google"].Lat: 37.4 != 37.5`,
+		}},
+		{"events", Options{}, e1, e2, []string{
+			".When: 2009-11-10 23:00:00 +0000 UTC != 2009-11-11 00:00:00 +0000 UTC",
+			".Where.Lat: 37.42202 != 40",
+			`.Tags[1]: <missing> != "gopher"`,
+		}},
+		{"ignore name and paths", Options{Ignore: []string{"When", ".Where.Lat", ".Tags[1]"}}, e1, e2, nil},
+		{"ignore field name at any depth", Options{Ignore: []string{"Lat"}}, m2, m3, []string{
+			`["Gophers"]: <missing> != {1 2}`,
+		}},
+		{"same instant in two zones", Options{}, utc, utc.In(time.FixedZone("EST", -5*60*60)), nil},
+		{"unexported", Options{}, &List[int]{val: 1}, &List[int]{val: 2}, []string{".val: 1 != 2"}},
+		{"ignore unexported", Options{IgnoreUnexported: true}, &List[int]{val: 1}, &List[int]{val: 2}, nil},
+		{"types", Options{}, 1, "1", []string{`(root): 1 (int) != "1" (string)`}},
+		{"nil slice", Options{}, []int(nil), []int{}, []string{"(root): nil != []"}},
+		{"shorter slice", Options{}, []int{1, 2, 3}, []int{1, 3}, []string{"[1]: 2 != 3", "[2]: 3 != <missing>"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, d := range tt.o.Diff(tt.a, tt.b) {
+			got = append(got, d.String())
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s:\ngot  %q\nwant %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUnified(t *testing.T) {
+	m2 := map[string]Vertex2{"Bell Labs": {40.68433, -74.39967}, "Google": {37.42202, -122.08408}}
+	m3 := map[string]Vertex2{"Bell Labs": {40.68433, -74.39967}, "Google": {37.5, -122.08408}, "Gophers": {1, 2}}
+	a := &List[string]{val: "first", next: &List[string]{val: "second"}}
+	b := &List[string]{val: "first", next: &List[string]{val: "2nd"}}
+
+	tests := []struct {
+		name    string
+		a, b    any
+		context int
+		want    string
+	}{
+		{"same", a, a, 3, ""},
+		{"maps", m2, m3, 1, `--- a
++++ b
+@@ -6,5 +6,9 @@
+   "Google": main.Vertex2{
+-    Lat: 37.42202
++    Lat: 37.5
+     Long: -122.08408
+   }
++  "Gophers": main.Vertex2{
++    Lat: 1
++    Long: 2
++  }
+ }
+`},
+		{"lists", a, b, 3, `--- a
++++ b
+@@ -1,7 +1,7 @@
+ &main.List[string]{
+   next: &main.List[string]{
+     next: nil
+-    val: "second"
++    val: "2nd"
+   }
+   val: "first"
+ }
+`},
+	}
+	for _, tt := range tests {
+		if got := Unified(tt.a, tt.b, tt.context); got != tt.want {
+			t.Errorf("%s:\ngot\n%s\nwant\n%s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+	"testing/iotest"
+	"time"
+)
+
+////// readers: the readers demo (4-methods.go) calls Read on a strings.Reader 8 bytes at a time
+// until io.EOF. Every type here is an io.Reader (or io.Writer) that wraps another one, so they
+// stack: a reader that counts lines can read from one that hashes, which reads from a file.
+// Each one passes on whatever the reader below returns, data and errors together, and never
+// assumes a Read fills the buffer.
+
+////// transform: the tour's rot13Reader, generalised to any byte-for-byte mapping.
+
+type TransformReader struct {
+	R io.Reader
+	F func(byte) byte
+}
+
+func (t *TransformReader) Read(p []byte) (int, error) {
+	n, err := t.R.Read(p)
+	for i := range p[:n] {
+		p[i] = t.F(p[i])
+	}
+	return n, err
+}
+
+func rot13(b byte) byte {
+	switch {
+	case b >= 'a' && b <= 'z':
+		return 'a' + (b-'a'+13)%26
+	case b >= 'A' && b <= 'Z':
+		return 'A' + (b-'A'+13)%26
+	}
+	return b
+}
+
+func Rot13Reader(r io.Reader) io.Reader {
+	return &TransformReader{r, rot13}
+}
+
+////// line counting: counts '\n' as the data goes past. A last line without a newline counts
+// too, once the reader below reports io.EOF.
+
+type LineCounter struct {
+	R io.Reader
+
+	lines   int
+	partial bool // bytes seen since the last '\n'
+}
+
+func (l *LineCounter) Read(p []byte) (int, error) {
+	n, err := l.R.Read(p)
+	for _, b := range p[:n] {
+		if b == '\n' {
+			l.lines++
+			l.partial = false
+		} else {
+			l.partial = true
+		}
+	}
+	if err == io.EOF && l.partial {
+		l.lines++
+		l.partial = false
+	}
+	return n, err
+}
+
+// Lines returns the number of lines read so far.
+func (l *LineCounter) Lines() int {
+	return l.lines
+}
+
+////// hashing: io.TeeReader into a hash, so the checksum is ready when the data has been read,
+// without reading it twice.
+
+type HashReader struct {
+	r io.Reader
+	h hash.Hash
+}
+
+func NewHashReader(r io.Reader, h hash.Hash) *HashReader {
+	return &HashReader{io.TeeReader(r, h), h}
+}
+
+func (h *HashReader) Read(p []byte) (int, error) {
+	return h.r.Read(p)
+}
+
+// Sum returns the hash of everything read so far, in hex.
+func (h *HashReader) Sum() string {
+	return hex.EncodeToString(h.h.Sum(nil))
+}
+
+////// rate limiting: a token bucket. Tokens (bytes) refill at Rate per second up to Burst, and
+// each Read waits until at least one token is there and reads no more than it has.
+
+type RateLimitedReader struct {
+	R     io.Reader
+	Rate  float64 // bytes per second; 0 or less means no limit
+	Burst int     // largest single read; 0 means Rate bytes
+
+	tokens float64
+	last   time.Time
+	// sleep waits; nil means time.Sleep. Tests replace it to run without waiting.
+	sleep func(time.Duration)
+}
+
+func NewRateLimitedReader(r io.Reader, bytesPerSecond float64, burst int) *RateLimitedReader {
+	return &RateLimitedReader{R: r, Rate: bytesPerSecond, Burst: burst, sleep: time.Sleep}
+}
+
+func (l *RateLimitedReader) Read(p []byte) (int, error) {
+	// !(Rate > 0) rather than Rate <= 0 so that NaN is unlimited too, instead of an endless wait.
+	if len(p) == 0 || !(l.Rate > 0) {
+		return l.R.Read(p)
+	}
+	burst := float64(l.Burst)
+	if burst <= 0 {
+		burst = max(1, l.Rate)
+	}
+	now := time.Now()
+	if l.last.IsZero() {
+		l.tokens = burst
+	} else {
+		// last can be ahead of now when sleep didn't really wait; that adds no tokens.
+		l.tokens = min(burst, l.tokens+max(0, now.Sub(l.last)).Seconds()*l.Rate)
+	}
+	l.last = now
+	if l.tokens < 1 {
+		wait := time.Duration((1 - l.tokens) / l.Rate * float64(time.Second))
+		if l.sleep != nil {
+			l.sleep(wait)
+		} else {
+			time.Sleep(wait)
+		}
+		l.tokens = 1
+		l.last = l.last.Add(wait)
+	}
+	p = p[:min(len(p), int(l.tokens))]
+	n, err := l.R.Read(p)
+	l.tokens -= float64(n)
+	return n, err
+}
+
+////// progress: calls a function as data arrives, at most once per Every (and always at the
+// end), with the bytes read so far and the total if it is known.
+
+type ProgressReader struct {
+	R        io.Reader
+	Total    int64 // 0 if unknown
+	Every    time.Duration
+	Progress func(read, total int64)
+
+	read int64
+	last time.Time
+}
+
+func (pr *ProgressReader) Read(p []byte) (int, error) {
+	n, err := pr.R.Read(p)
+	pr.read += int64(n)
+	done := err != nil
+	if pr.Progress != nil && (done || time.Since(pr.last) >= pr.Every) {
+		pr.Progress(pr.read, pr.Total)
+		pr.last = time.Now()
+	}
+	return n, err
+}
+
+////// limited writer: the writing side of io.LimitReader. Past N bytes it writes what fits and
+// returns ErrWriteLimit, which is a short write as io.Writer requires an error for.
+
+var ErrWriteLimit = errors.New("write limit reached")
+
+type LimitedWriter struct {
+	W io.Writer
+	N int64 // bytes still allowed
+}
+
+func (l *LimitedWriter) Write(p []byte) (int, error) {
+	if l.N <= 0 {
+		return 0, ErrWriteLimit
+	}
+	short := int64(len(p)) > l.N
+	if short {
+		p = p[:l.N]
+	}
+	n, err := l.W.Write(p)
+	l.N -= int64(n)
+	if err == nil && short {
+		err = ErrWriteLimit
+	}
+	return n, err
+}
+
+// readAll reads r in 8-byte chunks like the readers demo, printing each one.
+func readAll(r io.Reader) {
+	b := make([]byte, 8)
+	for {
+		n, err := r.Read(b)
+		fmt.Printf("n = %v err = %v b[:n] = %q\n", n, err, b[:n])
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fmt.Println("error:", err)
+			break
+		}
+	}
+}
+
+func tryReaders() {
+	// The tour's rot13 puzzle, read 8 bytes at a time.
+	readAll(Rot13Reader(strings.NewReader("Lbh penpxrq gur pbqr!")))
+
+	// Chained: rot13 -> hash -> count lines.
+	text := "one\ntwo\nthree"
+	hr := NewHashReader(Rot13Reader(strings.NewReader(text)), sha256.New())
+	lc := &LineCounter{R: hr}
+	out, err := io.ReadAll(lc)
+	want := sha256.Sum256(out)
+	fmt.Printf("%q %v lines=%d hash ok=%v\n", out, err, lc.Lines(), hr.Sum() == hex.EncodeToString(want[:]))
+
+	// Progress with a total, reported every read.
+	data := strings.Repeat("x", 20)
+	pr := &ProgressReader{R: strings.NewReader(data), Total: int64(len(data)), Progress: func(read, total int64) {
+		fmt.Printf("  %d/%d\n", read, total)
+	}}
+	io.Copy(io.Discard, iotest.OneByteReader(&LimitedProgress{pr, 3}))
+
+	// Rate limiting: 1000 bytes/s with a burst of 100 takes about 0.4s for 500 bytes.
+	start := time.Now()
+	rl := NewRateLimitedReader(strings.NewReader(strings.Repeat("y", 500)), 1000, 100)
+	n, _ := io.Copy(io.Discard, rl)
+	fmt.Printf("rate limited: %d bytes in %.1fs\n", n, time.Since(start).Seconds())
+
+	// Limited writer.
+	var buf bytes.Buffer
+	lw := &LimitedWriter{W: &buf, N: 10}
+	written, err := io.Copy(lw, strings.NewReader("Hello, Reader!"))
+	fmt.Println(written, err, buf.String())
+	_, err = fmt.Fprintf(&LimitedWriter{W: os.Stdout, N: 6}, "Hello, Writer!\n")
+	fmt.Println()
+	fmt.Println(err)
+}
+
+// LimitedProgress stops reporting after n reports, to keep the demo output short.
+type LimitedProgress struct {
+	*ProgressReader
+	n int
+}
+
+func (l *LimitedProgress) Read(p []byte) (int, error) {
+	if l.n == 0 {
+		l.Progress = nil
+	}
+	l.n--
+	return l.ProgressReader.Read(p)
+}
+
+func tryIotest() {
+	// 28-readers_test.go runs every reader here through iotest.TestReader. Whatever the source
+	// hands out, one byte at a time (OneByteReader), in half-size pieces (HalfReader), or with
+	// io.EOF together with the last data (DataErrReader), line counts come out the same.
+	text := "Hello, Reader!\nline two\nno newline"
+	sources := []struct {
+		name string
+		r    io.Reader
+	}{
+		{"OneByteReader", iotest.OneByteReader(strings.NewReader(text))},
+		{"HalfReader", iotest.HalfReader(strings.NewReader(text))},
+		{"DataErrReader", iotest.DataErrReader(strings.NewReader(text))},
+	}
+	for _, src := range sources {
+		lc := &LineCounter{R: src.r}
+		io.Copy(io.Discard, lc)
+		fmt.Printf("%-14s lines=%d\n", src.name, lc.Lines())
+	}
+
+	// Errors from below come through unchanged.
+	boom := errors.New("disk on fire")
+	_, err := io.ReadAll(&LineCounter{R: Rot13Reader(iotest.ErrReader(boom))})
+	fmt.Println(errors.Is(err, boom))
+	_, err = io.ReadAll(iotest.TimeoutReader(NewHashReader(strings.NewReader(text), sha256.New())))
+	fmt.Println(err)
+
+	// A writer that writes nothing gets an error, never a silent (0, nil).
+	n, err := (&LimitedWriter{W: io.Discard, N: 0}).Write([]byte("x"))
+	fmt.Println(n, err)
+	var sb strings.Builder
+	w := iotest.TruncateWriter(&LimitedWriter{W: &sb, N: 100}, 5)
+	fmt.Fprint(w, "Hello, Writer!")
+	fmt.Println(sb.String())
+}
+
+func main() {
+	// chained readers
+	tryReaders()
+
+	// iotest
+	tryIotest()
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+const text = "Hello, Reader!\nline two\nno newline"
+
+// noSleep makes a rate-limited reader fast enough for iotest.
+func noSleep(r io.Reader) io.Reader {
+	rl := NewRateLimitedReader(r, 1e9, 5)
+	rl.sleep = func(time.Duration) {}
+	return rl
+}
+
+// readChunks reads r to the end, size bytes at a time.
+func readChunks(r io.Reader, size int) ([]byte, error) {
+	var out []byte
+	b := make([]byte, size)
+	for {
+		n, err := r.Read(b)
+		out = append(out, b[:n]...)
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return out, err
+		}
+	}
+}
+
+// Every reader must produce the right bytes when the source hands them out one at a time, in
+// half-size pieces, or with io.EOF together with the last data, and must follow the rest of
+// the io.Reader contract that iotest.TestReader checks.
+func TestReadersWithIotest(t *testing.T) {
+	rot13Text := strings.Map(func(r rune) rune { return rune(rot13(byte(r))) }, text)
+	sources := []struct {
+		name string
+		open func() io.Reader
+		// DataErrReader spins forever on the zero-length reads TestReader makes, so it is only
+		// read to the end in 3-byte chunks.
+		full bool
+	}{
+		{"OneByteReader", func() io.Reader { return iotest.OneByteReader(strings.NewReader(text)) }, true},
+		{"HalfReader", func() io.Reader { return iotest.HalfReader(strings.NewReader(text)) }, true},
+		{"DataErrReader", func() io.Reader { return iotest.DataErrReader(strings.NewReader(text)) }, false},
+	}
+	readers := []struct {
+		name string
+		wrap func(io.Reader) io.Reader
+		want string
+	}{
+		{"rot13", Rot13Reader, rot13Text},
+		{"lines", func(r io.Reader) io.Reader { return &LineCounter{R: r} }, text},
+		{"hash", func(r io.Reader) io.Reader { return NewHashReader(r, sha256.New()) }, text},
+		{"rate", noSleep, text},
+		{"unlimited rate", func(r io.Reader) io.Reader { return NewRateLimitedReader(r, 0, 0) }, text},
+		{"progress", func(r io.Reader) io.Reader { return &ProgressReader{R: r} }, text},
+		{"chain", func(r io.Reader) io.Reader { return &LineCounter{R: noSleep(Rot13Reader(Rot13Reader(r)))} }, text},
+	}
+	for _, src := range sources {
+		for _, rd := range readers {
+			t.Run(src.name+"/"+rd.name, func(t *testing.T) {
+				r := rd.wrap(src.open())
+				if src.full {
+					if err := iotest.TestReader(r, []byte(rd.want)); err != nil {
+						t.Error(err)
+					}
+					return
+				}
+				got, err := readChunks(r, 3)
+				if err != nil || string(got) != rd.want {
+					t.Errorf("got %q, %v; want %q", got, err, rd.want)
+				}
+			})
+		}
+		t.Run(src.name+"/line count", func(t *testing.T) {
+			lc := &LineCounter{R: src.open()}
+			io.Copy(io.Discard, lc)
+			if lc.Lines() != 3 {
+				t.Errorf("lines = %d, want 3", lc.Lines())
+			}
+		})
+	}
+}
+
+func TestErrorsPassThrough(t *testing.T) {
+	boom := errors.New("disk on fire")
+	if _, err := io.ReadAll(&LineCounter{R: Rot13Reader(iotest.ErrReader(boom))}); !errors.Is(err, boom) {
+		t.Errorf("ErrReader: err = %v", err)
+	}
+	if _, err := io.ReadAll(iotest.TimeoutReader(NewHashReader(strings.NewReader(text), sha256.New()))); !errors.Is(err, iotest.ErrTimeout) {
+		t.Errorf("TimeoutReader: err = %v", err)
+	}
+}
+
+func TestLimitedWriter(t *testing.T) {
+	if n, err := (&LimitedWriter{W: io.Discard, N: 0}).Write([]byte("x")); n != 0 || !errors.Is(err, ErrWriteLimit) {
+		t.Errorf("full writer: %d, %v", n, err)
+	}
+	var sb strings.Builder
+	w := iotest.TruncateWriter(&LimitedWriter{W: &sb, N: 100}, 5)
+	fmt.Fprint(w, "Hello, Writer!")
+	if sb.String() != "Hello" {
+		t.Errorf("TruncateWriter wrote %q", sb.String())
+	}
+}
+
+func TestRateLimitedReaderWaits(t *testing.T) {
+	rl := NewRateLimitedReader(strings.NewReader(strings.Repeat("y", 200)), 2000, 20)
+	var slept time.Duration
+	rl.sleep = func(d time.Duration) { slept += d }
+	start := time.Now()
+	if n, err := io.Copy(io.Discard, rl); n != 200 || err != nil {
+		t.Fatalf("copied %d, %v", n, err)
+	}
+	// The first 20 bytes are the burst; the other 180 come at 2000 bytes/s, which is 90ms of
+	// waiting, plus one token's wait for the last Read that only finds EOF. Real time that
+	// passed during the copy earns tokens too, and shortens the waits by as much.
+	want := 90*time.Millisecond + time.Second/2000
+	if elapsed := time.Since(start); slept > want || slept+elapsed < want {
+		t.Errorf("slept %v in %v, want %v", slept, elapsed, want)
+	}
+}
+
+// The configuration fields are exported, so a struct literal without sleep must work too.
+func TestRateLimitedReaderLiteral(t *testing.T) {
+	rl := &RateLimitedReader{R: strings.NewReader(strings.Repeat("z", 30)), Rate: 1000, Burst: 10}
+	start := time.Now()
+	if n, err := io.Copy(io.Discard, rl); n != 30 || err != nil {
+		t.Fatalf("copied %d, %v", n, err)
+	}
+	if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+		t.Errorf("20 bytes past the burst at 1000/s took %v, want at least 20ms", elapsed)
+	}
+}
+
+func TestRateLimitedReaderWithoutRate(t *testing.T) {
+	for _, rate := range []float64{0, -5, math.NaN()} {
+		rl := NewRateLimitedReader(strings.NewReader(text), rate, 2)
+		rl.sleep = func(d time.Duration) { t.Fatalf("rate %v: slept %v", rate, d) }
+		if got, err := io.ReadAll(rl); string(got) != text || err != nil {
+			t.Errorf("rate %v: got %q, %v", rate, got, err)
+		}
+	}
+}
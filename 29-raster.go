@@ -0,0 +1,520 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+////// raster drawing: the images demo (4-methods.go) makes a 100x100 image.RGBA and only prints
+// its bounds and At(0, 0). A Canvas draws on one with integer algorithms that need no
+// floating point per pixel: Bresenham lines, midpoint circles and scanline-filled polygons.
+// The result can be written out as PNG, JPEG or GIF.
+
+// Canvas is an image.RGBA with drawing methods. Pixels outside the bounds are ignored, so
+// shapes may run off the edge.
+type Canvas struct {
+	*image.RGBA
+}
+
+func NewCanvas(w, h int, bg color.Color) *Canvas {
+	c := &Canvas{image.NewRGBA(image.Rect(0, 0, w, h))}
+	c.FillRect(c.Bounds(), bg)
+	return c
+}
+
+// Set is image.RGBA's Set; it already skips points outside the bounds.
+func (c *Canvas) Set(x, y int, col color.Color) {
+	c.RGBA.Set(x, y, col)
+}
+
+// Line draws from (x0, y0) to (x1, y1) inclusive with Bresenham's algorithm: step along one
+// axis, and keep an error term that says when to step along the other.
+func (c *Canvas) Line(x0, y0, x1, y1 int, col color.Color) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := sign(x1-x0), sign(y1-y0)
+	err := dx + dy
+	for {
+		c.Set(x0, y0, col)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func sign(x int) int {
+	switch {
+	case x < 0:
+		return -1
+	case x > 0:
+		return 1
+	}
+	return 0
+}
+
+// Circle draws the outline with the midpoint algorithm: compute one eighth of the circle and
+// mirror it into the other seven.
+func (c *Canvas) Circle(cx, cy, r int, col color.Color) {
+	c.circle(cx, cy, r, func(x, y int) {
+		for _, p := range [][2]int{{x, y}, {y, x}, {-y, x}, {-x, y}, {-x, -y}, {-y, -x}, {y, -x}, {x, -y}} {
+			c.Set(cx+p[0], cy+p[1], col)
+		}
+	})
+}
+
+// FillCircle fills the disc with horizontal spans between the mirrored points.
+func (c *Canvas) FillCircle(cx, cy, r int, col color.Color) {
+	c.circle(cx, cy, r, func(x, y int) {
+		c.hline(cx-x, cx+x, cy+y, col)
+		c.hline(cx-x, cx+x, cy-y, col)
+		c.hline(cx-y, cx+y, cy+x, col)
+		c.hline(cx-y, cx+y, cy-x, col)
+	})
+}
+
+func (c *Canvas) circle(cx, cy, r int, plot func(x, y int)) {
+	x, y := r, 0
+	err := 1 - r
+	for x >= y {
+		plot(x, y)
+		y++
+		if err < 0 {
+			err += 2*y + 1
+		} else {
+			x--
+			err += 2*(y-x) + 1
+		}
+	}
+}
+
+func (c *Canvas) hline(x0, x1, y int, col color.Color) {
+	for x := x0; x <= x1; x++ {
+		c.Set(x, y, col)
+	}
+}
+
+// Rect draws the outline of r; like image.Rectangle, Max is just outside.
+func (c *Canvas) Rect(r image.Rectangle, col color.Color) {
+	c.Polygon([]image.Point{r.Min, {r.Max.X - 1, r.Min.Y}, r.Max.Sub(image.Pt(1, 1)), {r.Min.X, r.Max.Y - 1}}, col)
+}
+
+func (c *Canvas) FillRect(r image.Rectangle, col color.Color) {
+	r = r.Intersect(c.Bounds())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		c.hline(r.Min.X, r.Max.X-1, y, col)
+	}
+}
+
+// Polygon draws the closed outline through pts.
+func (c *Canvas) Polygon(pts []image.Point, col color.Color) {
+	for i, p := range pts {
+		q := pts[(i+1)%len(pts)]
+		c.Line(p.X, p.Y, q.X, q.Y, col)
+	}
+}
+
+// FillPolygon fills pts with the even-odd rule: on each row, find where the edges cross the
+// pixel centres, sort the crossings, and fill between each pair. Concave and self-crossing
+// polygons work too.
+func (c *Canvas) FillPolygon(pts []image.Point, col color.Color) {
+	if len(pts) < 3 {
+		return
+	}
+	minY, maxY := pts[0].Y, pts[0].Y
+	for _, p := range pts {
+		minY, maxY = min(minY, p.Y), max(maxY, p.Y)
+	}
+	minY, maxY = max(minY, c.Bounds().Min.Y), min(maxY, c.Bounds().Max.Y-1)
+	var xs []int
+	for y := minY; y <= maxY; y++ {
+		xs = xs[:0]
+		yc := float64(y) + 0.5
+		for i, p := range pts {
+			q := pts[(i+1)%len(pts)]
+			// Half-open in y so a vertex shared by two edges is counted once.
+			if (float64(p.Y) <= yc) != (float64(q.Y) <= yc) {
+				t := (yc - float64(p.Y)) / float64(q.Y-p.Y)
+				xs = append(xs, int(math.Round(float64(p.X)+t*float64(q.X-p.X))))
+			}
+		}
+		slices.Sort(xs)
+		for i := 0; i+1 < len(xs); i += 2 {
+			c.hline(xs[i], xs[i+1]-1, y, col)
+		}
+	}
+}
+
+////// output: the format follows the file name, as image.Decode does on the way in.
+
+var ErrFormat = errors.New("unknown image format")
+
+// Encode writes img as "png", "jpeg"/"jpg" or "gif".
+func Encode(w io.Writer, img image.Image, format string) error {
+	switch strings.ToLower(format) {
+	case "png":
+		return png.Encode(w, img)
+	case "jpeg", "jpg":
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: 90})
+	case "gif":
+		// GIF has at most 256 colours; the encoder maps to the Plan 9 palette.
+		return gif.Encode(w, img, nil)
+	}
+	return fmt.Errorf("%w: %q", ErrFormat, format)
+}
+
+// Save writes img to path in the format its extension names.
+func Save(path string, img image.Image) (err error) {
+	format := strings.TrimPrefix(filepath.Ext(path), ".")
+	var buf bytes.Buffer
+	if err := Encode(&buf, img, format); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+////// charts: plot float points with axes that pick themselves. The data range is widened to
+// "nice" round numbers (steps of 1, 2 or 5 times a power of ten), so ticks land on values like
+// 0, 0.5, 1 instead of 0.13, 0.61. There is no text, so ticks and grid lines carry the scale.
+
+type Vertex struct {
+	X, Y float64
+}
+
+type ChartKind int
+
+const (
+	Scatter ChartKind = iota
+	LineChart
+)
+
+var chartKindNames = map[ChartKind]string{
+	Scatter:   "scatter",
+	LineChart: "line",
+}
+
+func (k ChartKind) String() string {
+	return chartKindNames[k]
+}
+
+type Chart struct {
+	Width, Height int
+	Kind          ChartKind
+	Points        []Vertex
+	Color         color.Color
+}
+
+// Axis maps data values in [Min, Max] onto pixels, with ticks every Step.
+type Axis struct {
+	Min, Max, Step float64
+}
+
+// NiceAxis covers [lo, hi] with about n ticks on round values.
+func NiceAxis(lo, hi float64, n int) Axis {
+	if !finite(lo) || !finite(hi) {
+		lo, hi = 0, 1
+	}
+	if lo == hi {
+		lo, hi = lo-1, hi+1
+	}
+	if !finite(hi - lo) {
+		// Too wide for any step: no ticks.
+		return Axis{lo, hi, 0}
+	}
+	step := niceNumber((hi - lo) / float64(max(1, n)))
+	return Axis{roundTo(math.Floor(lo/step)*step, step), roundTo(math.Ceil(hi/step)*step, step), step}
+}
+
+// roundTo rounds v to as many decimals as step has, so 3*0.1 comes out as 0.3, not
+// 0.30000000000000004.
+func roundTo(v, step float64) float64 {
+	decimals := max(0, int(-math.Floor(math.Log10(step))))
+	r, _ := strconv.ParseFloat(strconv.FormatFloat(v, 'f', decimals, 64), 64)
+	return r + 0 // no -0
+}
+
+// niceNumber rounds x up to 1, 2 or 5 times a power of ten.
+func niceNumber(x float64) float64 {
+	exp := math.Pow(10, math.Floor(math.Log10(x)))
+	for _, m := range []float64{1, 2, 5, 10} {
+		if x <= m*exp*(1+1e-9) {
+			return m * exp
+		}
+	}
+	return 10 * exp
+}
+
+func finite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
+
+// maxTicks bounds Ticks for axes built by hand with a tiny Step.
+const maxTicks = 1000
+
+// Ticks returns the tick values from Min to Max, or none for an axis that can't have any.
+func (a Axis) Ticks() []float64 {
+	if !finite(a.Min) || !finite(a.Max) || !finite(a.Step) || a.Step <= 0 {
+		return nil
+	}
+	var ts []float64
+	for i := range maxTicks {
+		t := a.Min + float64(i)*a.Step
+		if t > a.Max+a.Step/2 {
+			return ts
+		}
+		ts = append(ts, roundTo(t, a.Step))
+	}
+	return ts
+}
+
+// pixel maps v onto [p0, p1].
+func (a Axis) pixel(v float64, p0, p1 int) int {
+	// Halved, so a range as wide as the float64s themselves doesn't overflow to Inf.
+	t := (v/2 - a.Min/2) / (a.Max/2 - a.Min/2)
+	if !finite(t) {
+		t = 0
+	}
+	return p0 + int(math.Round(t*float64(p1-p0)))
+}
+
+const chartMargin = 20
+
+// points returns the points that can be plotted: NaN and ±Inf have no place on an axis.
+func (ch Chart) points() []Vertex {
+	return slices.DeleteFunc(slices.Clone(ch.Points), func(p Vertex) bool { return !finite(p.X) || !finite(p.Y) })
+}
+
+// Axes returns the axes Render will use.
+func (ch Chart) Axes() (x, y Axis) {
+	pts := ch.points()
+	if len(pts) == 0 {
+		return NiceAxis(0, 1, 5), NiceAxis(0, 1, 5)
+	}
+	minX, maxX, minY, maxY := pts[0].X, pts[0].X, pts[0].Y, pts[0].Y
+	for _, p := range pts {
+		minX, maxX = min(minX, p.X), max(maxX, p.X)
+		minY, maxY = min(minY, p.Y), max(maxY, p.Y)
+	}
+	return NiceAxis(minX, maxX, 8), NiceAxis(minY, maxY, 6)
+}
+
+func (ch Chart) Render() *Canvas {
+	white := color.RGBA{255, 255, 255, 255}
+	grid := color.RGBA{225, 225, 225, 255}
+	axisCol := color.RGBA{60, 60, 60, 255}
+	col := ch.Color
+	if col == nil {
+		col = color.RGBA{30, 100, 200, 255}
+	}
+
+	c := NewCanvas(ch.Width, ch.Height, white)
+	left, right := chartMargin, ch.Width-chartMargin
+	top, bottom := chartMargin, ch.Height-chartMargin
+	ax, ay := ch.Axes()
+	// y grows downwards in images, so the y axis maps Min to the bottom.
+	px := func(v float64) int { return ax.pixel(v, left, right) }
+	py := func(v float64) int { return ay.pixel(v, bottom, top) }
+
+	for _, t := range ax.Ticks() {
+		c.Line(px(t), top, px(t), bottom, grid)
+		c.Line(px(t), bottom, px(t), bottom+4, axisCol)
+	}
+	for _, t := range ay.Ticks() {
+		c.Line(left, py(t), right, py(t), grid)
+		c.Line(left-4, py(t), left, py(t), axisCol)
+	}
+	// The axes sit at zero when zero is in range, otherwise along the edge.
+	x0, y0 := left, bottom
+	if ax.Min <= 0 && 0 <= ax.Max {
+		x0 = px(0)
+	}
+	if ay.Min <= 0 && 0 <= ay.Max {
+		y0 = py(0)
+	}
+	c.Line(left, y0, right, y0, axisCol)
+	c.Line(x0, top, x0, bottom, axisCol)
+
+	switch ch.Kind {
+	case LineChart:
+		pts := ch.points()
+		slices.SortStableFunc(pts, func(a, b Vertex) int { return compareFloat(a.X, b.X) })
+		for i := 1; i < len(pts); i++ {
+			c.Line(px(pts[i-1].X), py(pts[i-1].Y), px(pts[i].X), py(pts[i].Y), col)
+		}
+	default:
+		for _, p := range ch.points() {
+			c.FillCircle(px(p.X), py(p.Y), 2, col)
+		}
+	}
+	return c
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func sinePoints(n int) []Vertex {
+	pts := make([]Vertex, n)
+	for i := range pts {
+		x := float64(i) / float64(n-1) * 2 * math.Pi
+		pts[i] = Vertex{x, math.Sin(x)}
+	}
+	return pts
+}
+
+// render is the command: go run 29-raster.go render out.png [scatter|line]
+func render(path, kind string) error {
+	ch := Chart{Width: 640, Height: 400, Kind: LineChart, Points: sinePoints(100)}
+	if kind == "scatter" {
+		ch.Kind = Scatter
+		ch.Points = sinePoints(30)
+	}
+	if err := Save(path, ch.Render()); err != nil {
+		return err
+	}
+	fmt.Printf("wrote %s chart to %s\n", ch.Kind, path)
+	return nil
+}
+
+func tryDrawing() {
+	// The 100x100 image from 4-methods.go, now with something on it.
+	black := color.RGBA{0, 0, 0, 255}
+	red := color.RGBA{255, 0, 0, 255}
+	c := NewCanvas(100, 100, color.RGBA{255, 255, 255, 255})
+	fmt.Println(c.Bounds())
+	fmt.Println(c.At(0, 0).RGBA())
+
+	// A diagonal hits every (i, i); a shallow line has exactly one pixel per column.
+	c.Line(0, 0, 99, 99, black)
+	fmt.Println(c.At(50, 50) == black, c.At(50, 51) == black)
+	c.Line(0, 10, 99, 30, red)
+	perColumn := 0
+	for x := range 100 {
+		for y := range 100 {
+			if c.At(x, y) == red {
+				perColumn++
+			}
+		}
+	}
+	fmt.Println("shallow line pixels:", perColumn)
+
+	// A circle's outline is r away from the centre, within half a pixel.
+	c = NewCanvas(100, 100, color.RGBA{255, 255, 255, 255})
+	c.Circle(50, 50, 30, black)
+	worst := 0.0
+	for x := range 100 {
+		for y := range 100 {
+			if c.At(x, y) == black {
+				worst = max(worst, math.Abs(math.Hypot(float64(x-50), float64(y-50))-30))
+			}
+		}
+	}
+	fmt.Printf("circle: worst distance error %.2f\n", worst)
+
+	// A filled disc of radius r covers about pi*r*r pixels.
+	c.FillCircle(50, 50, 30, red)
+	fmt.Printf("disc: %d pixels, pi*r*r = %.0f\n", count(c, red), math.Pi*30*30)
+
+	// A filled square and a concave arrow.
+	c = NewCanvas(100, 100, color.RGBA{255, 255, 255, 255})
+	c.FillPolygon([]image.Point{{10, 10}, {30, 10}, {30, 30}, {10, 30}}, red)
+	fmt.Println("square:", count(c, red))
+	c = NewCanvas(100, 100, color.RGBA{255, 255, 255, 255})
+	arrow := []image.Point{{10, 40}, {60, 40}, {60, 20}, {90, 50}, {60, 80}, {60, 60}, {10, 60}}
+	c.FillPolygon(arrow, red)
+	c.Polygon(arrow, black)
+	fmt.Println("arrow tip and notch:", c.At(85, 50) == red, c.At(40, 30) == red)
+	c.Rect(image.Rect(0, 0, 100, 100), black)
+	fmt.Println("frame corners:", c.At(0, 0) == black, c.At(99, 99) == black)
+}
+
+func count(c *Canvas, col color.Color) int {
+	n := 0
+	for x := range c.Bounds().Dx() {
+		for y := range c.Bounds().Dy() {
+			if c.At(x, y) == col {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+func tryCharts() {
+	ax := NiceAxis(0.13, 0.61, 5)
+	fmt.Println(ax, ax.Ticks())
+	ax = NiceAxis(-1, 1, 6)
+	fmt.Println(ax, ax.Ticks())
+	ax = NiceAxis(0, 2*math.Pi, 8)
+	fmt.Println(ax, ax.Ticks())
+
+	// Every format round-trips through image.Decode.
+	ch := Chart{Width: 320, Height: 200, Kind: LineChart, Points: sinePoints(50)}
+	img := ch.Render()
+	for _, format := range []string{"png", "jpeg", "gif", "bmp"} {
+		var buf bytes.Buffer
+		if err := Encode(&buf, img, format); err != nil {
+			fmt.Println(format, err)
+			continue
+		}
+		back, name, err := image.Decode(&buf)
+		if err != nil {
+			fmt.Println(format, err)
+			continue
+		}
+		fmt.Println(format, name, back.Bounds())
+	}
+	fmt.Println(Chart{Width: 100, Height: 100, Kind: Scatter}.Render().Bounds())
+}
+
+func main() {
+	if len(os.Args) > 2 && os.Args[1] == "render" {
+		kind := "line"
+		if len(os.Args) > 3 {
+			kind = os.Args[3]
+		}
+		if err := render(os.Args[2], kind); err != nil {
+			fmt.Println(err)
+		}
+		return
+	}
+
+	// drawing
+	tryDrawing()
+
+	// charts and export
+	tryCharts()
+}
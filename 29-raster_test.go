@@ -0,0 +1,96 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// renderWithin runs Render with a deadline, since the failure mode is a hang.
+func renderWithin(t *testing.T, ch Chart) *Canvas {
+	t.Helper()
+	done := make(chan *Canvas, 1)
+	go func() { done <- ch.Render() }()
+	select {
+	case c := <-done:
+		return c
+	case <-time.After(5 * time.Second):
+		t.Fatal("Render did not finish")
+		return nil
+	}
+}
+
+func TestRenderNonFinitePoints(t *testing.T) {
+	tests := []struct {
+		name   string
+		points []Vertex
+	}{
+		{"NaN y", []Vertex{{0, 1}, {1, math.NaN()}, {2, 3}}},
+		{"NaN x", []Vertex{{math.NaN(), 1}, {1, 2}}},
+		{"+Inf", []Vertex{{0, 1}, {1, math.Inf(1)}}},
+		{"-Inf x", []Vertex{{math.Inf(-1), 0}, {1, 2}}},
+		{"only NaN", []Vertex{{math.NaN(), math.NaN()}}},
+		{"huge range", []Vertex{{-math.MaxFloat64, 0}, {math.MaxFloat64, 1}}},
+	}
+	for _, tt := range tests {
+		for _, kind := range []ChartKind{Scatter, LineChart} {
+			t.Run(tt.name+"/"+kind.String(), func(t *testing.T) {
+				c := renderWithin(t, Chart{Width: 200, Height: 100, Kind: kind, Points: tt.points})
+				if got := c.Bounds().Size(); got.X != 200 || got.Y != 100 {
+					t.Errorf("size = %v", got)
+				}
+			})
+		}
+	}
+}
+
+func TestAxesIgnoreNonFinitePoints(t *testing.T) {
+	ch := Chart{Points: []Vertex{{0, 1}, {1, math.NaN()}, {2, 3}, {math.Inf(1), 2}}}
+	x, y := ch.Axes()
+	if x.Min != 0 || x.Max != 2 || y.Min != 1 || y.Max != 3 {
+		t.Errorf("axes = %v %v, want [0, 2] and [1, 3]", x, y)
+	}
+}
+
+func TestTicks(t *testing.T) {
+	tests := []struct {
+		name string
+		axis Axis
+		want int
+	}{
+		{"normal", Axis{0, 1, 0.25}, 5},
+		{"zero step", Axis{0, 1, 0}, 0},
+		{"negative step", Axis{0, 1, -1}, 0},
+		{"NaN step", Axis{0, 1, math.NaN()}, 0},
+		{"Inf step", Axis{0, 1, math.Inf(1)}, 0},
+		{"NaN max", Axis{0, math.NaN(), 1}, 0},
+		{"Inf max", Axis{0, math.Inf(1), 1}, 0},
+		{"tiny step", Axis{0, 1, 1e-9}, maxTicks},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := len(tt.axis.Ticks()); got != tt.want {
+				t.Errorf("%v: %d ticks, want %d", tt.axis, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNiceAxis(t *testing.T) {
+	tests := []struct {
+		lo, hi float64
+		n      int
+		want   Axis
+	}{
+		{0.13, 0.61, 5, Axis{0.1, 0.7, 0.1}},
+		{-1, 1, 6, Axis{-1, 1, 0.5}},
+		{0, 2 * math.Pi, 8, Axis{0, 7, 1}},
+		{5, 5, 4, Axis{4, 6, 0.5}},
+		{math.NaN(), 1, 5, Axis{0, 1, 0.2}},
+	}
+	for _, tt := range tests {
+		if got := NiceAxis(tt.lo, tt.hi, tt.n); got != tt.want {
+			t.Errorf("NiceAxis(%v, %v, %d) = %v, want %v", tt.lo, tt.hi, tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,421 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"math"
+	"math/cmplx"
+	"math/rand/v2"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+////// generated images: the images demo (4-methods.go) makes an empty image.NewRGBA. Every image
+// here is a Shader, a function from pixel to colour, and Render runs one over an image.RGBA with
+// rows handed out to goroutines. Pixels are independent, so this parallelises without locks:
+// each goroutine writes only the rows it took.
+
+// Shader returns the colour of pixel (x, y) in a w x h image.
+type Shader func(x, y, w, h int) color.RGBA
+
+// Render fills a w x h image with workers goroutines; workers <= 0 means one per CPU.
+func Render(w, h int, s Shader, workers int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	// Rows are taken one at a time from a shared counter, so a worker that gets cheap rows
+	// (outside the Mandelbrot set) simply takes more of them.
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for range min(workers, h) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				y := int(next.Add(1) - 1)
+				if y >= h {
+					return
+				}
+				for x := range w {
+					img.SetRGBA(x, y, s(x, y, w, h))
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return img
+}
+
+////// palettes: a gradient through colour stops, sampled with t in [0, 1].
+
+type Palette []color.RGBA
+
+var (
+	Grayscale = Palette{{0, 0, 0, 255}, {255, 255, 255, 255}}
+	Fire      = Palette{{0, 0, 0, 255}, {128, 0, 0, 255}, {255, 96, 0, 255}, {255, 220, 64, 255}, {255, 255, 255, 255}}
+	Ocean     = Palette{{0, 7, 100, 255}, {32, 107, 203, 255}, {237, 255, 255, 255}, {255, 170, 0, 255}, {0, 2, 0, 255}}
+	Rainbow   = Palette{{255, 0, 0, 255}, {255, 255, 0, 255}, {0, 255, 0, 255}, {0, 255, 255, 255}, {0, 0, 255, 255}, {255, 0, 255, 255}}
+)
+
+// At interpolates between the stops; t outside [0, 1] is clamped, and NaN counts as 0. An
+// empty palette is all black.
+func (p Palette) At(t float64) color.RGBA {
+	switch {
+	case len(p) == 0:
+		return color.RGBA{0, 0, 0, 255}
+	case len(p) == 1:
+		return p[0]
+	case math.IsNaN(t):
+		t = 0
+	}
+	t = math.Max(0, math.Min(1, t)) * float64(len(p)-1)
+	i := min(int(t), len(p)-2)
+	f := t - float64(i)
+	a, b := p[i], p[i+1]
+	mix := func(x, y uint8) uint8 { return uint8(math.Round(float64(x) + f*(float64(y)-float64(x)))) }
+	return color.RGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), 255}
+}
+
+// Cyclic repeats the palette n times over [0, 1], which shows more bands in fractals.
+func (p Palette) Cyclic(n float64) func(float64) color.RGBA {
+	return func(t float64) color.RGBA {
+		t = math.Mod(t*n, 2)
+		if t > 1 {
+			t = 2 - t // back and forth, so there is no seam
+		}
+		return p.At(t)
+	}
+}
+
+// Colors samples n colours, for a GIF's color.Palette. A single colour is the first stop.
+func (p Palette) Colors(n int) color.Palette {
+	switch {
+	case n <= 0:
+		return nil
+	case n == 1:
+		return color.Palette{p.At(0)}
+	}
+	cs := make(color.Palette, n)
+	for i := range cs {
+		cs[i] = p.At(float64(i) / float64(n-1))
+	}
+	return cs
+}
+
+////// Mandelbrot and Julia sets: iterate z = z*z + c and count the steps until |z| > 2. For the
+// Mandelbrot set z starts at 0 and c is the pixel; for a Julia set c is fixed and z starts at
+// the pixel. Points that never escape are in the set and drawn black.
+
+// View is the part of the complex plane on screen: Center, and Width in complex units.
+type View struct {
+	Center complex128
+	Width  float64
+}
+
+// point maps a pixel to the complex plane, keeping the aspect ratio square.
+func (v View) point(x, y, w, h int) complex128 {
+	scale := v.Width / float64(w)
+	return v.Center + complex((float64(x)-float64(w)/2)*scale, (float64(h)/2-float64(y))*scale)
+}
+
+// escape iterates and returns a smooth iteration count, or -1 if z stays bounded. The smooth
+// count adds a fraction from how far past the radius z landed, which removes visible bands.
+func escape(z, c complex128, maxIter int) float64 {
+	for i := range maxIter {
+		z = z*z + c
+		if r2 := real(z)*real(z) + imag(z)*imag(z); r2 > 256 {
+			// A larger radius than 2 makes the smoothing accurate.
+			return float64(i) + 1 - math.Log2(math.Log(cmplx.Abs(z)))
+		}
+	}
+	return -1
+}
+
+func Mandelbrot(v View, maxIter int, colour func(float64) color.RGBA) Shader {
+	return func(x, y, w, h int) color.RGBA {
+		n := escape(0, v.point(x, y, w, h), maxIter)
+		if n < 0 {
+			return color.RGBA{0, 0, 0, 255}
+		}
+		return colour(n / float64(maxIter))
+	}
+}
+
+func Julia(c complex128, v View, maxIter int, colour func(float64) color.RGBA) Shader {
+	return func(x, y, w, h int) color.RGBA {
+		n := escape(v.point(x, y, w, h), c, maxIter)
+		if n < 0 {
+			return color.RGBA{0, 0, 0, 255}
+		}
+		return colour(n / float64(maxIter))
+	}
+}
+
+////// noise: Perlin noise puts a random gradient at every integer grid point and blends the dot
+// products smoothly between them. Simplex noise does the same on a grid of triangles, which
+// needs three corners instead of four and has fewer axis-aligned artefacts. Both return values
+// in about [-1, 1]; adding octaves at doubling frequency and halving amplitude (fractal
+// Brownian motion) gives clouds and terrain.
+
+type Noise struct {
+	perm [512]uint8
+}
+
+func NewNoise(seed uint64) *Noise {
+	n := &Noise{}
+	p := rand.New(rand.NewPCG(seed, seed)).Perm(256)
+	for i := range n.perm {
+		n.perm[i] = uint8(p[i%256])
+	}
+	return n
+}
+
+// grad picks one of eight gradient directions for a hash and dots it with (x, y).
+func grad(hash uint8, x, y float64) float64 {
+	switch hash & 7 {
+	case 0:
+		return x + y
+	case 1:
+		return -x + y
+	case 2:
+		return x - y
+	case 3:
+		return -x - y
+	case 4:
+		return x
+	case 5:
+		return -x
+	case 6:
+		return y
+	}
+	return -y
+}
+
+func fade(t float64) float64 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
+
+func lerp(a, b, t float64) float64 {
+	return a + t*(b-a)
+}
+
+// Perlin is Ken Perlin's improved noise in two dimensions.
+func (n *Noise) Perlin(x, y float64) float64 {
+	xf, yf := math.Floor(x), math.Floor(y)
+	xi, yi := int(xf)&255, int(yf)&255
+	x, y = x-xf, y-yf
+	u, v := fade(x), fade(y)
+	p := &n.perm
+	aa, ab := p[int(p[xi])+yi], p[int(p[xi])+yi+1]
+	ba, bb := p[int(p[xi+1])+yi], p[int(p[xi+1])+yi+1]
+	return lerp(
+		lerp(grad(aa, x, y), grad(ba, x-1, y), u),
+		lerp(grad(ab, x, y-1), grad(bb, x-1, y-1), u),
+		v)
+}
+
+// Simplex is two-dimensional simplex noise.
+func (n *Noise) Simplex(x, y float64) float64 {
+	const (
+		f2 = 0.36602540378443864676 // (sqrt(3) - 1) / 2: skews the square grid into triangles
+		g2 = 0.21132486540518711775 // (3 - sqrt(3)) / 6: and back
+	)
+	s := (x + y) * f2
+	i, j := math.Floor(x+s), math.Floor(y+s)
+	t := (i + j) * g2
+	x0, y0 := x-(i-t), y-(j-t)
+
+	// Which of the two triangles in the skewed cell are we in?
+	i1, j1 := 0.0, 1.0
+	if x0 > y0 {
+		i1, j1 = 1, 0
+	}
+	corners := [3][2]float64{
+		{x0, y0},
+		{x0 - i1 + g2, y0 - j1 + g2},
+		{x0 - 1 + 2*g2, y0 - 1 + 2*g2},
+	}
+	offsets := [3][2]int{{0, 0}, {int(i1), int(j1)}, {1, 1}}
+	ii, jj := int(i)&255, int(j)&255
+	total := 0.0
+	for k, c := range corners {
+		t := 0.5 - c[0]*c[0] - c[1]*c[1]
+		if t < 0 {
+			continue
+		}
+		h := n.perm[ii+offsets[k][0]+int(n.perm[jj+offsets[k][1]])]
+		t *= t
+		total += t * t * grad(h, c[0], c[1])
+	}
+	// Scale to about [-1, 1].
+	return 70 * total
+}
+
+// FBM sums octaves of noise.
+func FBM(noise func(x, y float64) float64, x, y float64, octaves int) float64 {
+	total, amp, freq, norm := 0.0, 1.0, 1.0, 0.0
+	for range octaves {
+		total += amp * noise(x*freq, y*freq)
+		norm += amp
+		amp /= 2
+		freq *= 2
+	}
+	return total / norm
+}
+
+// NoiseShader maps noise at the given scale (pixels per grid cell) onto a palette.
+func NoiseShader(noise func(x, y float64) float64, scale float64, octaves int, p Palette) Shader {
+	return func(x, y, w, h int) color.RGBA {
+		v := FBM(noise, float64(x)/scale, float64(y)/scale, octaves)
+		return p.At((v + 1) / 2)
+	}
+}
+
+////// the tour's Pic exercise: a dx x dy slice of uint8s shown as a bluescale picture.
+
+func Pic(dx, dy int) [][]uint8 {
+	pic := make([][]uint8, dy)
+	for y := range pic {
+		pic[y] = make([]uint8, dx)
+		for x := range pic[y] {
+			pic[y][x] = uint8((x + y) / 2)
+		}
+	}
+	return pic
+}
+
+// PicShader shows a Pic result the way the tour does: each value as a shade of blue. Rows may
+// have different lengths; missing values, including those of an empty result, show as 0.
+func PicShader(f func(dx, dy int) [][]uint8, dx, dy int) Shader {
+	pic := f(dx, dy)
+	return func(x, y, w, h int) color.RGBA {
+		var v uint8
+		if len(pic) > 0 {
+			if row := pic[y*len(pic)/h]; len(row) > 0 {
+				v = row[x*len(row)/w]
+			}
+		}
+		return color.RGBA{v, v, 255, 255}
+	}
+}
+
+////// zoom: an animated GIF of frames that each narrow the view by a constant factor.
+
+func Zoom(w, h, frames int, from View, to complex128, factor float64, maxIter int, p Palette) *gif.GIF {
+	anim := &gif.GIF{}
+	pal := p.Colors(255)
+	pal = append(pal, color.RGBA{0, 0, 0, 255})
+	v := from
+	for range frames {
+		// Zoom towards the target as well as in.
+		img := Render(w, h, Mandelbrot(v, maxIter, p.Cyclic(8)), 0)
+		frame := image.NewPaletted(img.Bounds(), pal)
+		draw.Draw(frame, frame.Bounds(), img, image.Point{}, draw.Src)
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, 10)
+		v.Width *= factor
+		v.Center += (to - v.Center) * complex(1-factor, 0)
+	}
+	return anim
+}
+
+func savePNG(path string, img image.Image) error {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+var mandelbrotView = View{-0.5, 3.2}
+
+// render is the command: go run 30-fractals.go render <mandelbrot|julia|perlin|simplex|pic|zoom> <file>
+func render(kind, path string) error {
+	const w, h = 640, 480
+	noise := NewNoise(1)
+	shaders := map[string]Shader{
+		"mandelbrot": Mandelbrot(mandelbrotView, 500, Ocean.Cyclic(4)),
+		"julia":      Julia(complex(-0.8, 0.156), View{0, 3.2}, 500, Fire.At),
+		"perlin":     NoiseShader(noise.Perlin, 80, 5, Ocean),
+		"simplex":    NoiseShader(noise.Simplex, 80, 5, Fire),
+		"pic":        PicShader(Pic, 256, 256),
+	}
+	if kind == "zoom" {
+		var buf bytes.Buffer
+		anim := Zoom(320, 240, 30, mandelbrotView, complex(-0.743643887037151, 0.13182590420533), 0.8, 300, Ocean)
+		if err := gif.EncodeAll(&buf, anim); err != nil {
+			return err
+		}
+		return os.WriteFile(path, buf.Bytes(), 0o644)
+	}
+	s, ok := shaders[kind]
+	if !ok {
+		return fmt.Errorf("unknown image %q", kind)
+	}
+	return savePNG(path, Render(w, h, s, 0))
+}
+
+func tryGenerators() {
+	// The empty image from 4-methods.go, and the same size rendered.
+	m := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	fmt.Println(m.Bounds(), m.At(0, 0))
+	m = Render(100, 100, Mandelbrot(mandelbrotView, 100, Grayscale.At), 0)
+	fmt.Println(m.Bounds(), m.At(0, 0))
+
+	// The centre of the view, -0.5, is inside the set; 1+1i escapes at once.
+	fmt.Println(escape(0, -0.5, 1000), escape(0, 1+1i, 1000) < 2)
+	fmt.Println(m.At(50, 50))
+
+	// Serial and parallel rendering produce the same pixels.
+	s := Julia(complex(-0.8, 0.156), View{0, 3.2}, 200, Fire.At)
+	serial, parallel := Render(200, 150, s, 1), Render(200, 150, s, 8)
+	fmt.Println("serial == parallel:", bytes.Equal(serial.Pix, parallel.Pix))
+
+	// Noise is 0 on grid points, stays within [-1, 1], and the same seed gives the same noise.
+	n := NewNoise(7)
+	lo, hi := 0.0, 0.0
+	for i := range 10000 {
+		x, y := float64(i%100)*0.37, float64(i/100)*0.41
+		for _, v := range []float64{n.Perlin(x, y), n.Simplex(x, y)} {
+			lo, hi = min(lo, v), max(hi, v)
+		}
+	}
+	fmt.Printf("perlin(3,4)=%v range [%.2f, %.2f] repeatable=%v\n",
+		n.Perlin(3, 4), lo, hi, n.Simplex(1.5, 2.5) == NewNoise(7).Simplex(1.5, 2.5))
+
+	// Palettes: ends and midpoint.
+	fmt.Println(Grayscale.At(0), Grayscale.At(0.5), Grayscale.At(1), Grayscale.At(2))
+
+	// Pic as in the tour.
+	pic := Render(256, 256, PicShader(Pic, 256, 256), 0)
+	fmt.Println(pic.At(0, 0), pic.At(255, 255))
+
+	// A few zoom frames.
+	anim := Zoom(80, 60, 5, mandelbrotView, complex(-0.743643887037151, 0.13182590420533), 0.5, 100, Ocean)
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		fmt.Println(err)
+	}
+	back, err := gif.DecodeAll(&buf)
+	fmt.Println("gif frames:", len(back.Image), err)
+}
+
+func main() {
+	if len(os.Args) > 3 && os.Args[1] == "render" {
+		if err := render(os.Args[2], os.Args[3]); err != nil {
+			fmt.Println(err)
+		}
+		return
+	}
+
+	// generators
+	tryGenerators()
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestPaletteAt(t *testing.T) {
+	tests := []struct {
+		p    Palette
+		t    float64
+		want color.RGBA
+	}{
+		{Grayscale, 0, color.RGBA{0, 0, 0, 255}},
+		{Grayscale, 0.5, color.RGBA{128, 128, 128, 255}},
+		{Grayscale, 2, color.RGBA{255, 255, 255, 255}},
+		{Grayscale, math.NaN(), color.RGBA{0, 0, 0, 255}},
+		{Grayscale, math.Inf(-1), color.RGBA{0, 0, 0, 255}},
+		{Palette{{1, 2, 3, 255}}, math.NaN(), color.RGBA{1, 2, 3, 255}},
+		{nil, 0.5, color.RGBA{0, 0, 0, 255}},
+	}
+	for _, tt := range tests {
+		if got := tt.p.At(tt.t); got != tt.want {
+			t.Errorf("%v.At(%v) = %v, want %v", tt.p, tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestPaletteColors(t *testing.T) {
+	for _, n := range []int{-1, 0, 1, 2, 255} {
+		cs := Fire.Colors(n)
+		if len(cs) != max(n, 0) {
+			t.Errorf("Colors(%d) has %d colours", n, len(cs))
+		}
+		if n >= 1 && cs[0] != Fire[0] {
+			t.Errorf("Colors(%d)[0] = %v, want %v", n, cs[0], Fire[0])
+		}
+		if n >= 2 && cs[n-1] != Fire[len(Fire)-1] {
+			t.Errorf("Colors(%d)[%d] = %v, want %v", n, n-1, cs[n-1], Fire[len(Fire)-1])
+		}
+	}
+}
+
+func TestPicShaderShortResults(t *testing.T) {
+	for name, f := range map[string]func(dx, dy int) [][]uint8{
+		"nil":        func(int, int) [][]uint8 { return nil },
+		"empty rows": func(int, int) [][]uint8 { return make([][]uint8, 3) },
+		"ragged":     func(int, int) [][]uint8 { return [][]uint8{{7}, {}, {7, 7, 7}} },
+		"tour":       Pic,
+	} {
+		img := Render(16, 9, PicShader(f, 16, 9), 0)
+		if c := img.RGBAAt(15, 8); c.B != 255 || c.A != 255 {
+			t.Errorf("%s: corner = %v", name, c)
+		}
+	}
+}
+
+// BenchmarkRender compares serial and parallel rendering. The speed-up is bounded by the number
+// of CPUs; on one CPU every worker count takes the same time.
+func BenchmarkRender(b *testing.B) {
+	s := Mandelbrot(mandelbrotView, 200, Ocean.At)
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for b.Loop() {
+				Render(320, 240, s, workers)
+			}
+		})
+	}
+}
@@ -0,0 +1,451 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+////// image filters: the images demo (4-methods.go) stops at creating an image.RGBA. These filters
+// take an *image.RGBA and return a new one, leaving the input alone. They read and write Pix
+// directly, four bytes per pixel, rather than going through At and Set, which box a
+// color.Color for every pixel. image.RGBA holds alpha-premultiplied colour, which is what
+// blurring and resampling need: a transparent pixel contributes nothing, whatever its RGB.
+
+// clamp rounds v to the nearest byte value.
+func clamp(v float64) uint8 {
+	switch {
+	case v <= 0:
+		return 0
+	case v >= 255:
+		return 255
+	}
+	return uint8(v + 0.5)
+}
+
+// newLike returns a blank image with img's bounds.
+func newLike(img *image.RGBA) *image.RGBA {
+	return image.NewRGBA(img.Bounds())
+}
+
+// mapPixels applies f to every pixel, passing and returning r, g, b, a.
+func mapPixels(img *image.RGBA, f func(r, g, b, a uint8) (uint8, uint8, uint8, uint8)) *image.RGBA {
+	out := newLike(img)
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		i := img.PixOffset(b.Min.X, y)
+		o := out.PixOffset(b.Min.X, y)
+		for x := 0; x < b.Dx(); x++ {
+			p := img.Pix[i+4*x : i+4*x+4 : i+4*x+4]
+			out.Pix[o+4*x], out.Pix[o+4*x+1], out.Pix[o+4*x+2], out.Pix[o+4*x+3] = f(p[0], p[1], p[2], p[3])
+		}
+	}
+	return out
+}
+
+////// point filters: each output pixel depends only on the same input pixel.
+
+// luma is the brightness the eye sees, with the ITU-R BT.601 weights JPEG also uses.
+func luma(r, g, b uint8) float64 {
+	return 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+}
+
+func Grayscale(img *image.RGBA) *image.RGBA {
+	return mapPixels(img, func(r, g, b, a uint8) (uint8, uint8, uint8, uint8) {
+		y := clamp(luma(r, g, b))
+		return y, y, y, a
+	})
+}
+
+// BrightnessContrast adds brightness (-1 to 1, as a fraction of full scale) and scales the
+// distance from mid-grey by contrast (1 leaves it unchanged, 0 makes everything grey).
+func BrightnessContrast(img *image.RGBA, brightness, contrast float64) *image.RGBA {
+	var lut [256]uint8
+	for v := range lut {
+		lut[v] = clamp((float64(v)-128)*contrast + 128 + brightness*255)
+	}
+	return mapPixels(img, func(r, g, b, a uint8) (uint8, uint8, uint8, uint8) {
+		// Premultiplied colour can't exceed alpha.
+		return min(lut[r], a), min(lut[g], a), min(lut[b], a), a
+	})
+}
+
+// Equalize spreads the brightness histogram over the full range: each brightness is mapped to
+// the fraction of pixels at or below it. Only luma changes; the colours keep their hue.
+func Equalize(img *image.RGBA) *image.RGBA {
+	var hist [256]int
+	n := 0
+	mapPixels(img, func(r, g, b, a uint8) (uint8, uint8, uint8, uint8) {
+		y, _, _ := color.RGBToYCbCr(r, g, b)
+		hist[y]++
+		n++
+		return r, g, b, a
+	})
+	// The lowest brightness present goes to 0, the highest to 255.
+	var lut [256]uint8
+	cdf, cdfMin := 0, 0
+	for v, h := range hist {
+		cdf += h
+		if cdfMin == 0 {
+			cdfMin = cdf
+		}
+		if n > cdfMin {
+			lut[v] = clamp(float64(cdf-cdfMin) / float64(n-cdfMin) * 255)
+		} else {
+			lut[v] = uint8(v)
+		}
+	}
+	return mapPixels(img, func(r, g, b, a uint8) (uint8, uint8, uint8, uint8) {
+		y, cb, cr := color.RGBToYCbCr(r, g, b)
+		r, g, b = color.YCbCrToRGB(lut[y], cb, cr)
+		// As in BrightnessContrast: premultiplied colour can't exceed alpha.
+		return min(r, a), min(g, a), min(b, a), a
+	})
+}
+
+////// convolution: Gaussian blur is separable, so blurring rows and then columns with a 1-D
+// kernel gives the same result as a 2-D kernel, in O(r) per pixel instead of O(r*r). Pixels
+// past the edge repeat the edge pixel.
+
+// channels holds an image as floats, so intermediate passes don't round.
+type channels struct {
+	w, h int
+	v    []float64 // r, g, b, a per pixel, row by row
+}
+
+func toChannels(img *image.RGBA) channels {
+	b := img.Bounds()
+	c := channels{b.Dx(), b.Dy(), make([]float64, 4*b.Dx()*b.Dy())}
+	for y := range c.h {
+		i := img.PixOffset(b.Min.X, b.Min.Y+y)
+		for k := range 4 * c.w {
+			c.v[4*c.w*y+k] = float64(img.Pix[i+k])
+		}
+	}
+	return c
+}
+
+func (c channels) toRGBA(r image.Rectangle) *image.RGBA {
+	out := image.NewRGBA(r)
+	for y := range c.h {
+		o := out.PixOffset(r.Min.X, r.Min.Y+y)
+		for k := range 4 * c.w {
+			out.Pix[o+k] = clamp(c.v[4*c.w*y+k])
+		}
+	}
+	return out
+}
+
+// convolve1D runs kernel (centred on its middle element) along x or y.
+func (c channels) convolve1D(kernel []float64, vertical bool) channels {
+	out := channels{c.w, c.h, make([]float64, len(c.v))}
+	r := len(kernel) / 2
+	for y := range c.h {
+		for x := range c.w {
+			var sum [4]float64
+			for k, wt := range kernel {
+				sx, sy := x, y
+				if vertical {
+					sy = min(max(y+k-r, 0), c.h-1)
+				} else {
+					sx = min(max(x+k-r, 0), c.w-1)
+				}
+				i := 4 * (sy*c.w + sx)
+				for ch := range 4 {
+					sum[ch] += wt * c.v[i+ch]
+				}
+			}
+			copy(out.v[4*(y*c.w+x):], sum[:])
+		}
+	}
+	return out
+}
+
+// GaussianKernel returns a normalised kernel covering three standard deviations each way.
+func GaussianKernel(sigma float64) []float64 {
+	r := int(math.Ceil(3 * sigma))
+	k := make([]float64, 2*r+1)
+	sum := 0.0
+	for i := range k {
+		x := float64(i - r)
+		k[i] = math.Exp(-x * x / (2 * sigma * sigma))
+		sum += k[i]
+	}
+	for i := range k {
+		k[i] /= sum
+	}
+	return k
+}
+
+func GaussianBlur(img *image.RGBA, sigma float64) *image.RGBA {
+	if sigma <= 0 {
+		return mapPixels(img, func(r, g, b, a uint8) (uint8, uint8, uint8, uint8) { return r, g, b, a })
+	}
+	k := GaussianKernel(sigma)
+	return toChannels(img).convolve1D(k, false).convolve1D(k, true).toRGBA(img.Bounds())
+}
+
+// Sobel finds edges: it estimates the brightness gradient with two 3x3 kernels and draws its
+// magnitude as grey, so flat areas are black and sharp edges white. Each Sobel kernel is
+// itself separable into a [1 2 1] smoothing and a [-1 0 1] difference.
+func Sobel(img *image.RGBA) *image.RGBA {
+	gray := toChannels(Grayscale(img))
+	smooth, diff := []float64{1, 2, 1}, []float64{-1, 0, 1}
+	gx := gray.convolve1D(diff, false).convolve1D(smooth, true)
+	gy := gray.convolve1D(smooth, false).convolve1D(diff, true)
+	out := channels{gray.w, gray.h, make([]float64, len(gray.v))}
+	for i := 0; i < len(out.v); i += 4 {
+		// The largest possible gradient, 4*255 in both directions, maps to white.
+		m := math.Hypot(gx.v[i], gy.v[i]) / (4 * math.Sqrt2)
+		out.v[i], out.v[i+1], out.v[i+2], out.v[i+3] = m, m, m, 255
+	}
+	return out.toRGBA(img.Bounds())
+}
+
+////// resizing: every output pixel centre maps back to a point in the input, and the methods
+// differ in how they sample around it. Nearest takes the closest pixel, bilinear blends the
+// four around it, and Lanczos weighs a wider window with a windowed sinc, which keeps edges
+// sharper. Lanczos is separable too, so it runs as a horizontal then a vertical pass.
+
+type ResizeMethod int
+
+const (
+	Nearest ResizeMethod = iota
+	Bilinear
+	Lanczos
+)
+
+var resizeMethodNames = map[ResizeMethod]string{
+	Nearest:  "nearest",
+	Bilinear: "bilinear",
+	Lanczos:  "lanczos",
+}
+
+func (m ResizeMethod) String() string {
+	return resizeMethodNames[m]
+}
+
+// Resize scales img to w x h; the result starts at (0, 0). A size of zero or less gives an
+// empty image, and an empty img a transparent one, since there is nothing to sample.
+func Resize(img *image.RGBA, w, h int, method ResizeMethod) *image.RGBA {
+	if w <= 0 || h <= 0 || img.Bounds().Empty() {
+		return image.NewRGBA(image.Rect(0, 0, max(w, 0), max(h, 0)))
+	}
+	src := toChannels(img)
+	var out channels
+	switch method {
+	case Nearest:
+		out = src.resample(w, h, nearestKernel, 0.5)
+	case Bilinear:
+		out = src.resample(w, h, triangleKernel, 1)
+	default:
+		out = src.resample(w, h, lanczosKernel, 3)
+	}
+	return out.toRGBA(image.Rect(0, 0, w, h))
+}
+
+func nearestKernel(x float64) float64 {
+	if x > -0.5 && x <= 0.5 {
+		return 1
+	}
+	return 0
+}
+
+func triangleKernel(x float64) float64 {
+	return max(0, 1-math.Abs(x))
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+func lanczosKernel(x float64) float64 {
+	if math.Abs(x) >= 3 {
+		return 0
+	}
+	return sinc(x) * sinc(x/3)
+}
+
+// resample scales in x, then in y. When shrinking, the kernel is stretched by the scale
+// factor so every input pixel still contributes, which avoids aliasing.
+func (c channels) resample(w, h int, kernel func(float64) float64, support float64) channels {
+	return c.resample1D(w, kernel, support, false).resample1D(h, kernel, support, true)
+}
+
+func (c channels) resample1D(n int, kernel func(float64) float64, support float64, vertical bool) channels {
+	// others is the number of rows (or columns) the pass runs along.
+	srcN, w, h, others := c.w, n, c.h, c.h
+	if vertical {
+		srcN, w, h, others = c.h, c.w, n, c.w
+	}
+	out := channels{w, h, make([]float64, 4*w*h)}
+	scale := float64(srcN) / float64(n)
+	stretch := max(1, scale)
+	if support < 1 {
+		// Nearest neighbour is never stretched.
+		stretch = 1
+	}
+	for j := range n {
+		// The output pixel's centre, in input coordinates.
+		centre := (float64(j)+0.5)*scale - 0.5
+		lo := int(math.Floor(centre - support*stretch))
+		hi := int(math.Ceil(centre + support*stretch))
+		var weights []float64
+		total := 0.0
+		for s := lo; s <= hi; s++ {
+			wt := kernel((float64(s) - centre) / stretch)
+			weights = append(weights, wt)
+			total += wt
+		}
+		for other := range others {
+			var sum [4]float64
+			for k, wt := range weights {
+				if wt == 0 {
+					continue
+				}
+				s := min(max(lo+k, 0), srcN-1)
+				i := 4 * (other*c.w + s)
+				if vertical {
+					i = 4 * (s*c.w + other)
+				}
+				for ch := range 4 {
+					sum[ch] += wt * c.v[i+ch]
+				}
+			}
+			o := 4 * (other*w + j)
+			if vertical {
+				o = 4 * (j*w + other)
+			}
+			for ch := range 4 {
+				out.v[o+ch] = sum[ch] / total
+			}
+		}
+	}
+	return out
+}
+
+////// rotation by quarter turns is just moving pixels, so it's exact.
+
+// Rotate turns img clockwise by quarters*90 degrees; the result starts at (0, 0).
+func Rotate(img *image.RGBA, quarters int) *image.RGBA {
+	quarters = ((quarters % 4) + 4) % 4
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	ow, oh := w, h
+	if quarters%2 == 1 {
+		ow, oh = h, w
+	}
+	out := image.NewRGBA(image.Rect(0, 0, ow, oh))
+	for y := range h {
+		for x := range w {
+			var ox, oy int
+			switch quarters {
+			case 0:
+				ox, oy = x, y
+			case 1:
+				ox, oy = h-1-y, x
+			case 2:
+				ox, oy = w-1-x, h-1-y
+			case 3:
+				ox, oy = y, w-1-x
+			}
+			out.SetRGBA(ox, oy, img.RGBAAt(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return out
+}
+
+// synthetic returns a w x h image from a function, for the demos below and the tests.
+func synthetic(w, h int, f func(x, y int) color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			img.SetRGBA(x, y, f(x, y))
+		}
+	}
+	return img
+}
+
+func solid(c color.RGBA) func(x, y int) color.RGBA {
+	return func(x, y int) color.RGBA { return c }
+}
+
+func gray(v uint8) color.RGBA {
+	return color.RGBA{v, v, v, 255}
+}
+
+// row returns the red channel along row y.
+func row(img *image.RGBA, y int) []uint8 {
+	var out []uint8
+	for x := img.Bounds().Min.X; x < img.Bounds().Max.X; x++ {
+		out = append(out, img.RGBAAt(x, y).R)
+	}
+	return out
+}
+
+func tryPointFilters() {
+	// 31-image-filters_test.go checks every filter on small synthetic images like these.
+	steps := synthetic(5, 1, func(x, y int) color.RGBA { return gray([]uint8{0, 64, 128, 192, 255}[x]) })
+	fmt.Println("steps:       ", row(steps, 0))
+	fmt.Println("contrast 2:  ", row(BrightnessContrast(steps, 0, 2), 0))
+	fmt.Println("brighter:    ", row(BrightnessContrast(steps, 0.25, 1), 0))
+
+	rgb := synthetic(3, 1, func(x, y int) color.RGBA {
+		return []color.RGBA{{255, 0, 0, 255}, {0, 255, 0, 255}, {0, 0, 255, 255}}[x]
+	})
+	fmt.Println("red, green, blue in grey:", row(Grayscale(rgb), 0))
+
+	dull := synthetic(4, 1, func(x, y int) color.RGBA { return gray(uint8(100 + x)) })
+	fmt.Println("equalized dull greys:", row(Equalize(dull), 0))
+}
+
+func tryConvolution() {
+	dot := synthetic(5, 5, func(x, y int) color.RGBA {
+		if x == 2 && y == 2 {
+			return gray(255)
+		}
+		return gray(0)
+	})
+	blurred := GaussianBlur(dot, 1)
+	fmt.Println("blurred dot:")
+	for y := range 5 {
+		fmt.Println("  ", row(blurred, y))
+	}
+
+	edge := synthetic(6, 3, func(x, y int) color.RGBA {
+		if x < 3 {
+			return gray(0)
+		}
+		return gray(255)
+	})
+	fmt.Println("sobel across an edge:", row(Sobel(edge), 1))
+}
+
+func tryGeometry() {
+	quad := synthetic(2, 2, func(x, y int) color.RGBA { return gray(uint8(10 + 10*x + 100*y)) })
+	for _, m := range []ResizeMethod{Nearest, Bilinear, Lanczos} {
+		big := Resize(quad, 4, 4, m)
+		fmt.Printf("%-8s 2x2 -> 4x4: %v %v\n", m, row(big, 0), row(big, 3))
+	}
+
+	r := synthetic(3, 2, func(x, y int) color.RGBA { return gray(uint8(1 + x + 3*y)) })
+	for q := range 4 {
+		img := Rotate(r, q)
+		fmt.Printf("rotated %3d: %v first row %v\n", 90*q, img.Bounds().Size(), row(img, 0))
+	}
+}
+
+func main() {
+	// point filters
+	tryPointFilters()
+
+	// blur and edges
+	tryConvolution()
+
+	// resizing and rotation
+	tryGeometry()
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"slices"
+	"testing"
+)
+
+// rowTest checks one row of a filter's output.
+type rowTest struct {
+	name string
+	img  func() *image.RGBA
+	y    int
+	want []uint8
+}
+
+func runRows(t *testing.T, tests []rowTest) {
+	t.Helper()
+	for _, tt := range tests {
+		if got := row(tt.img(), tt.y); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: row %d = %v, want %v", tt.name, tt.y, got, tt.want)
+		}
+	}
+}
+
+func TestPointFilters(t *testing.T) {
+	rgb := synthetic(3, 1, func(x, y int) color.RGBA {
+		return []color.RGBA{{255, 0, 0, 255}, {0, 255, 0, 255}, {0, 0, 255, 255}}[x]
+	})
+	steps := synthetic(5, 1, func(x, y int) color.RGBA { return gray([]uint8{0, 64, 128, 192, 255}[x]) })
+	// Four equally common dull greys spread to the full range.
+	dull := synthetic(4, 1, func(x, y int) color.RGBA { return gray(uint8(100 + x)) })
+
+	runRows(t, []rowTest{
+		// Red, green and blue each turn into their luma weight.
+		{"grayscale weights", func() *image.RGBA { return Grayscale(rgb) }, 0, []uint8{76, 150, 29}},
+		{"contrast 2", func() *image.RGBA { return BrightnessContrast(steps, 0, 2) }, 0, []uint8{0, 0, 128, 255, 255}},
+		{"contrast 0", func() *image.RGBA { return BrightnessContrast(steps, 0, 0) }, 0, []uint8{128, 128, 128, 128, 128}},
+		{"brightness +0.25", func() *image.RGBA { return BrightnessContrast(steps, 0.25, 1) }, 0, []uint8{64, 128, 192, 255, 255}},
+		{"equalize", func() *image.RGBA { return Equalize(dull) }, 0, []uint8{0, 85, 170, 255}},
+		{"equalize flat image", func() *image.RGBA { return Equalize(synthetic(2, 1, solid(gray(90)))) }, 0, []uint8{90, 90}},
+	})
+
+	// The 100x100 image from 4-methods.go: transparent black, and it stays that way.
+	if got := Grayscale(image.NewRGBA(image.Rect(0, 0, 100, 100))).RGBAAt(0, 0); got != (color.RGBA{}) {
+		t.Errorf("grayscale of an empty image = %v, want transparent black", got)
+	}
+	// Premultiplied colours never exceed their alpha.
+	half := synthetic(1, 1, solid(color.RGBA{100, 100, 100, 128}))
+	if got, want := BrightnessContrast(half, 1, 1).RGBAAt(0, 0), (color.RGBA{128, 128, 128, 128}); got != want {
+		t.Errorf("brightness of a half-transparent pixel = %v, want %v", got, want)
+	}
+	// The brightest of these half-transparent greys would become 255, above its alpha.
+	faint := synthetic(4, 1, func(x, y int) color.RGBA { return color.RGBA{uint8(50 + x), uint8(50 + x), uint8(50 + x), 128} })
+	if got, want := Equalize(faint).RGBAAt(3, 0), (color.RGBA{128, 128, 128, 128}); got != want {
+		t.Errorf("equalize of a half-transparent pixel = %v, want %v", got, want)
+	}
+}
+
+func TestGaussianKernel(t *testing.T) {
+	k := GaussianKernel(1)
+	sum := 0.0
+	for _, v := range k {
+		sum += v
+	}
+	if len(k) != 7 || math.Abs(sum-1) > 1e-9 {
+		t.Errorf("GaussianKernel(1) has %d taps summing to %v, want 7 summing to 1", len(k), sum)
+	}
+}
+
+func TestConvolution(t *testing.T) {
+	// A single white dot spreads out but keeps its total.
+	dot := synthetic(9, 9, func(x, y int) color.RGBA {
+		if x == 4 && y == 4 {
+			return gray(255)
+		}
+		return gray(0)
+	})
+	blurred := GaussianBlur(dot, 1)
+	total := 0
+	for y := range 9 {
+		for _, v := range row(blurred, y) {
+			total += int(v)
+		}
+	}
+	// Rounding each pixel loses or gains a little.
+	if total < 245 || total > 265 {
+		t.Errorf("blurred dot sums to %d, want about 255", total)
+	}
+	if got := blurred.RGBAAt(4, 4).R; got != 41 {
+		t.Errorf("blur peak = %d, want 41", got)
+	}
+	for _, pair := range [][2]image.Point{{{3, 4}, {5, 4}}, {{4, 3}, {4, 5}}, {{3, 3}, {5, 5}}} {
+		a, b := blurred.RGBAAt(pair[0].X, pair[0].Y), blurred.RGBAAt(pair[1].X, pair[1].Y)
+		if a != b {
+			t.Errorf("blur not symmetric: %v is %v, %v is %v", pair[0], a, pair[1], b)
+		}
+	}
+	if got := GaussianBlur(synthetic(8, 8, solid(gray(77))), 2).RGBAAt(3, 3); got != gray(77) {
+		t.Errorf("blur of a flat image = %v, want %v", got, gray(77))
+	}
+
+	// A vertical edge between x=2 and x=3: the gradient is there and nowhere else.
+	edge := synthetic(6, 3, func(x, y int) color.RGBA {
+		if x < 3 {
+			return gray(0)
+		}
+		return gray(255)
+	})
+	runRows(t, []rowTest{
+		// 4*255 across the edge, scaled by 1/(4*sqrt(2)): 180.
+		{"sobel vertical edge", func() *image.RGBA { return Sobel(edge) }, 1, []uint8{0, 0, 180, 180, 0, 0}},
+		{"sobel flat image", func() *image.RGBA { return Sobel(synthetic(4, 4, solid(gray(200)))) }, 1, []uint8{0, 0, 0, 0}},
+	})
+}
+
+func TestResize(t *testing.T) {
+	quad := synthetic(2, 2, func(x, y int) color.RGBA { return gray(uint8(10 + 10*x + 100*y)) })
+	ramp := synthetic(2, 1, func(x, y int) color.RGBA { return gray(uint8(255 * x)) })
+	pairs := synthetic(4, 1, func(x, y int) color.RGBA { return gray([]uint8{0, 100, 200, 200}[x]) })
+
+	runRows(t, []rowTest{
+		// 2x2 -> 4x4 nearest neighbour: each pixel becomes a 2x2 block.
+		{"nearest", func() *image.RGBA { return Resize(quad, 4, 4, Nearest) }, 0, []uint8{10, 10, 20, 20}},
+		{"nearest", func() *image.RGBA { return Resize(quad, 4, 4, Nearest) }, 3, []uint8{110, 110, 120, 120}},
+		// Bilinear between 0 and 255 over 4 pixels: centres at -0.25, 0.25, 0.75, 1.25.
+		{"bilinear upscale", func() *image.RGBA { return Resize(ramp, 4, 1, Bilinear) }, 0, []uint8{0, 64, 191, 255}},
+		// Halving stretches the triangle over four pixels, weighted 1:3:3:1 around each pair, so
+		// 0 0 | 0 100 | 200 200 | 200 gives (0+0+300+200)/8 and (100+600+600+200)/8.
+		{"bilinear downscale", func() *image.RGBA { return Resize(pairs, 2, 1, Bilinear) }, 0, []uint8{63, 188}},
+		// Lanczos keeps flat areas flat.
+		{"lanczos of flat image", func() *image.RGBA { return Resize(synthetic(5, 7, solid(gray(123))), 4, 3, Lanczos) }, 1, []uint8{123, 123, 123, 123}},
+		{"lanczos at same size", func() *image.RGBA { return Resize(quad, 2, 2, Lanczos) }, 0, []uint8{10, 20}},
+	})
+
+	empty := image.NewRGBA(image.Rect(0, 0, 0, 0))
+	sizes := []struct {
+		name string
+		img  *image.RGBA
+		want image.Point
+	}{
+		{"lanczos", Resize(synthetic(5, 7, solid(gray(123))), 13, 3, Lanczos), image.Pt(13, 3)},
+		{"empty source", Resize(empty, 3, 2, Bilinear), image.Pt(3, 2)},
+		{"zero width", Resize(quad, 0, 5, Lanczos), image.Pt(0, 5)},
+		{"negative size", Resize(quad, -4, -1, Nearest), image.Pt(0, 0)},
+	}
+	for _, tt := range sizes {
+		if got := tt.img.Bounds().Size(); got != tt.want {
+			t.Errorf("%s: size %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if got := Resize(empty, 3, 2, Bilinear).RGBAAt(2, 1); got != (color.RGBA{}) {
+		t.Errorf("resized empty image = %v, want transparent black", got)
+	}
+}
+
+func TestRotate(t *testing.T) {
+	// The top-left pixel of a 3x2 image ends up top-right after a quarter turn.
+	r := synthetic(3, 2, func(x, y int) color.RGBA { return gray(uint8(1 + x + 3*y)) })
+	r90 := Rotate(r, 1)
+	if got := r90.Bounds().Size(); got != image.Pt(2, 3) {
+		t.Errorf("quarter turn: size %v, want (2,3)", got)
+	}
+	runRows(t, []rowTest{
+		{"rotate 90", func() *image.RGBA { return r90 }, 0, []uint8{4, 1}},
+		{"rotate 90", func() *image.RGBA { return r90 }, 1, []uint8{5, 2}},
+		{"rotate 90", func() *image.RGBA { return r90 }, 2, []uint8{6, 3}},
+		{"rotate 180", func() *image.RGBA { return Rotate(r, 2) }, 0, []uint8{6, 5, 4}},
+		{"rotate -90", func() *image.RGBA { return Rotate(r, -1) }, 0, []uint8{3, 6}},
+		{"rotate 270", func() *image.RGBA { return Rotate(r, 3) }, 0, []uint8{3, 6}},
+	})
+	full := Rotate(Rotate(Rotate(Rotate(r, 1), 1), 1), 1)
+	if !slices.Equal(full.Pix, r.Pix) {
+		t.Errorf("four quarter turns: %v, want %v", full.Pix, r.Pix)
+	}
+}
+
+// A sub-image keeps its bounds through filters that don't change size.
+func TestSubImage(t *testing.T) {
+	sub := synthetic(4, 4, func(x, y int) color.RGBA { return gray(uint8(x * 60)) }).SubImage(image.Rect(1, 1, 3, 3)).(*image.RGBA)
+	if got, want := Grayscale(sub).Bounds(), image.Rect(1, 1, 3, 3); got != want {
+		t.Errorf("bounds %v, want %v", got, want)
+	}
+	if got := row(GaussianBlur(sub, 0), 1); !slices.Equal(got, []uint8{60, 120}) {
+		t.Errorf("row 1 = %v, want [60 120]", got)
+	}
+}
@@ -0,0 +1,427 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"iter"
+	"math/rand/v2"
+	"slices"
+	"strings"
+)
+
+////// keeping things in order: trySorting and trySortingByFunctions (7-extra.go) sort a whole slice
+// once. When values keep arriving, re-sorting after each one is wasteful. A priority queue
+// always knows its smallest element, in O(log n) per push or pop; an ordered map keeps every
+// key in order, so it can also answer "what's the nearest key to x".
+
+////// priority queue: a binary heap in a slice. Element i's children are 2i+1 and 2i+2, and no
+// child comes before its parent, so the first element is the one cmp puts first. Push adds at
+// the end and moves the element up; Pop swaps the last element in and moves it down.
+
+// Item is a handle to a value in a PriorityQueue, for Update and Remove.
+type Item[T any] struct {
+	Value T
+	index int // -1 once the item has left the queue
+}
+
+type PriorityQueue[T any] struct {
+	items []*Item[T]
+	cmp   func(a, b T) int
+}
+
+// NewPriorityQueue returns a queue that pops the value cmp orders first: cmp.Compare gives a
+// min-queue, and swapping its arguments a max-queue.
+func NewPriorityQueue[T any](cmp func(a, b T) int) *PriorityQueue[T] {
+	return &PriorityQueue[T]{cmp: cmp}
+}
+
+func (pq *PriorityQueue[T]) Len() int {
+	return len(pq.items)
+}
+
+// Push adds v and returns its handle.
+func (pq *PriorityQueue[T]) Push(v T) *Item[T] {
+	it := &Item[T]{Value: v, index: len(pq.items)}
+	pq.items = append(pq.items, it)
+	pq.up(it.index)
+	return it
+}
+
+// Peek returns the first value without removing it.
+func (pq *PriorityQueue[T]) Peek() (T, bool) {
+	if len(pq.items) == 0 {
+		var zero T
+		return zero, false
+	}
+	return pq.items[0].Value, true
+}
+
+// Pop removes and returns the first value.
+func (pq *PriorityQueue[T]) Pop() (T, bool) {
+	if len(pq.items) == 0 {
+		var zero T
+		return zero, false
+	}
+	return pq.Remove(pq.items[0]), true
+}
+
+// Update changes an item's value and restores the heap order around it.
+func (pq *PriorityQueue[T]) Update(it *Item[T], v T) {
+	if !pq.contains(it) {
+		return
+	}
+	it.Value = v
+	pq.fix(it.index)
+}
+
+// Remove takes an item out of the queue wherever it is and returns its value. Removing an item
+// that already left does nothing.
+func (pq *PriorityQueue[T]) Remove(it *Item[T]) T {
+	if !pq.contains(it) {
+		return it.Value
+	}
+	i, last := it.index, len(pq.items)-1
+	pq.swap(i, last)
+	pq.items[last] = nil
+	pq.items = pq.items[:last]
+	if i < last {
+		pq.fix(i)
+	}
+	it.index = -1
+	return it.Value
+}
+
+// Drain pops every value in order.
+func (pq *PriorityQueue[T]) Drain() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for pq.Len() > 0 {
+			v, _ := pq.Pop()
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+func (pq *PriorityQueue[T]) contains(it *Item[T]) bool {
+	return it.index >= 0 && it.index < len(pq.items) && pq.items[it.index] == it
+}
+
+func (pq *PriorityQueue[T]) less(i, j int) bool {
+	return pq.cmp(pq.items[i].Value, pq.items[j].Value) < 0
+}
+
+func (pq *PriorityQueue[T]) swap(i, j int) {
+	pq.items[i], pq.items[j] = pq.items[j], pq.items[i]
+	pq.items[i].index = i
+	pq.items[j].index = j
+}
+
+// fix moves element i up or down, whichever its new value needs.
+func (pq *PriorityQueue[T]) fix(i int) {
+	if !pq.down(i) {
+		pq.up(i)
+	}
+}
+
+func (pq *PriorityQueue[T]) up(i int) {
+	for i > 0 {
+		parent := (i - 1) / 2
+		if !pq.less(i, parent) {
+			return
+		}
+		pq.swap(i, parent)
+		i = parent
+	}
+}
+
+// down reports whether the element moved.
+func (pq *PriorityQueue[T]) down(i int) bool {
+	start := i
+	for {
+		first := i
+		for _, child := range []int{2*i + 1, 2*i + 2} {
+			if child < len(pq.items) && pq.less(child, first) {
+				first = child
+			}
+		}
+		if first == i {
+			return i > start
+		}
+		pq.swap(i, first)
+		i = first
+	}
+}
+
+////// ordered map: a skip list. Every key is in a sorted linked list on level 0; each node is
+// also on level 1 with probability 1/4, on level 2 with 1/16, and so on. A search runs along
+// the highest level until the next key would overshoot, then drops a level, which skips most
+// nodes and takes O(log n) expected time. Unlike a balanced tree, nothing ever needs to be
+// rebalanced: inserting and deleting only splice a node in or out of each level it is on.
+
+// Keys are ordered with cmp.Compare rather than < and ==: a NaN key is not equal to itself
+// under ==, so it could be added any number of times and never found again. cmp.Compare puts
+// NaN before every other float and treats NaNs as equal.
+
+const maxLevel = 24
+
+type node[K cmp.Ordered, V any] struct {
+	key   K
+	value V
+	next  []*node[K, V] // next[l] is the following node on level l
+}
+
+// OrderedMap is a map that iterates in key order. The zero value is not usable; call
+// NewOrderedMap.
+type OrderedMap[K cmp.Ordered, V any] struct {
+	head  *node[K, V] // a sentinel before the first key, on every level
+	level int         // levels in use
+	len   int
+	rng   *rand.Rand
+}
+
+func NewOrderedMap[K cmp.Ordered, V any]() *OrderedMap[K, V] {
+	return &OrderedMap[K, V]{
+		head:  &node[K, V]{next: make([]*node[K, V], maxLevel)},
+		level: 1,
+		rng:   rand.New(rand.NewPCG(1, 2)),
+	}
+}
+
+func (m *OrderedMap[K, V]) Len() int {
+	return m.len
+}
+
+// search fills update[l] with the last node on level l whose key is below k, and returns the
+// first node at or after k.
+func (m *OrderedMap[K, V]) search(k K, update []*node[K, V]) *node[K, V] {
+	x := m.head
+	for l := m.level - 1; l >= 0; l-- {
+		for x.next[l] != nil && cmp.Less(x.next[l].key, k) {
+			x = x.next[l]
+		}
+		if update != nil {
+			update[l] = x
+		}
+	}
+	return x.next[0]
+}
+
+func (m *OrderedMap[K, V]) Get(k K) (V, bool) {
+	if n := m.search(k, nil); n != nil && cmp.Compare(n.key, k) == 0 {
+		return n.value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Set adds or replaces the value for k.
+func (m *OrderedMap[K, V]) Set(k K, v V) {
+	update := make([]*node[K, V], maxLevel)
+	if n := m.search(k, update); n != nil && cmp.Compare(n.key, k) == 0 {
+		n.value = v
+		return
+	}
+	level := 1
+	for level < maxLevel && m.rng.IntN(4) == 0 {
+		level++
+	}
+	for l := m.level; l < level; l++ {
+		update[l] = m.head
+	}
+	m.level = max(m.level, level)
+	n := &node[K, V]{key: k, value: v, next: make([]*node[K, V], level)}
+	for l := range level {
+		n.next[l] = update[l].next[l]
+		update[l].next[l] = n
+	}
+	m.len++
+}
+
+// Delete removes k and reports whether it was there.
+func (m *OrderedMap[K, V]) Delete(k K) bool {
+	update := make([]*node[K, V], maxLevel)
+	n := m.search(k, update)
+	if n == nil || cmp.Compare(n.key, k) != 0 {
+		return false
+	}
+	for l := range n.next {
+		update[l].next[l] = n.next[l]
+	}
+	for m.level > 1 && m.head.next[m.level-1] == nil {
+		m.level--
+	}
+	m.len--
+	return true
+}
+
+// Min returns the smallest key.
+func (m *OrderedMap[K, V]) Min() (K, V, bool) {
+	return entry(m.head.next[0])
+}
+
+// Max returns the largest key, walking down the levels from the top.
+func (m *OrderedMap[K, V]) Max() (K, V, bool) {
+	x := m.head
+	for l := m.level - 1; l >= 0; l-- {
+		for x.next[l] != nil {
+			x = x.next[l]
+		}
+	}
+	if x == m.head {
+		return entry[K, V](nil)
+	}
+	return entry(x)
+}
+
+// Ceiling returns the smallest key >= k.
+func (m *OrderedMap[K, V]) Ceiling(k K) (K, V, bool) {
+	return entry(m.search(k, nil))
+}
+
+// Floor returns the largest key <= k.
+func (m *OrderedMap[K, V]) Floor(k K) (K, V, bool) {
+	update := make([]*node[K, V], maxLevel)
+	if n := m.search(k, update); n != nil && cmp.Compare(n.key, k) == 0 {
+		return entry(n)
+	}
+	// update[0] is the last node below k.
+	if update[0] == m.head {
+		return entry[K, V](nil)
+	}
+	return entry(update[0])
+}
+
+func entry[K cmp.Ordered, V any](n *node[K, V]) (K, V, bool) {
+	if n == nil {
+		var k K
+		var v V
+		return k, v, false
+	}
+	return n.key, n.value, true
+}
+
+// All yields every key and value in key order.
+func (m *OrderedMap[K, V]) All() iter.Seq2[K, V] {
+	return m.from(m.head.next[0], nil)
+}
+
+// Range yields the keys in [lo, hi) in order.
+func (m *OrderedMap[K, V]) Range(lo, hi K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.from(m.search(lo, nil), &hi)(yield)
+	}
+}
+
+// Keys yields the keys in order.
+func (m *OrderedMap[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for k := range m.All() {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+func (m *OrderedMap[K, V]) from(n *node[K, V], hi *K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for ; n != nil && (hi == nil || cmp.Less(n.key, *hi)); n = n.next[0] {
+			if !yield(n.key, n.value) {
+				return
+			}
+		}
+	}
+}
+
+func (m *OrderedMap[K, V]) String() string {
+	var sb strings.Builder
+	sb.WriteString("map[")
+	for k, v := range m.All() {
+		if sb.Len() > 4 {
+			sb.WriteString(" ")
+		}
+		fmt.Fprintf(&sb, "%v:%v", k, v)
+	}
+	sb.WriteString("]")
+	return sb.String()
+}
+
+type Person struct {
+	name string
+	age  int
+}
+
+func tryPriorityQueue() {
+	// The ints from trySorting, arriving one at a time.
+	ints := NewPriorityQueue(cmp.Compare[int])
+	for _, v := range []int{7, 2, 4} {
+		ints.Push(v)
+		first, _ := ints.Peek()
+		fmt.Println("pushed", v, "first is", first)
+	}
+	fmt.Println(slices.Collect(ints.Drain()))
+
+	// The people from trySortingByFunctions, oldest first.
+	people := NewPriorityQueue(func(a, b Person) int { return cmp.Compare(b.age, a.age) })
+	jax := people.Push(Person{"Jax", 37})
+	tj := people.Push(Person{"TJ", 25})
+	people.Push(Person{"Alex", 72})
+	fmt.Println(people.Peek())
+
+	// Handles: TJ has a birthday or fifty, Jax leaves.
+	people.Update(tj, Person{"TJ", 75})
+	fmt.Println(people.Peek())
+	fmt.Println(people.Remove(jax), people.Len())
+	people.Remove(jax) // already gone: no effect
+	for p := range people.Drain() {
+		fmt.Println(p)
+	}
+	_, ok := people.Pop()
+	fmt.Println(ok)
+
+}
+
+func tryOrderedMap() {
+	// Fruits by name, in order whatever order they were added in.
+	fruits := NewOrderedMap[string, int]()
+	for _, f := range []string{"peach", "banana", "kiwi", "apple", "mango"} {
+		fruits.Set(f, len(f))
+	}
+	fmt.Println(fruits, fruits.Len())
+	fruits.Set("kiwi", 40)
+	fruits.Delete("banana")
+	fmt.Println(fruits, fruits.Delete("banana"))
+	fmt.Println(fruits.Get("kiwi"))
+	fmt.Println(fruits.Min())
+	fmt.Println(fruits.Max())
+
+	// Nearest keys and ranges.
+	ages := NewOrderedMap[int, string]()
+	for _, p := range []Person{{"Jax", 37}, {"TJ", 25}, {"Alex", 72}} {
+		ages.Set(p.age, p.name)
+	}
+	fmt.Println(ages.Floor(40))
+	fmt.Println(ages.Ceiling(40))
+	fmt.Println(ages.Floor(10))
+	fmt.Println(ages.Ceiling(80))
+	for age, name := range ages.Range(20, 40) {
+		fmt.Println(" ", age, name)
+	}
+	fmt.Println(slices.Collect(ages.Keys()))
+
+	// Breaking out of a range loop stops the iteration.
+	for k := range fruits.All() {
+		fmt.Println("first key:", k)
+		break
+	}
+
+}
+
+func main() {
+	// priority queue
+	tryPriorityQueue()
+
+	// ordered map
+	tryOrderedMap()
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"cmp"
+	"maps"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestPriorityQueue(t *testing.T) {
+	pq := NewPriorityQueue(cmp.Compare[int])
+	if _, ok := pq.Peek(); ok {
+		t.Error("Peek on an empty queue reported a value")
+	}
+	if _, ok := pq.Pop(); ok {
+		t.Error("Pop on an empty queue reported a value")
+	}
+	for _, v := range []int{7, 2, 4, 2, 9} {
+		pq.Push(v)
+	}
+	if first, _ := pq.Peek(); first != 2 {
+		t.Errorf("Peek = %d, want 2", first)
+	}
+	if got, want := slices.Collect(pq.Drain()), []int{2, 2, 4, 7, 9}; !slices.Equal(got, want) {
+		t.Errorf("Drain = %v, want %v", got, want)
+	}
+}
+
+func TestPriorityQueueHandles(t *testing.T) {
+	// Oldest first.
+	people := NewPriorityQueue(func(a, b Person) int { return cmp.Compare(b.age, a.age) })
+	jax := people.Push(Person{"Jax", 37})
+	tj := people.Push(Person{"TJ", 25})
+	alex := people.Push(Person{"Alex", 72})
+
+	people.Update(tj, Person{"TJ", 75})
+	if p, _ := people.Peek(); p.name != "TJ" {
+		t.Errorf("after moving TJ up: Peek = %v", p)
+	}
+	people.Update(tj, Person{"TJ", 5})
+	if p, _ := people.Peek(); p.name != "Alex" {
+		t.Errorf("after moving TJ down: Peek = %v", p)
+	}
+
+	if p := people.Remove(jax); p.name != "Jax" || people.Len() != 2 {
+		t.Errorf("Remove(jax) = %v, Len = %d", p, people.Len())
+	}
+	// A handle that left does nothing, whether removed or updated again.
+	people.Remove(jax)
+	people.Update(jax, Person{"Jax", 100})
+	if people.Len() != 2 {
+		t.Errorf("Len = %d after reusing a removed handle, want 2", people.Len())
+	}
+	if p, _ := people.Pop(); p != alex.Value {
+		t.Errorf("Pop = %v, want %v", p, alex.Value)
+	}
+	people.Remove(alex)
+	if p, _ := people.Pop(); p.name != "TJ" || people.Len() != 0 {
+		t.Errorf("Pop = %v with %d left", p, people.Len())
+	}
+}
+
+// A random mix of pushes, updates and removes always pops in sorted order.
+func TestPriorityQueueRandom(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	pq := NewPriorityQueue(cmp.Compare[int])
+	var handles []*Item[int]
+	for range 2000 {
+		switch op := rng.IntN(10); {
+		case op < 6 || len(handles) == 0:
+			handles = append(handles, pq.Push(rng.IntN(1000)))
+		case op < 8:
+			pq.Update(handles[rng.IntN(len(handles))], rng.IntN(1000))
+		default:
+			pq.Remove(handles[rng.IntN(len(handles))])
+		}
+	}
+	var want []int
+	for _, h := range handles {
+		if pq.contains(h) {
+			want = append(want, h.Value)
+		}
+	}
+	slices.Sort(want)
+	if got := slices.Collect(pq.Drain()); !slices.Equal(got, want) {
+		t.Errorf("popped %v, want %v", got, want)
+	}
+}
+
+func TestOrderedMap(t *testing.T) {
+	fruits := NewOrderedMap[string, int]()
+	for _, f := range []string{"peach", "banana", "kiwi", "apple", "mango"} {
+		fruits.Set(f, len(f))
+	}
+	fruits.Set("kiwi", 40)
+	if !fruits.Delete("banana") || fruits.Delete("banana") {
+		t.Error("Delete should succeed once")
+	}
+	if got, want := fruits.String(), "map[apple:5 kiwi:40 mango:5 peach:5]"; got != want {
+		t.Errorf("String = %s, want %s", got, want)
+	}
+	if v, ok := fruits.Get("kiwi"); !ok || v != 40 {
+		t.Errorf("Get(kiwi) = %d, %v", v, ok)
+	}
+	if _, ok := fruits.Get("banana"); ok {
+		t.Error("Get(banana) found a deleted key")
+	}
+	if k, _, _ := fruits.Min(); k != "apple" {
+		t.Errorf("Min = %s", k)
+	}
+	if k, _, _ := fruits.Max(); k != "peach" {
+		t.Errorf("Max = %s", k)
+	}
+
+	empty := NewOrderedMap[int, int]()
+	if _, _, ok := empty.Min(); ok {
+		t.Error("Min of an empty map")
+	}
+	if _, _, ok := empty.Max(); ok {
+		t.Error("Max of an empty map")
+	}
+}
+
+func TestFloorCeiling(t *testing.T) {
+	ages := NewOrderedMap[int, string]()
+	for _, p := range []Person{{"Jax", 37}, {"TJ", 25}, {"Alex", 72}} {
+		ages.Set(p.age, p.name)
+	}
+	tests := []struct {
+		q                 int
+		floor, ceil       int
+		hasFloor, hasCeil bool
+	}{
+		{10, 0, 25, false, true},
+		{25, 25, 25, true, true},
+		{40, 37, 72, true, true},
+		{72, 72, 72, true, true},
+		{80, 72, 0, true, false},
+	}
+	for _, tt := range tests {
+		floor, _, hasFloor := ages.Floor(tt.q)
+		ceil, _, hasCeil := ages.Ceiling(tt.q)
+		if floor != tt.floor || hasFloor != tt.hasFloor || ceil != tt.ceil || hasCeil != tt.hasCeil {
+			t.Errorf("%d: Floor %d %v, Ceiling %d %v; want %d %v, %d %v",
+				tt.q, floor, hasFloor, ceil, hasCeil, tt.floor, tt.hasFloor, tt.ceil, tt.hasCeil)
+		}
+	}
+	for _, tt := range []struct {
+		lo, hi int
+		want   []int
+	}{
+		{20, 40, []int{25, 37}},
+		{25, 72, []int{25, 37}},
+		{26, 73, []int{37, 72}},
+		{40, 40, nil},
+	} {
+		var got []int
+		for k := range ages.Range(tt.lo, tt.hi) {
+			got = append(got, k)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Range(%d, %d) = %v, want %v", tt.lo, tt.hi, got, tt.want)
+		}
+	}
+}
+
+func TestOrderedMapNaN(t *testing.T) {
+	m := NewOrderedMap[float64, string]()
+	for _, v := range []string{"a", "b", "c"} {
+		m.Set(math.NaN(), v)
+	}
+	m.Set(1, "one")
+	if m.Len() != 2 {
+		t.Errorf("Len = %d after setting NaN three times and 1 once, want 2", m.Len())
+	}
+	if v, ok := m.Get(math.NaN()); !ok || v != "c" {
+		t.Errorf("Get(NaN) = %q, %v", v, ok)
+	}
+	if k, _, _ := m.Min(); !math.IsNaN(k) {
+		t.Errorf("Min = %v, want NaN", k)
+	}
+	if !m.Delete(math.NaN()) || m.Len() != 1 {
+		t.Errorf("Delete(NaN) left Len = %d", m.Len())
+	}
+}
+
+// Against a plain map and slices.Sort, over a random workload.
+func TestOrderedMapRandom(t *testing.T) {
+	rng := rand.New(rand.NewPCG(5, 6))
+	om := NewOrderedMap[int, int]()
+	ref := map[int]int{}
+	for i := range 20000 {
+		k := rng.IntN(2000)
+		if rng.IntN(3) == 0 {
+			_, had := ref[k]
+			delete(ref, k)
+			if om.Delete(k) != had {
+				t.Fatalf("Delete(%d) = %v, want %v", k, !had, had)
+			}
+		} else {
+			om.Set(k, i)
+			ref[k] = i
+		}
+	}
+	keys := slices.Sorted(maps.Keys(ref))
+	if om.Len() != len(ref) || !slices.Equal(slices.Collect(om.Keys()), keys) {
+		t.Fatalf("%d keys, want %d, or out of order", om.Len(), len(ref))
+	}
+	for k, v := range om.All() {
+		if ref[k] != v {
+			t.Errorf("[%d] = %d, want %d", k, v, ref[k])
+		}
+	}
+	for q := range 2100 {
+		i, found := slices.BinarySearch(keys, q)
+		floor, _, hasFloor := om.Floor(q)
+		ceil, _, hasCeil := om.Ceiling(q)
+		wantFloor, wantCeil := i-1, i
+		if found {
+			wantFloor = i
+		}
+		if hasFloor != (wantFloor >= 0) || (hasFloor && floor != keys[wantFloor]) {
+			t.Errorf("Floor(%d) = %d, %v", q, floor, hasFloor)
+		}
+		if hasCeil != (wantCeil < len(keys)) || (hasCeil && ceil != keys[wantCeil]) {
+			t.Errorf("Ceiling(%d) = %d, %v", q, ceil, hasCeil)
+		}
+	}
+}
@@ -0,0 +1,425 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+////// sort specs: trySortingByFunctions (7-extra.go) sorts Person by age with a hand-written
+// cmp.Compare closure. Sorting by age, then by name descending, with missing values at the end,
+// takes a closure full of if-statements. A Spec describes that order instead:
+//
+//	By(func(p Person) int { return p.age }).ThenBy(name, Desc).NullsLast()
+//
+// and sorts with slices.SortStableFunc, so people who compare equal keep their order.
+
+type Order int
+
+const (
+	Asc Order = iota
+	Desc
+)
+
+var orderNames = map[Order]string{
+	Asc:  "asc",
+	Desc: "desc",
+}
+
+func (o Order) String() string {
+	return orderNames[o]
+}
+
+// Key compares T values by one property. null, if set, reports values that have no such
+// property (a nil pointer, a NaN); where they go is up to the Spec.
+type Key[T any] struct {
+	compare func(a, b T) int
+	null    func(T) bool
+}
+
+// Field orders by a value that cmp.Compare can compare. NaN floats count as null.
+func Field[T any, K cmp.Ordered](f func(T) K) Key[T] {
+	return Key[T]{
+		compare: func(a, b T) int { return cmp.Compare(f(a), f(b)) },
+		null:    func(t T) bool { k := f(t); return k != k },
+	}
+}
+
+// Nullable orders by a pointer's target; nil pointers are null.
+func Nullable[T any, K cmp.Ordered](f func(T) *K) Key[T] {
+	return Key[T]{
+		compare: func(a, b T) int { return cmp.Compare(*f(a), *f(b)) },
+		null:    func(t T) bool { return f(t) == nil },
+	}
+}
+
+// Text orders strings with a Collation.
+func Text[T any](f func(T) string, c Collation) Key[T] {
+	return Key[T]{compare: func(a, b T) int { return c.Compare(f(a), f(b)) }}
+}
+
+type sortKey[T any] struct {
+	Key[T]
+	order     Order
+	nullsLast bool
+}
+
+// Spec is a list of keys, each used only to break ties in the ones before it.
+type Spec[T any] struct {
+	keys []sortKey[T]
+}
+
+// By starts a Spec with a field key.
+func By[T any, K cmp.Ordered](f func(T) K, order ...Order) *Spec[T] {
+	return ByKey(Field(f), order...)
+}
+
+// ByKey starts a Spec with any Key.
+func ByKey[T any](k Key[T], order ...Order) *Spec[T] {
+	return new(Spec[T]).ThenBy(k, order...)
+}
+
+// ThenBy returns a new Spec with k added, ascending unless Desc is given. Like every builder
+// method it leaves s alone, so one Spec can be the base of several others.
+func (s *Spec[T]) ThenBy(k Key[T], order ...Order) *Spec[T] {
+	sk := sortKey[T]{Key: k}
+	if len(order) > 0 {
+		sk.order = order[len(order)-1]
+	}
+	// Without NullsFirst or NullsLast, nulls sort as the smallest value, as NaN does in
+	// cmp.Compare: first ascending, last descending.
+	sk.nullsLast = sk.order == Desc
+	return &Spec[T]{keys: append(slices.Clone(s.keys), sk)}
+}
+
+// NullsLast returns a new Spec whose last key puts nulls after all other values, in either
+// order.
+func (s *Spec[T]) NullsLast() *Spec[T] {
+	return s.withNulls(true)
+}
+
+// NullsFirst returns a new Spec whose last key puts nulls before all other values, in either
+// order.
+func (s *Spec[T]) NullsFirst() *Spec[T] {
+	return s.withNulls(false)
+}
+
+func (s *Spec[T]) withNulls(last bool) *Spec[T] {
+	out := &Spec[T]{keys: slices.Clone(s.keys)}
+	if len(out.keys) > 0 {
+		out.keys[len(out.keys)-1].nullsLast = last
+	}
+	return out
+}
+
+// Compare is the Spec as a comparison function, for slices.SortFunc and friends.
+func (s *Spec[T]) Compare(a, b T) int {
+	for _, k := range s.keys {
+		if c := k.compareNulls(a, b); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func (k sortKey[T]) compareNulls(a, b T) int {
+	if k.null != nil {
+		an, bn := k.null(a), k.null(b)
+		switch {
+		case an && bn:
+			return 0
+		case an != bn:
+			// Null placement doesn't flip with the order.
+			if an == k.nullsLast {
+				return 1
+			}
+			return -1
+		}
+	}
+	c := k.compare(a, b)
+	if k.order == Desc {
+		return -c
+	}
+	return c
+}
+
+// Sort sorts s in place, keeping equal elements in their original order.
+func (s *Spec[T]) Sort(x []T) {
+	slices.SortStableFunc(x, s.Compare)
+}
+
+// Sorted returns a sorted copy.
+func (s *Spec[T]) Sorted(x []T) []T {
+	out := slices.Clone(x)
+	s.Sort(out)
+	return out
+}
+
+func (s *Spec[T]) IsSorted(x []T) bool {
+	return slices.IsSortedFunc(x, s.Compare)
+}
+
+////// string collation: byte order puts "Zebra" before "apple" and "file10" before "file2".
+// Folding case compares letters without their case; natural order compares runs of digits as
+// numbers. Without the x/text tables, accents are not folded ("é" still sorts after "z") and
+// neither are multi-letter folds like "ß" and "ss".
+
+type Collation struct {
+	FoldCase bool
+	Natural  bool
+}
+
+var (
+	Binary  = Collation{}
+	Folded  = Collation{FoldCase: true}
+	Natural = Collation{FoldCase: true, Natural: true}
+)
+
+// Compare orders a and b; strings that only differ in case or leading zeros are ordered by
+// their bytes last, so the result is still a total order.
+func (c Collation) Compare(a, b string) int {
+	if r := c.compare(a, b); r != 0 {
+		return r
+	}
+	return strings.Compare(a, b)
+}
+
+func (c Collation) compare(a, b string) int {
+	for a != "" && b != "" {
+		if c.Natural && isDigit(a[0]) && isDigit(b[0]) {
+			da, db := digitRun(a), digitRun(b)
+			if r := compareNumbers(a[:da], b[:db]); r != 0 {
+				return r
+			}
+			a, b = a[da:], b[db:]
+			continue
+		}
+		ra, na := utf8.DecodeRuneInString(a)
+		rb, nb := utf8.DecodeRuneInString(b)
+		if c.FoldCase {
+			ra, rb = fold(ra), fold(rb)
+		}
+		if ra != rb {
+			return cmp.Compare(ra, rb)
+		}
+		a, b = a[na:], b[nb:]
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+// fold maps a rune to one case. Going through upper case first makes variants like the Greek
+// final sigma 'ς' fold together with 'σ' and 'Σ'.
+func fold(r rune) rune {
+	return unicode.ToLower(unicode.ToUpper(r))
+}
+
+func isDigit(b byte) bool {
+	return '0' <= b && b <= '9'
+}
+
+func digitRun(s string) int {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return i
+}
+
+// compareNumbers compares digit strings of any length by value: without leading zeros, the
+// longer one is bigger, and equal lengths compare like strings.
+func compareNumbers(a, b string) int {
+	ta, tb := strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	if r := cmp.Compare(len(ta), len(tb)); r != 0 {
+		return r
+	}
+	return strings.Compare(ta, tb)
+}
+
+////// parsing: a spec like "age:desc,name" names the keys, left to right, each with an
+// optional direction and null placement, as in SQL's ORDER BY age DESC NULLS LAST, name.
+
+var (
+	ErrUnknownKey = errors.New("unknown sort key")
+	ErrEmptySpec  = errors.New("empty sort spec")
+)
+
+// ParseSpec builds a Spec from text, looking key names up in keys. Empty text, or an empty
+// key between commas, is ErrEmptySpec.
+func ParseSpec[T any](text string, keys map[string]Key[T]) (*Spec[T], error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, ErrEmptySpec
+	}
+	s := new(Spec[T])
+	for part := range strings.SplitSeq(text, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ":")
+		if fields[0] == "" {
+			return nil, fmt.Errorf("%w: missing key in %q", ErrEmptySpec, text)
+		}
+		k, ok := keys[strings.ToLower(fields[0])]
+		if !ok {
+			return nil, fmt.Errorf("%w %q (have %s)", ErrUnknownKey, fields[0], strings.Join(slices.Sorted(maps.Keys(keys)), ", "))
+		}
+		order := Asc
+		nulls := ""
+		for _, mod := range fields[1:] {
+			switch strings.ToLower(mod) {
+			case "asc":
+				order = Asc
+			case "desc":
+				order = Desc
+			case "nullsfirst", "nullslast":
+				nulls = strings.ToLower(mod)
+			default:
+				return nil, fmt.Errorf("sort key %q: unknown modifier %q", fields[0], mod)
+			}
+		}
+		s = s.ThenBy(k, order)
+		switch nulls {
+		case "nullsfirst":
+			s = s.NullsFirst()
+		case "nullslast":
+			s = s.NullsLast()
+		}
+	}
+	return s, nil
+}
+
+// SpecFlag is a flag.Value holding a parsed Spec.
+type SpecFlag[T any] struct {
+	Keys map[string]Key[T]
+	Spec *Spec[T]
+	text string
+}
+
+func (f *SpecFlag[T]) String() string {
+	return f.text
+}
+
+func (f *SpecFlag[T]) Set(text string) error {
+	s, err := ParseSpec(text, f.Keys)
+	if err != nil {
+		return err
+	}
+	f.Spec, f.text = s, text
+	return nil
+}
+
+type Person struct {
+	name  string
+	age   int
+	email *string
+}
+
+func (p Person) String() string {
+	email := "-"
+	if p.email != nil {
+		email = *p.email
+	}
+	return fmt.Sprintf("{%s %d %s}", p.name, p.age, email)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+var people = []Person{
+	{name: "Jax", age: 37, email: ptr("jax@example.com")},
+	{name: "TJ", age: 25},
+	{name: "Alex", age: 72, email: ptr("alex@example.com")},
+	{name: "alex", age: 37},
+	{name: "Bo", age: 25, email: ptr("bo@example.com")},
+}
+
+var personKeys = map[string]Key[Person]{
+	"name":  Text(func(p Person) string { return p.name }, Folded),
+	"age":   Field(func(p Person) int { return p.age }),
+	"email": Nullable(func(p Person) *string { return p.email }),
+}
+
+var sortFlag = SpecFlag[Person]{Keys: personKeys}
+
+func trySortSpecs() {
+	// trySortingByFunctions, as a Spec. Stable: TJ and Bo are both 25 and stay in input order.
+	byAge := By(func(p Person) int { return p.age })
+	fmt.Println(byAge.Sorted(people))
+
+	// Ties on age broken by name, descending.
+	name := Text(func(p Person) string { return p.name }, Folded)
+	fmt.Println(By(func(p Person) int { return p.age }).ThenBy(name, Desc).Sorted(people))
+
+	// Missing emails: first ascending and last descending by default, or wherever asked.
+	email := Nullable(func(p Person) *string { return p.email })
+	fmt.Println(ByKey(email).Sorted(people))
+	fmt.Println(ByKey(email).NullsLast().Sorted(people))
+	fmt.Println(ByKey(email, Desc).Sorted(people))
+	fmt.Println(ByKey(email, Desc).NullsFirst().Sorted(people))
+
+	// NaN has no place in an order; as a null it goes where the Spec says.
+	scores := []float64{2, math.NaN(), -1, 3}
+	fmt.Println(By(func(f float64) float64 { return f }).NullsLast().Sorted(scores))
+
+	// The Spec is a plain comparison func, for slices.IsSortedFunc, BinarySearchFunc, ...
+	sorted := byAge.Sorted(people)
+	fmt.Println(byAge.IsSorted(sorted), byAge.IsSorted(people))
+	i, found := slices.BinarySearchFunc(sorted, Person{age: 37}, byAge.Compare)
+	fmt.Println(i, found)
+}
+
+func tryCollation() {
+	files := []string{"file10.txt", "File2.txt", "file1.txt", "file02.txt", "FILE2.txt", "file2.txt", "apple", "Zebra", "file", "file1b", "file1a"}
+	for _, c := range []Collation{Binary, Folded, Natural} {
+		s := slices.Clone(files)
+		slices.SortStableFunc(s, c.Compare)
+		fmt.Printf("%+v: %v\n", c, s)
+	}
+	// Digit runs of any length, and leading zeros only break ties.
+	fmt.Println(Natural.Compare("v99999999999999999999", "v100000000000000000000"), Natural.Compare("a01", "a1"), Natural.Compare("a1", "a01"))
+	// Case only matters when nothing else differs.
+	fmt.Println(Binary.Compare("apple", "Zebra"), Folded.Compare("apple", "Zebra"), Folded.compare("ΣΊΣΥΦΟΣ", "σίσυφος"))
+}
+
+func tryParsing() {
+	for _, text := range []string{"age:desc,name", "email:nullslast,name", "AGE, Name:desc", "height", "age:sideways", "", "age,,name"} {
+		s, err := ParseSpec(text, personKeys)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		fmt.Printf("%-22s %v\n", text, s.Sorted(people))
+	}
+
+	// And through the flag package, as in 8-extra-part-2.go.
+	fs := flag.NewFlagSet("people", flag.ContinueOnError)
+	f := SpecFlag[Person]{Keys: personKeys}
+	fs.Var(&f, "sort", "sort order, like age:desc,name")
+	if err := fs.Parse([]string{"-sort", "age,name:desc"}); err != nil {
+		fmt.Println(err)
+	}
+	fmt.Println(f.String(), f.Spec.Sorted(people))
+}
+
+func main() {
+	// go run 33-sort-spec.go -sort age:desc,name
+	flag.Var(&sortFlag, "sort", "sort people by, like age:desc,name")
+	flag.Parse()
+	if sortFlag.Spec != nil {
+		fmt.Println(sortFlag.Spec.Sorted(people))
+		return
+	}
+
+	// building specs
+	trySortSpecs()
+
+	// string collation
+	tryCollation()
+
+	// parsing specs
+	tryParsing()
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func names(ps []Person) []string {
+	var out []string
+	for _, p := range ps {
+		out = append(out, p.name)
+	}
+	return out
+}
+
+// Deriving two specs from one base must not let them share or change the base's keys.
+func TestSpecBuildersDoNotShareKeys(t *testing.T) {
+	name := Text(func(p Person) string { return p.name }, Folded)
+	base := By(func(p Person) int { return p.age })
+	desc := base.ThenBy(name, Desc)
+	asc := base.ThenBy(name)
+	last := asc.NullsLast()
+
+	if len(base.keys) != 1 || len(desc.keys) != 2 || len(asc.keys) != 2 || len(last.keys) != 2 {
+		t.Fatalf("key counts: base=%d desc=%d asc=%d last=%d", len(base.keys), len(desc.keys), len(asc.keys), len(last.keys))
+	}
+	if asc.keys[1].nullsLast {
+		t.Error("NullsLast changed the spec it was called on")
+	}
+
+	// Jax and alex are both 37.
+	if got, want := names(desc.Sorted(people)), []string{"TJ", "Bo", "Jax", "alex", "Alex"}; !slices.Equal(got, want) {
+		t.Errorf("desc = %v, want %v", got, want)
+	}
+	if got, want := names(asc.Sorted(people)), []string{"Bo", "TJ", "alex", "Jax", "Alex"}; !slices.Equal(got, want) {
+		t.Errorf("asc = %v, want %v", got, want)
+	}
+	if got, want := names(base.Sorted(people)), []string{"TJ", "Bo", "Jax", "alex", "Alex"}; !slices.Equal(got, want) {
+		t.Errorf("base = %v, want %v", got, want)
+	}
+}
+
+func TestParseSpec(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    []string
+		wantErr error
+	}{
+		{"age:desc,name", []string{"Alex", "alex", "Jax", "Bo", "TJ"}, nil},
+		{"email:nullslast,name", []string{"Alex", "Bo", "Jax", "alex", "TJ"}, nil},
+		{"email:desc:nullsfirst", []string{"TJ", "alex", "Jax", "Bo", "Alex"}, nil},
+		{"", nil, ErrEmptySpec},
+		{"  ", nil, ErrEmptySpec},
+		{"age,,name", nil, ErrEmptySpec},
+		{"height", nil, ErrUnknownKey},
+	}
+	for _, tt := range tests {
+		s, err := ParseSpec(tt.text, personKeys)
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ParseSpec(%q) error = %v, want %v", tt.text, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseSpec(%q): %v", tt.text, err)
+			continue
+		}
+		if got := names(s.Sorted(people)); !slices.Equal(got, tt.want) {
+			t.Errorf("ParseSpec(%q) sorts %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestNaturalCollation(t *testing.T) {
+	files := []string{"file10", "file2", "File1", "file02"}
+	slices.SortStableFunc(files, Natural.Compare)
+	if want := []string{"File1", "file02", "file2", "file10"}; !slices.Equal(files, want) {
+		t.Errorf("got %v, want %v", files, want)
+	}
+}
@@ -0,0 +1,423 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+////// tic-tac-toe: createTicTacToeBoard (3-more-types.go) only places hard-coded moves on a [][]string.
+// Here the board becomes a proper type that knows whose turn it is, rejects illegal moves,
+// detects a winner or a draw and can take moves back.
+
+// Mark is what a cell holds: nobody, X or O. X always moves first.
+type Mark int
+
+const (
+	Empty Mark = iota
+	X
+	O
+)
+
+var markName = map[Mark]string{
+	Empty: "_",
+	X:     "X",
+	O:     "O",
+}
+
+func (m Mark) String() string {
+	return markName[m]
+}
+
+// Opponent returns the other player; Empty has no opponent.
+func (m Mark) Opponent() Mark {
+	switch m {
+	case X:
+		return O
+	case O:
+		return X
+	default:
+		return Empty
+	}
+}
+
+// Move is a zero-based (row, col) position on the board.
+type Move struct {
+	Row, Col int
+}
+
+func (mv Move) String() string {
+	return fmt.Sprintf("(%d, %d)", mv.Row, mv.Col)
+}
+
+var (
+	ErrOutOfBounds = errors.New("move is outside the board")
+	ErrOccupied    = errors.New("cell is already taken")
+	ErrGameOver    = errors.New("game is already over")
+	ErrNoMoves     = errors.New("no moves to undo")
+)
+
+// Board is a 3x3 tic-tac-toe board. The zero value is an empty board with X to move.
+type Board struct {
+	cells   [3][3]Mark
+	history []Move
+}
+
+// lines lists every row, column and diagonal that wins the game.
+var lines = [8][3]Move{
+	{{0, 0}, {0, 1}, {0, 2}},
+	{{1, 0}, {1, 1}, {1, 2}},
+	{{2, 0}, {2, 1}, {2, 2}},
+	{{0, 0}, {1, 0}, {2, 0}},
+	{{0, 1}, {1, 1}, {2, 1}},
+	{{0, 2}, {1, 2}, {2, 2}},
+	{{0, 0}, {1, 1}, {2, 2}},
+	{{0, 2}, {1, 1}, {2, 0}},
+}
+
+// At returns the mark in the given cell.
+func (b *Board) At(row, col int) Mark {
+	return b.cells[row][col]
+}
+
+// Turn returns the player who moves next, derived from the number of moves played.
+func (b *Board) Turn() Mark {
+	if len(b.history)%2 == 0 {
+		return X
+	}
+	return O
+}
+
+// Winner returns X or O if someone has three in a row, Empty otherwise.
+func (b *Board) Winner() Mark {
+	for _, m := range []Mark{X, O} {
+		if b.wins(m) {
+			return m
+		}
+	}
+	return Empty
+}
+
+// wins reports whether m has at least one line.
+func (b *Board) wins(m Mark) bool {
+	for _, l := range lines {
+		if m == b.cells[l[0].Row][l[0].Col] && m == b.cells[l[1].Row][l[1].Col] && m == b.cells[l[2].Row][l[2].Col] {
+			return true
+		}
+	}
+	return false
+}
+
+// Full reports whether every cell is taken.
+func (b *Board) Full() bool {
+	return len(b.history) == 9
+}
+
+// Draw reports whether the board is full and nobody won.
+func (b *Board) Draw() bool {
+	return b.Full() && b.Winner() == Empty
+}
+
+// Over reports whether the game has finished, by a win or a draw.
+func (b *Board) Over() bool {
+	return b.Winner() != Empty || b.Full()
+}
+
+// Play places the mark of the player to move in the given cell.
+func (b *Board) Play(mv Move) error {
+	if b.Over() {
+		return ErrGameOver
+	}
+	if mv.Row < 0 || mv.Row > 2 || mv.Col < 0 || mv.Col > 2 {
+		return fmt.Errorf("%v: %w", mv, ErrOutOfBounds)
+	}
+	if b.cells[mv.Row][mv.Col] != Empty {
+		return fmt.Errorf("%v: %w", mv, ErrOccupied)
+	}
+	b.cells[mv.Row][mv.Col] = b.Turn()
+	b.history = append(b.history, mv)
+	return nil
+}
+
+// Undo takes back the last move.
+func (b *Board) Undo() error {
+	if len(b.history) == 0 {
+		return ErrNoMoves
+	}
+	last := b.history[len(b.history)-1]
+	b.history = b.history[:len(b.history)-1]
+	b.cells[last.Row][last.Col] = Empty
+	return nil
+}
+
+// Moves returns the empty cells, in row-major order. A finished game has no moves.
+func (b *Board) Moves() []Move {
+	if b.Over() {
+		return nil
+	}
+	var moves []Move
+	for r := 0; r < 3; r++ {
+		for c := 0; c < 3; c++ {
+			if b.cells[r][c] == Empty {
+				moves = append(moves, Move{r, c})
+			}
+		}
+	}
+	return moves
+}
+
+// String renders the board the same way createTicTacToeBoard prints it: one row per line.
+func (b *Board) String() string {
+	var sb strings.Builder
+	for r := 0; r < 3; r++ {
+		row := make([]string, 3)
+		for c := 0; c < 3; c++ {
+			row[c] = b.cells[r][c].String()
+		}
+		sb.WriteString(strings.Join(row, " "))
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// ParseBoard reads a board written by String. Whitespace and '/' are ignored, so compact
+// strings like "X_O/_X_/__O" work too. Because the move order is lost,
+// the history is rebuilt X, O, X, ... which is enough for Turn and Undo to stay consistent; a
+// winning move is put last. Positions no game can reach, like a win followed by more moves, are
+// rejected.
+func ParseBoard(s string) (*Board, error) {
+	var xs, ys []Move
+	i := 0
+	for _, ch := range s {
+		switch ch {
+		case ' ', '\t', '\n', '\r', '/':
+			continue
+		}
+		if i >= 9 {
+			return nil, fmt.Errorf("parse board: more than 9 cells")
+		}
+		mv := Move{i / 3, i % 3}
+		switch ch {
+		case 'X', 'x':
+			xs = append(xs, mv)
+		case 'O', 'o':
+			ys = append(ys, mv)
+		case '_', '.', '-':
+		default:
+			return nil, fmt.Errorf("parse board: unexpected %q at cell %d", ch, i)
+		}
+		i++
+	}
+	if i != 9 {
+		return nil, fmt.Errorf("parse board: got %d cells, want 9", i)
+	}
+	if len(xs) != len(ys) && len(xs) != len(ys)+1 {
+		return nil, fmt.Errorf("parse board: %d X and %d O is not a reachable position", len(xs), len(ys))
+	}
+
+	b := &Board{}
+	for _, mv := range xs {
+		b.cells[mv.Row][mv.Col] = X
+	}
+	for _, mv := range ys {
+		b.cells[mv.Row][mv.Col] = O
+	}
+	if b.wins(X) && b.wins(O) {
+		return nil, fmt.Errorf("parse board: X and O cannot both have three in a row")
+	}
+	// The winner moved last, so the win goes with the extra X or the matching O, and one of their
+	// cells must complete every line they have: it becomes the last move of the history.
+	if w := b.Winner(); w != Empty {
+		moves := &xs
+		if w == O {
+			moves = &ys
+		}
+		if (w == X) != (len(xs) > len(ys)) {
+			return nil, fmt.Errorf("parse board: %v won but %v moved last", w, w.Opponent())
+		}
+		last := slices.IndexFunc(*moves, func(mv Move) bool {
+			b.cells[mv.Row][mv.Col] = Empty
+			defer func() { b.cells[mv.Row][mv.Col] = w }()
+			return !b.wins(w)
+		})
+		if last < 0 {
+			return nil, fmt.Errorf("parse board: moves were played after %v won", w)
+		}
+		mv := (*moves)[last]
+		*moves = append(slices.Delete(*moves, last, last+1), mv)
+	}
+
+	b = &Board{}
+	for n := 0; n < len(xs)+len(ys); n++ {
+		var mv Move
+		if n%2 == 0 {
+			mv = xs[n/2]
+		} else {
+			mv = ys[n/2]
+		}
+		b.cells[mv.Row][mv.Col] = b.Turn()
+		b.history = append(b.history, mv)
+	}
+	return b, nil
+}
+
+////// minimax with alpha-beta pruning: the AI tries every move, assumes the opponent answers with
+// their best move, and keeps the move with the best guaranteed outcome. Alpha-beta skips branches
+// that can no longer change the result.
+
+// score rates a finished board for player me. Quicker wins and slower losses score better.
+func (b *Board) score(me Mark, depth int) int {
+	switch b.Winner() {
+	case me:
+		return 10 - depth
+	case me.Opponent():
+		return depth - 10
+	}
+	return 0
+}
+
+func (b *Board) alphaBeta(me Mark, depth, alpha, beta int) int {
+	if b.Over() {
+		return b.score(me, depth)
+	}
+	maximizing := b.Turn() == me
+	for _, mv := range b.Moves() {
+		b.Play(mv)
+		v := b.alphaBeta(me, depth+1, alpha, beta)
+		b.Undo()
+		if maximizing {
+			alpha = max(alpha, v)
+		} else {
+			beta = min(beta, v)
+		}
+		if alpha >= beta {
+			break
+		}
+	}
+	if maximizing {
+		return alpha
+	}
+	return beta
+}
+
+// BestMove returns the best move for the player to move. It fails if the game is over.
+func (b *Board) BestMove() (Move, error) {
+	moves := b.Moves()
+	if len(moves) == 0 {
+		return Move{}, ErrGameOver
+	}
+	me := b.Turn()
+	best, bestScore := moves[0], -100
+	for _, mv := range moves {
+		b.Play(mv)
+		v := b.alphaBeta(me, 1, -100, 100)
+		b.Undo()
+		if v > bestScore {
+			best, bestScore = mv, v
+		}
+	}
+	return best, nil
+}
+
+////// game loop: `go run 9-tictactoe.go play tictactoe` plays a human (X) against the AI (O) in the terminal.
+func playTicTacToe() {
+	b := &Board{}
+	in := bufio.NewScanner(os.Stdin)
+	fmt.Println("You are X. Enter moves as \"row col\" (0-2), \"u\" to undo, \"q\" to quit.")
+	for !b.Over() {
+		fmt.Print(b)
+		if b.Turn() == O {
+			mv, _ := b.BestMove()
+			b.Play(mv)
+			fmt.Println("AI plays", mv)
+			continue
+		}
+
+		fmt.Print("> ")
+		if !in.Scan() {
+			return
+		}
+		fields := strings.Fields(in.Text())
+		switch {
+		case len(fields) == 1 && fields[0] == "q":
+			return
+		case len(fields) == 1 && fields[0] == "u":
+			// Take back the AI's reply and our own move.
+			b.Undo()
+			b.Undo()
+			continue
+		case len(fields) != 2:
+			fmt.Println("expected \"row col\"")
+			continue
+		}
+		r, err1 := strconv.Atoi(fields[0])
+		c, err2 := strconv.Atoi(fields[1])
+		if err1 != nil || err2 != nil {
+			fmt.Println("row and col must be numbers")
+			continue
+		}
+		if err := b.Play(Move{r, c}); err != nil {
+			fmt.Println(err)
+		}
+	}
+
+	fmt.Print(b)
+	if w := b.Winner(); w != Empty {
+		fmt.Println(w, "wins!")
+	} else {
+		fmt.Println("It's a draw.")
+	}
+}
+
+func tryTicTacToe() {
+	// The same moves createTicTacToeBoard hard-codes, now checked and played in turn.
+	b := &Board{}
+	for _, mv := range []Move{{0, 0}, {2, 2}, {1, 2}, {1, 0}, {0, 2}} {
+		if err := b.Play(mv); err != nil {
+			fmt.Println(err)
+		}
+	}
+	fmt.Print(b)
+	fmt.Println("turn:", b.Turn(), "winner:", b.Winner())
+
+	// Illegal moves come back as errors that can be checked with errors.Is.
+	err := b.Play(Move{0, 0})
+	fmt.Println(err, errors.Is(err, ErrOccupied))
+	fmt.Println(b.Play(Move{3, 1}))
+
+	// X threatens to win on (0, 1); the AI playing O blocks it.
+	mv, _ := b.BestMove()
+	fmt.Println("best move for", b.Turn(), "is", mv)
+	b.Play(mv)
+	fmt.Print(b)
+	fmt.Println("winner:", b.Winner())
+
+	// Boards round-trip through their string form.
+	parsed, err := ParseBoard("X O X / _ X _ / O _ O")
+	fmt.Println(err)
+	fmt.Print(parsed)
+	fmt.Println("turn:", parsed.Turn())
+
+	// Two perfect players always draw.
+	selfPlay := &Board{}
+	for !selfPlay.Over() {
+		mv, _ := selfPlay.BestMove()
+		selfPlay.Play(mv)
+	}
+	fmt.Print(selfPlay)
+	fmt.Println("draw:", selfPlay.Draw())
+}
+
+func main() {
+	if len(os.Args) > 2 && os.Args[1] == "play" && os.Args[2] == "tictactoe" {
+		playTicTacToe()
+		return
+	}
+
+	// tic-tac-toe
+	tryTicTacToe()
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseBoard(t *testing.T) {
+	tests := []struct {
+		board string
+		err   string // "" for a valid board
+	}{
+		{"___/___/___", ""},
+		{"X__/___/___", ""},
+		{"XOX/_X_/O_O", ""},
+		{"XXX/OO_/___", ""},
+		{"XXX/OOX/OXO", ""}, // X wins, with two more X played before
+		{"OOO/XX_/X__", ""},
+		{"XOX/OXO/OXX", ""}, // a diagonal on the ninth move
+		{"XXX/OXO/OXO", ""}, // two lines at once, both through the last move
+		{"XOX/OXO/XOX", ""}, // both diagonals and nothing else left
+		{"x.o/-x-/o_x", ""}, // lower case and other blanks
+		{"X O X\n_ X _\nO _ O\n", ""},
+		{"XX_/___/___", "not a reachable position"},
+		{"O__/___/___", "not a reachable position"},
+		{"XXX/OOO/___", "cannot both"},
+		{"XXX/OO_/O__", "X won but O moved last"},
+		{"OOO/XX_/XX_", "O won but X moved last"},
+		{"OOO/XX_/X_X", "O won but X moved last"},
+		{"XXX/___/___", "not a reachable position"},
+		{"XXX/O_O/___", ""},
+		{"XXX/OOX/OO_", "X won but O moved last"},
+		{"XXO/XO_/OX_", "O won but X moved last"},
+		{"XXX/OXO/O_O", "X won but O moved last"},
+		{"XX?/___/___", "unexpected"},
+		{"XO/___/___", "got 8 cells"},
+		{"XO_/___/___/_", "more than 9"},
+	}
+	for _, tt := range tests {
+		b, err := ParseBoard(tt.board)
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("ParseBoard(%q): %v", tt.board, err)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("ParseBoard(%q) = %v, want an error about %q", tt.board, err, tt.err)
+		case err == nil:
+			// The rebuilt history replays into the same board, and undoing the winning move
+			// gives a position still in play.
+			again, _ := ParseBoard(b.String())
+			if again.String() != b.String() {
+				t.Errorf("ParseBoard(%q) round trip: %q", tt.board, again.String())
+			}
+			if w := b.Winner(); w != Empty {
+				b.Undo()
+				if b.Winner() != Empty || b.Turn() != w {
+					t.Errorf("ParseBoard(%q): undoing the last move leaves\n%vwith winner %v and %v to move", tt.board, b, b.Winner(), b.Turn())
+				}
+			}
+		}
+	}
+}
+
+func TestWinner(t *testing.T) {
+	tests := []struct {
+		board  string
+		winner Mark
+		draw   bool
+		over   bool
+	}{
+		{"___/___/___", Empty, false, false},
+		{"XOX/_X_/O_O", Empty, false, false},
+		{"XXX/OO_/___", X, false, true},
+		{"X_O/XO_/O_X", O, false, true},
+		{"OX_/OX_/_X_", X, false, true},
+		{"XOX/OXO/OXX", X, false, true}, // a win on a full board is not a draw
+		{"XXO/OOX/XOX", Empty, true, true},
+	}
+	for _, tt := range tests {
+		b, err := ParseBoard(tt.board)
+		if err != nil {
+			t.Fatalf("ParseBoard(%q): %v", tt.board, err)
+		}
+		if w, d, o := b.Winner(), b.Draw(), b.Over(); w != tt.winner || d != tt.draw || o != tt.over {
+			t.Errorf("%s: winner %v, draw %v, over %v; want %v, %v, %v", tt.board, w, d, o, tt.winner, tt.draw, tt.over)
+		}
+		if tt.over && b.Moves() != nil {
+			t.Errorf("%s: finished game has moves %v", tt.board, b.Moves())
+		}
+	}
+}
+
+func TestPlay(t *testing.T) {
+	b, _ := ParseBoard("XX_/OO_/___")
+	if err := b.Play(Move{0, 0}); !errors.Is(err, ErrOccupied) {
+		t.Errorf("Play on a taken cell = %v, want ErrOccupied", err)
+	}
+	if err := b.Play(Move{3, 0}); !errors.Is(err, ErrOutOfBounds) {
+		t.Errorf("Play off the board = %v, want ErrOutOfBounds", err)
+	}
+	if err := b.Play(Move{0, 2}); err != nil || b.Winner() != X {
+		t.Fatalf("Play(0, 2) = %v, winner %v; want X to win", err, b.Winner())
+	}
+	if err := b.Play(Move{2, 2}); !errors.Is(err, ErrGameOver) {
+		t.Errorf("Play after a win = %v, want ErrGameOver", err)
+	}
+	if _, err := b.BestMove(); !errors.Is(err, ErrGameOver) {
+		t.Errorf("BestMove after a win = %v, want ErrGameOver", err)
+	}
+	for range 5 {
+		b.Undo()
+	}
+	if err := b.Undo(); !errors.Is(err, ErrNoMoves) {
+		t.Errorf("Undo on an empty board = %v, want ErrNoMoves", err)
+	}
+}
+
+func TestBestMove(t *testing.T) {
+	tests := []struct {
+		name  string
+		board string
+		want  []Move // any of these
+	}{
+		{"X wins in one", "XX_/OO_/___", []Move{{0, 2}}},
+		// Winning beats blocking: O could stop X on the top row, but takes the middle row.
+		{"O wins rather than blocks", "XX_/OO_/X__", []Move{{1, 2}}},
+		{"O blocks a row", "XX_/_O_/___", []Move{{0, 2}}},
+		{"X blocks a column", "XO_/_O_/__X", []Move{{2, 1}}},
+		{"O blocks a diagonal", "X__/_X_/O__", []Move{{2, 2}}},
+		// X threatens both (0, 1) and (2, 1): O can only lose, but blocks one and loses later.
+		{"the demo", "X_X/O_X/__O", []Move{{0, 1}}},
+		// Against a corner opening only the centre holds the draw.
+		{"answer a corner", "X__/___/___", []Move{{1, 1}}},
+		{"one cell left", "XOX/XOO/OX_", []Move{{2, 2}}},
+	}
+	for _, tt := range tests {
+		b, err := ParseBoard(tt.board)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		before := b.String()
+		mv, err := b.BestMove()
+		if err != nil || !contains(tt.want, mv) {
+			t.Errorf("%s: BestMove = %v, %v; want one of %v", tt.name, mv, err, tt.want)
+		}
+		if b.String() != before {
+			t.Errorf("%s: BestMove changed the board to\n%v", tt.name, b)
+		}
+	}
+}
+
+func contains(moves []Move, mv Move) bool {
+	for _, m := range moves {
+		if m == mv {
+			return true
+		}
+	}
+	return false
+}
+
+// Perfect play from the empty board is a draw.
+func TestSelfPlay(t *testing.T) {
+	b := &Board{}
+	for !b.Over() {
+		mv, err := b.BestMove()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := b.Play(mv); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !b.Draw() {
+		t.Errorf("self play ended\n%vwith winner %v, want a draw", b, b.Winner())
+	}
+}